@@ -0,0 +1,4 @@
+// Package petstore 是 openapi-gen 根据 testdata/petstore.yaml 生成的示例客户端。
+package petstore
+
+//go:generate go run ../.. -spec ../../testdata/petstore.yaml -o petstore.go
@@ -0,0 +1,357 @@
+// Code generated by openapi-gen. DO NOT EDIT.
+
+package petstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bookiu/gopkg/httpclient"
+)
+
+// DefaultBaseURL 规范中声明的第一个服务地址
+const DefaultBaseURL = "https://petstore.example.com/v1"
+
+// Client Petstore 客户端，认证、响应处理与观测由 httpclient.Client 的配置决定
+type Client struct {
+	client  httpclient.Client
+	baseURL string
+}
+
+// NewClient 创建客户端，baseURL 为空时请求地址只包含路径，可通过 httpclient.Config.BaseURL 指定服务地址
+func NewClient(baseURL string, client httpclient.Client) *Client {
+	return &Client{client: client, baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// Error 对应 schema Error
+type Error struct {
+	Code    int32  `json:"code"`
+	Message string `json:"message"`
+}
+
+// NewPet 对应 schema NewPet
+type NewPet struct {
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Birthday   *time.Time        `json:"birthday,omitempty"`
+	Name       string            `json:"name"`
+	Status     *PetStatus        `json:"status,omitempty"`
+	Tag        *string           `json:"tag,omitempty"`
+}
+
+// PetOwner 内联 schema
+type PetOwner struct {
+	Name *string `json:"name,omitempty"`
+}
+
+// Pet 对应 schema Pet
+type Pet struct {
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Birthday   *time.Time        `json:"birthday,omitempty"`
+	ID         int64             `json:"id"`
+	Name       string            `json:"name"`
+	Owner      *PetOwner         `json:"owner,omitempty"`
+	Status     *PetStatus        `json:"status,omitempty"`
+	Tag        *string           `json:"tag,omitempty"`
+}
+
+// PetStatus Pet status in the store
+type PetStatus string
+
+const (
+	PetStatusAvailable PetStatus = "available"
+	PetStatusPending   PetStatus = "pending"
+	PetStatusSold      PetStatus = "sold"
+)
+
+// Pets 对应 schema Pets
+type Pets []Pet
+
+// ListPetsParams GET /pets 的查询与请求头参数
+type ListPetsParams struct {
+	// Limit How many items to return at one time
+	Limit *int32
+	// Tags query 参数 tags
+	Tags []string
+	// Status query 参数 status
+	Status *PetStatus
+	// XRequestID header 参数 X-Request-ID
+	XRequestID *string
+}
+
+// ListPetsDefaultError GET /pets 返回 default 时的错误：Unexpected error
+type ListPetsDefaultError struct {
+	StatusCode int
+	Body       Error
+	// DecodeErr 响应体无法解码为 Body 时的错误
+	DecodeErr error
+}
+
+func (e *ListPetsDefaultError) Error() string {
+	msg := fmt.Sprintf("GET /pets: unexpected status code: %d", e.StatusCode)
+	if e.DecodeErr != nil {
+		msg += ": " + e.DecodeErr.Error()
+	}
+	return msg
+}
+
+func (e *ListPetsDefaultError) Unwrap() error {
+	return e.DecodeErr
+}
+
+// CreatePetConflictError POST /pets 返回 409 时的错误：Pet already exists
+type CreatePetConflictError struct {
+	StatusCode int
+	Body       Error
+	// DecodeErr 响应体无法解码为 Body 时的错误
+	DecodeErr error
+}
+
+func (e *CreatePetConflictError) Error() string {
+	msg := fmt.Sprintf("POST /pets: unexpected status code: %d", e.StatusCode)
+	if e.DecodeErr != nil {
+		msg += ": " + e.DecodeErr.Error()
+	}
+	return msg
+}
+
+func (e *CreatePetConflictError) Unwrap() error {
+	return e.DecodeErr
+}
+
+// GetPetNotFoundError GET /pets/{petId} 返回 404 时的错误：Pet not found
+type GetPetNotFoundError struct {
+	StatusCode int
+	Body       Error
+	// DecodeErr 响应体无法解码为 Body 时的错误
+	DecodeErr error
+}
+
+func (e *GetPetNotFoundError) Error() string {
+	msg := fmt.Sprintf("GET /pets/{petId}: unexpected status code: %d", e.StatusCode)
+	if e.DecodeErr != nil {
+		msg += ": " + e.DecodeErr.Error()
+	}
+	return msg
+}
+
+func (e *GetPetNotFoundError) Unwrap() error {
+	return e.DecodeErr
+}
+
+// GetPetStatus5XXError GET /pets/{petId} 返回 5XX 时的错误：Server error
+type GetPetStatus5XXError struct {
+	StatusCode int
+}
+
+func (e *GetPetStatus5XXError) Error() string {
+	return fmt.Sprintf("GET /pets/{petId}: unexpected status code: %d", e.StatusCode)
+}
+
+// UploadPhotoResponse 内联 schema
+type UploadPhotoResponse struct {
+	Size *int64 `json:"size,omitempty"`
+	URL  string `json:"url"`
+}
+
+// ListPets List all pets
+//
+// GET /pets
+func (c *Client) ListPets(ctx context.Context, params *ListPetsParams, opts ...httpclient.RequestOption) (Pets, error) {
+	var result Pets
+	u := c.baseURL + "/pets"
+	q := url.Values{}
+	if params != nil {
+		if params.Limit != nil {
+			q.Add("limit", formatParam(*params.Limit))
+		}
+		for _, v := range params.Tags {
+			q.Add("tags", formatParam(v))
+		}
+		if params.Status != nil {
+			q.Add("status", formatParam(*params.Status))
+		}
+	}
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	req, err := httpclient.NewRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return result, err
+	}
+	options := []httpclient.RequestOption{
+		httpclient.WithRoute("GET /pets"),
+		httpclient.WithErrorHandler(responseFunc(func(resp *http.Response, _ interface{}) error {
+			e := &ListPetsDefaultError{StatusCode: resp.StatusCode}
+			e.DecodeErr = decodeJSON(resp, &e.Body)
+			return e
+		})),
+	}
+	if params != nil {
+		if params.XRequestID != nil {
+			options = append(options, httpclient.WithHeader("X-Request-ID", formatParam(*params.XRequestID)))
+		}
+	}
+	options = append(options, opts...)
+
+	err = c.client.Do(ctx, req, &result, options...)
+	return result, err
+}
+
+// CreatePet Create a pet
+//
+// POST /pets
+func (c *Client) CreatePet(ctx context.Context, body *NewPet, opts ...httpclient.RequestOption) (*Pet, error) {
+	u := c.baseURL + "/pets"
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := httpclient.NewRequest(ctx, http.MethodPost, u, data)
+	if err != nil {
+		return nil, err
+	}
+	options := []httpclient.RequestOption{
+		httpclient.WithRoute("POST /pets"),
+		httpclient.WithContentType("application/json"),
+		httpclient.WithStatusHandler(201, responseFunc(decodeJSON)),
+		httpclient.WithStatusHandler(409, responseFunc(func(resp *http.Response, _ interface{}) error {
+			e := &CreatePetConflictError{StatusCode: resp.StatusCode}
+			e.DecodeErr = decodeJSON(resp, &e.Body)
+			return e
+		})),
+	}
+	options = append(options, opts...)
+
+	var result Pet
+	if err := c.client.Do(ctx, req, &result, options...); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetPet Info for a specific pet
+//
+// GET /pets/{petId}
+func (c *Client) GetPet(ctx context.Context, petID int64, opts ...httpclient.RequestOption) (*Pet, error) {
+	u := c.baseURL + "/pets/" + url.PathEscape(formatParam(petID))
+	req, err := httpclient.NewRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	options := []httpclient.RequestOption{
+		httpclient.WithRoute("GET /pets/{petId}"),
+		httpclient.WithStatusHandler(404, responseFunc(func(resp *http.Response, _ interface{}) error {
+			e := &GetPetNotFoundError{StatusCode: resp.StatusCode}
+			e.DecodeErr = decodeJSON(resp, &e.Body)
+			return e
+		})),
+		httpclient.WithErrorHandler(responseFunc(func(resp *http.Response, _ interface{}) error {
+			switch {
+			case resp.StatusCode >= 500:
+				return &GetPetStatus5XXError{StatusCode: resp.StatusCode}
+			}
+			return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		})),
+	}
+	options = append(options, opts...)
+
+	var result Pet
+	if err := c.client.Do(ctx, req, &result, options...); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// DeletePet DELETE /pets/{petId}
+//
+// Deprecated: 规范中已标记为废弃
+func (c *Client) DeletePet(ctx context.Context, petID int64, opts ...httpclient.RequestOption) error {
+	u := c.baseURL + "/pets/" + url.PathEscape(formatParam(petID))
+	req, err := httpclient.NewRequest(ctx, http.MethodDelete, u, nil)
+	if err != nil {
+		return err
+	}
+	options := []httpclient.RequestOption{
+		httpclient.WithRoute("DELETE /pets/{petId}"),
+		httpclient.WithStatusHandler(204, responseFunc(decodeJSON)),
+	}
+	options = append(options, opts...)
+
+	return c.client.Do(ctx, req, nil, options...)
+}
+
+// UploadPhoto PUT /pets/{petId}/photos
+func (c *Client) UploadPhoto(ctx context.Context, petID int64, body io.Reader, opts ...httpclient.RequestOption) (*UploadPhotoResponse, error) {
+	u := c.baseURL + "/pets/" + url.PathEscape(formatParam(petID)) + "/photos"
+	req, err := httpclient.NewRequest(ctx, http.MethodPut, u, body)
+	if err != nil {
+		return nil, err
+	}
+	options := []httpclient.RequestOption{
+		httpclient.WithRoute("PUT /pets/{petId}/photos"),
+		httpclient.WithContentType("image/png"),
+	}
+	options = append(options, opts...)
+
+	var result UploadPhotoResponse
+	if err := c.client.Do(ctx, req, &result, options...); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetStats GET /stats
+func (c *Client) GetStats(ctx context.Context, opts ...httpclient.RequestOption) (map[string]int64, error) {
+	var result map[string]int64
+	u := c.baseURL + "/stats"
+	req, err := httpclient.NewRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return result, err
+	}
+	options := []httpclient.RequestOption{
+		httpclient.WithRoute("GET /stats"),
+	}
+	options = append(options, opts...)
+
+	err = c.client.Do(ctx, req, &result, options...)
+	return result, err
+}
+
+// responseFunc 将函数转为 httpclient.ResponseHandler
+type responseFunc func(resp *http.Response, result interface{}) error
+
+func (f responseFunc) Handle(resp *http.Response, result interface{}) error {
+	return f(resp, result)
+}
+
+// decodeJSON 将响应体解码到 v，v 为 nil 或响应体为空时忽略
+func decodeJSON(resp *http.Response, v interface{}) error {
+	if v == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil && err != io.EOF {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// formatParam 将路径、查询与请求头参数转为字符串
+func formatParam(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	case interface{ MarshalText() ([]byte, error) }:
+		text, _ := v.MarshalText()
+		return string(text)
+	default:
+		return fmt.Sprint(v)
+	}
+}
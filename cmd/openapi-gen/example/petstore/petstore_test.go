@@ -0,0 +1,166 @@
+package petstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bookiu/gopkg/httpclient"
+)
+
+func newPetServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /pets", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"code":401,"message":"unauthorized"}`))
+			return
+		}
+		q := r.URL.Query()
+		pets := Pets{{ID: 1, Name: q.Get("limit") + "|" + q.Get("status") + "|" + r.Header.Get("X-Request-ID")}}
+		for _, tag := range q["tags"] {
+			pets = append(pets, Pet{Name: tag})
+		}
+		_ = json.NewEncoder(w).Encode(pets)
+	})
+	mux.HandleFunc("POST /pets", func(w http.ResponseWriter, r *http.Request) {
+		var pet NewPet
+		_ = json.NewDecoder(r.Body).Decode(&pet)
+		if pet.Name == "exists" {
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"code":1,"message":"pet exists"}`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(Pet{ID: 10, Name: pet.Name, Status: pet.Status})
+	})
+	mux.HandleFunc("GET /pets/{petId}", func(w http.ResponseWriter, r *http.Request) {
+		switch r.PathValue("petId") {
+		case "1":
+			_, _ = w.Write([]byte(`{"id":1,"name":"cat","owner":{"name":"alice"}}`))
+		case "500":
+			w.WriteHeader(http.StatusBadGateway)
+		case "3":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`<html>not found</html>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"code":404,"message":"not found"}`))
+		}
+	})
+	mux.HandleFunc("DELETE /pets/{petId}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("PUT /pets/{petId}/photos", func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		_ = json.NewEncoder(w).Encode(map[string]any{"url": r.Header.Get("Content-Type"), "size": len(data)})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestClient(t *testing.T) *Client {
+	srv := newPetServer(t)
+	return NewClient(srv.URL, httpclient.NewHTTPClient(&httpclient.Config{
+		Auth: &httpclient.AuthBearerToken{Token: "token"},
+	}))
+}
+
+func TestListPets(t *testing.T) {
+	client := newTestClient(t)
+
+	limit := int32(5)
+	status := PetStatusSold
+	requestID := "req-1"
+	pets, err := client.ListPets(context.Background(), &ListPetsParams{
+		Limit:      &limit,
+		Tags:       []string{"a", "b"},
+		Status:     &status,
+		XRequestID: &requestID,
+	})
+	if err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+	if len(pets) != 3 || pets[0].Name != "5|sold|req-1" || pets[2].Name != "b" {
+		t.Fatal("Pets not match. ", pets)
+	}
+
+	// 没有认证时返回 default 错误
+	client.client = httpclient.NewHTTPClient(&httpclient.Config{})
+	_, err = client.ListPets(context.Background(), nil)
+	var defaultErr *ListPetsDefaultError
+	if !errors.As(err, &defaultErr) || defaultErr.StatusCode != http.StatusUnauthorized || defaultErr.Body.Message != "unauthorized" {
+		t.Fatal("Expected ListPetsDefaultError. ", err)
+	}
+}
+
+func TestCreatePet(t *testing.T) {
+	client := newTestClient(t)
+
+	status := PetStatusAvailable
+	pet, err := client.CreatePet(context.Background(), &NewPet{Name: "dog", Status: &status})
+	if err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+	if pet.ID != 10 || pet.Name != "dog" || *pet.Status != PetStatusAvailable {
+		t.Fatal("Pet not match. ", pet)
+	}
+
+	_, err = client.CreatePet(context.Background(), &NewPet{Name: "exists"})
+	var conflict *CreatePetConflictError
+	if !errors.As(err, &conflict) || conflict.Body.Message != "pet exists" {
+		t.Fatal("Expected CreatePetConflictError. ", err)
+	}
+}
+
+func TestGetPet(t *testing.T) {
+	client := newTestClient(t)
+
+	pet, err := client.GetPet(context.Background(), 1)
+	if err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+	if pet.Name != "cat" || pet.Owner == nil || *pet.Owner.Name != "alice" {
+		t.Fatal("Pet not match. ", pet)
+	}
+
+	_, err = client.GetPet(context.Background(), 2)
+	var notFound *GetPetNotFoundError
+	if !errors.As(err, &notFound) || notFound.Body.Code != 404 {
+		t.Fatal("Expected GetPetNotFoundError. ", err)
+	}
+
+	// 响应体不是 JSON 时仍返回错误类型，解码失败记录在 DecodeErr 中
+	_, err = client.GetPet(context.Background(), 3)
+	if !errors.As(err, &notFound) || notFound.StatusCode != http.StatusNotFound || notFound.DecodeErr == nil {
+		t.Fatal("Expected GetPetNotFoundError with DecodeErr. ", err)
+	}
+
+	_, err = client.GetPet(context.Background(), 500)
+	var serverErr *GetPetStatus5XXError
+	if !errors.As(err, &serverErr) || serverErr.StatusCode != http.StatusBadGateway {
+		t.Fatal("Expected GetPetStatus5XXError. ", err)
+	}
+}
+
+func TestDeleteAndUpload(t *testing.T) {
+	client := newTestClient(t)
+
+	if err := client.DeletePet(context.Background(), 1); err != nil {
+		t.Fatal("Delete failed. ", err)
+	}
+
+	result, err := client.UploadPhoto(context.Background(), 1, bytes.NewReader([]byte("png-data")))
+	if err != nil {
+		t.Fatal("Upload failed. ", err)
+	}
+	if result.URL != "image/png" || *result.Size != 8 {
+		t.Fatal("Result not match. ", result)
+	}
+}
@@ -0,0 +1,971 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// commonInitialisms 生成标识符时保持全大写的单词
+var commonInitialisms = map[string]bool{
+	"API": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true,
+	"JSON": true, "SQL": true, "TLS": true, "TTL": true, "UID": true, "URI": true,
+	"URL": true, "UUID": true, "XML": true,
+}
+
+// reservedIdents Go 关键字以及生成的方法中已使用的变量名，参数名与之冲突时加后缀
+var reservedIdents = map[string]bool{
+	"break": true, "case": true, "chan": true, "const": true, "continue": true,
+	"default": true, "defer": true, "else": true, "fallthrough": true, "for": true,
+	"func": true, "go": true, "goto": true, "if": true, "import": true,
+	"interface": true, "map": true, "package": true, "range": true, "return": true,
+	"select": true, "struct": true, "switch": true, "type": true, "var": true,
+	"ctx": true, "opts": true, "options": true, "params": true, "body": true,
+	"data": true, "req": true, "err": true, "result": true, "u": true, "q": true,
+}
+
+// generator 根据 Spec 生成客户端代码
+type generator struct {
+	spec    *Spec
+	imports map[string]bool
+	types   bytes.Buffer
+	methods bytes.Buffer
+	// declared 已声明的类型名
+	declared map[string]bool
+	// operations 已生成的方法名与对应的路由
+	operations map[string]string
+}
+
+// reservedTypes 生成的代码中固定使用的包级标识符，不能再声明同名类型
+var reservedTypes = []string{"DefaultBaseURL", "Client", "NewClient", "responseFunc", "decodeJSON", "formatParam"}
+
+// Generate 生成 package 为 pkg 的客户端代码
+func Generate(spec *Spec, pkg string) ([]byte, error) {
+	g := &generator{
+		spec: spec,
+		imports: map[string]bool{
+			"context":                            true,
+			"encoding/json":                      true,
+			"fmt":                                true,
+			"io":                                 true,
+			"net/http":                           true,
+			"strings":                            true,
+			"github.com/bookiu/gopkg/httpclient": true,
+		},
+		declared:   map[string]bool{},
+		operations: map[string]string{},
+	}
+	for _, name := range reservedTypes {
+		g.declared[name] = true
+	}
+	if err := g.generate(); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	out.WriteString("// Code generated by openapi-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", pkg)
+	g.writeImports(&out)
+	g.writeClient(&out)
+	out.Write(g.types.Bytes())
+	out.Write(g.methods.Bytes())
+	writeHelpers(&out)
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return out.Bytes(), fmt.Errorf("failed to format generated code: %w", err)
+	}
+	return src, nil
+}
+
+func (g *generator) generate() error {
+	names := sortedKeys(g.spec.Components.Schemas)
+	sources := map[string]string{}
+	for _, name := range names {
+		if err := g.declare(goName(name)); err != nil {
+			if other, ok := sources[goName(name)]; ok {
+				return fmt.Errorf("schema %s: type name %s conflicts with schema %s", name, goName(name), other)
+			}
+			return fmt.Errorf("schema %s: %w", name, err)
+		}
+		sources[goName(name)] = name
+	}
+	for _, name := range names {
+		if err := g.declareComponent(goName(name), g.spec.Components.Schemas[name], "对应 schema "+name); err != nil {
+			return fmt.Errorf("schema %s: %w", name, err)
+		}
+	}
+
+	for _, path := range sortedKeys(g.spec.Paths) {
+		item := g.spec.Paths[path]
+		for _, o := range item.operations() {
+			if err := g.operation(o.Method, path, item, o.Op); err != nil {
+				return fmt.Errorf("%s %s: %w", o.Method, path, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (g *generator) writeImports(out *bytes.Buffer) {
+	var std, other []string
+	for path := range g.imports {
+		if strings.Contains(path, ".") {
+			other = append(other, path)
+		} else {
+			std = append(std, path)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(other)
+	out.WriteString("import (\n")
+	for _, path := range std {
+		fmt.Fprintf(out, "\t%q\n", path)
+	}
+	out.WriteString("\n")
+	for _, path := range other {
+		fmt.Fprintf(out, "\t%q\n", path)
+	}
+	out.WriteString(")\n\n")
+}
+
+func (g *generator) writeClient(out *bytes.Buffer) {
+	title := g.spec.Info.Title
+	if title == "" {
+		title = "API"
+	}
+	if len(g.spec.Servers) > 0 {
+		fmt.Fprintf(out, "// DefaultBaseURL 规范中声明的第一个服务地址\nconst DefaultBaseURL = %q\n\n", g.spec.Servers[0].URL)
+	}
+	fmt.Fprintf(out, "// Client %s 客户端，认证、响应处理与观测由 httpclient.Client 的配置决定\n", title)
+	out.WriteString(`type Client struct {
+	client  httpclient.Client
+	baseURL string
+}
+
+// NewClient 创建客户端，baseURL 为空时请求地址只包含路径，可通过 httpclient.Config.BaseURL 指定服务地址
+func NewClient(baseURL string, client httpclient.Client) *Client {
+	return &Client{client: client, baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+`)
+}
+
+// writeHelpers 生成代码中使用的辅助函数
+func writeHelpers(out *bytes.Buffer) {
+	out.WriteString(`// responseFunc 将函数转为 httpclient.ResponseHandler
+type responseFunc func(resp *http.Response, result interface{}) error
+
+func (f responseFunc) Handle(resp *http.Response, result interface{}) error {
+	return f(resp, result)
+}
+
+// decodeJSON 将响应体解码到 v，v 为 nil 或响应体为空时忽略
+func decodeJSON(resp *http.Response, v interface{}) error {
+	if v == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil && err != io.EOF {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// formatParam 将路径、查询与请求头参数转为字符串
+func formatParam(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	case interface{ MarshalText() ([]byte, error) }:
+		text, _ := v.MarshalText()
+		return string(text)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+`)
+}
+
+// schemaKind 返回 schema 对应 Go 类型的种类：struct、slice、map、any 或 scalar
+func (g *generator) schemaKind(s *Schema) string {
+	if s == nil {
+		return "any"
+	}
+	if s.Ref != "" {
+		_, target, err := g.spec.schema(s.Ref)
+		if err != nil {
+			return "any"
+		}
+		return g.schemaKind(target)
+	}
+	if len(s.AllOf) == 1 && len(s.Properties) == 0 {
+		return g.schemaKind(s.AllOf[0])
+	}
+	if len(s.AllOf) > 0 {
+		return "struct"
+	}
+	if len(s.OneOf) > 0 || len(s.AnyOf) > 0 {
+		return "slice"
+	}
+	switch s.Type.Name {
+	case "string":
+		if s.Format == "byte" {
+			return "slice"
+		}
+		return "scalar"
+	case "integer", "number", "boolean":
+		return "scalar"
+	case "array":
+		return "slice"
+	}
+	if len(s.Properties) > 0 {
+		return "struct"
+	}
+	if s.Type.Name == "object" {
+		return "map"
+	}
+	return "any"
+}
+
+// nullable 返回 schema 是否允许 null
+func (g *generator) nullable(s *Schema) bool {
+	return s != nil && (s.Nullable || s.Type.Nullable)
+}
+
+// goType 返回 schema 对应的 Go 类型，内联的对象与枚举声明为 hint 命名的类型
+func (g *generator) goType(s *Schema, hint string) (string, error) {
+	if s == nil {
+		return "interface{}", nil
+	}
+	if s.Ref != "" {
+		name, _, err := g.spec.schema(s.Ref)
+		if err != nil {
+			return "", err
+		}
+		return goName(name), nil
+	}
+	if len(s.AllOf) == 1 && len(s.Properties) == 0 {
+		return g.goType(s.AllOf[0], hint)
+	}
+	if len(s.AllOf) > 0 {
+		return g.declareInline(hint, s)
+	}
+	if len(s.OneOf) > 0 || len(s.AnyOf) > 0 {
+		return "json.RawMessage", nil
+	}
+
+	switch s.Type.Name {
+	case "string":
+		if len(stringEnum(s)) > 0 {
+			return g.declareInline(hint, s)
+		}
+		switch s.Format {
+		case "date-time":
+			g.imports["time"] = true
+			return "time.Time", nil
+		case "byte":
+			return "[]byte", nil
+		}
+		return "string", nil
+	case "integer":
+		if s.Format == "int32" {
+			return "int32", nil
+		}
+		return "int64", nil
+	case "number":
+		if s.Format == "float" {
+			return "float32", nil
+		}
+		return "float64", nil
+	case "boolean":
+		return "bool", nil
+	case "array":
+		elem, err := g.goType(s.Items, hint+"Item")
+		if err != nil {
+			return "", err
+		}
+		return "[]" + elem, nil
+	}
+
+	if len(s.Properties) > 0 {
+		return g.declareInline(hint, s)
+	}
+	if s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil {
+		value, err := g.goType(s.AdditionalProperties.Schema, hint+"Value")
+		if err != nil {
+			return "", err
+		}
+		return "map[string]" + value, nil
+	}
+	if s.Type.Name == "object" {
+		return "map[string]interface{}", nil
+	}
+	return "interface{}", nil
+}
+
+// fieldType 返回字段类型，可选或可为 null 的结构体与标量使用指针
+func (g *generator) fieldType(s *Schema, hint string, optional bool) (string, error) {
+	t, err := g.goType(s, hint)
+	if err != nil {
+		return "", err
+	}
+	kind := g.schemaKind(s)
+	if (optional || g.nullable(s)) && (kind == "struct" || kind == "scalar") {
+		return "*" + t, nil
+	}
+	return t, nil
+}
+
+// declare 登记固定名称的类型，名称已被使用时返回错误
+func (g *generator) declare(name string) error {
+	if g.declared[name] {
+		return fmt.Errorf("type name %s conflicts with another declaration", name)
+	}
+	g.declared[name] = true
+	return nil
+}
+
+// declareInline 以不重复的名称声明内联类型
+func (g *generator) declareInline(hint string, s *Schema) (string, error) {
+	name := uniqueName(hint, g.declared)
+	return name, g.declareComponent(name, s, "内联 schema")
+}
+
+// declareComponent 声明类型，schema 没有描述时使用 doc 作为注释
+func (g *generator) declareComponent(name string, s *Schema, doc string) error {
+	if s.Description != "" {
+		doc = s.Description
+	}
+	if s.Ref == "" && s.Type.Name == "string" && len(stringEnum(s)) > 0 {
+		g.declareEnum(name, s, doc)
+		return nil
+	}
+	if g.schemaKind(s) == "struct" && !(len(s.AllOf) == 1 && len(s.Properties) == 0) {
+		return g.declareStruct(name, s, doc)
+	}
+
+	t, err := g.goType(s, name+"Value")
+	if err != nil {
+		return err
+	}
+	writeComment(&g.types, name, doc)
+	fmt.Fprintf(&g.types, "type %s %s\n\n", name, t)
+	return nil
+}
+
+func (g *generator) declareEnum(name string, s *Schema, doc string) {
+	writeComment(&g.types, name, doc)
+	fmt.Fprintf(&g.types, "type %s string\n\nconst (\n", name)
+	for _, v := range stringEnum(s) {
+		suffix := goName(v)
+		if suffix == "" {
+			suffix = "Empty"
+		}
+		fmt.Fprintf(&g.types, "\t%s%s %s = %q\n", name, suffix, name, v)
+	}
+	g.types.WriteString(")\n\n")
+}
+
+func (g *generator) declareStruct(name string, s *Schema, doc string) error {
+	props := map[string]*Schema{}
+	required := map[string]bool{}
+	if err := g.collectProperties(s, props, required); err != nil {
+		return err
+	}
+
+	var fields bytes.Buffer
+	// 不同属性可能转为同一个字段名，如 foo_bar 与 fooBar
+	used := map[string]bool{}
+	for _, prop := range sortedKeys(props) {
+		schema := props[prop]
+		field := uniqueName(goName(prop), used)
+		optional := !required[prop]
+		t, err := g.fieldType(schema, name+field, optional)
+		if err != nil {
+			return fmt.Errorf("property %s: %w", prop, err)
+		}
+		tag := prop
+		if optional {
+			tag += ",omitempty"
+		}
+		if schema.Description != "" {
+			writeComment(&fields, field, schema.Description)
+		}
+		fmt.Fprintf(&fields, "%s %s `json:%q`\n", field, t, tag)
+	}
+
+	writeComment(&g.types, name, doc)
+	fmt.Fprintf(&g.types, "type %s struct {\n%s}\n\n", name, fields.Bytes())
+	return nil
+}
+
+// collectProperties 合并 allOf 与自身的属性
+func (g *generator) collectProperties(s *Schema, props map[string]*Schema, required map[string]bool) error {
+	if s.Ref != "" {
+		_, target, err := g.spec.schema(s.Ref)
+		if err != nil {
+			return err
+		}
+		return g.collectProperties(target, props, required)
+	}
+	for _, member := range s.AllOf {
+		if err := g.collectProperties(member, props, required); err != nil {
+			return err
+		}
+	}
+	for name, prop := range s.Properties {
+		props[name] = prop
+	}
+	for _, name := range s.Required {
+		required[name] = true
+	}
+	return nil
+}
+
+// param 生成方法中的参数
+type param struct {
+	*Parameter
+	ident string
+	field string
+	typ   string
+}
+
+// statusError 非 2xx 响应对应的错误类型
+type statusError struct {
+	status string
+	name   string
+	body   string
+}
+
+func (g *generator) operation(method, path string, item *PathItem, op *Operation) error {
+	name := goName(op.OperationID)
+	if name == "" {
+		name = goName(strings.ToLower(method) + " " + strings.NewReplacer("{", "", "}", "").Replace(path))
+	}
+	route := method + " " + path
+	if other, ok := g.operations[name]; ok {
+		return fmt.Errorf("method name %s conflicts with %s, set a unique operationId", name, other)
+	}
+	g.operations[name] = route
+
+	params, err := g.params(item, op)
+	if err != nil {
+		return err
+	}
+	var pathParams, otherParams []*param
+	// 不同参数可能转为同一个名称，如 page_size 与 pageSize
+	idents, fields := map[string]bool{}, map[string]bool{}
+	for _, p := range params {
+		t, err := g.fieldType(p.Schema, name+goName(p.Name), !p.Required && p.In != "path")
+		if err != nil {
+			return fmt.Errorf("parameter %s: %w", p.Name, err)
+		}
+		p.typ = t
+		switch p.In {
+		case "path":
+			p.ident = uniqueName(lowerIdent(p.Name), idents)
+			pathParams = append(pathParams, p)
+		case "query", "header":
+			p.field = uniqueName(goName(p.Name), fields)
+			otherParams = append(otherParams, p)
+		}
+	}
+	if len(otherParams) > 0 {
+		if err := g.declare(name + "Params"); err != nil {
+			return err
+		}
+		g.declareParams(name+"Params", route, otherParams)
+	}
+
+	// 请求体
+	var bodyType, bodyContentType string
+	bodyIsJSON := false
+	if op.RequestBody != nil {
+		body, err := g.spec.requestBody(op.RequestBody)
+		if err != nil {
+			return err
+		}
+		ct, media := pickContent(body.Content)
+		switch {
+		case media != nil && isJSON(ct):
+			bodyType, err = g.goType(media.Schema, name+"Request")
+			if err != nil {
+				return fmt.Errorf("request body: %w", err)
+			}
+			if g.schemaKind(media.Schema) == "struct" {
+				bodyType = "*" + bodyType
+			}
+			bodyIsJSON = true
+		case ct != "":
+			bodyType = "io.Reader"
+		}
+		bodyContentType = ct
+	}
+
+	// 响应
+	var resultType string
+	resultIsStruct := false
+	var handlers []string
+	var errs []statusError
+	for _, code := range sortedKeys(op.Responses) {
+		resp, err := g.spec.response(op.Responses[code])
+		if err != nil {
+			return err
+		}
+		ct, media := pickContent(resp.Content)
+		hasJSON := media != nil && media.Schema != nil && isJSON(ct)
+
+		status, _ := strconv.Atoi(code)
+		switch {
+		case status >= 200 && status < 300:
+			if hasJSON && resultType == "" {
+				if resultType, err = g.goType(media.Schema, name+"Response"); err != nil {
+					return fmt.Errorf("response %s: %w", code, err)
+				}
+				resultIsStruct = g.schemaKind(media.Schema) == "struct"
+			}
+			if status != http.StatusOK || !hasJSON {
+				handlers = append(handlers, fmt.Sprintf("httpclient.WithStatusHandler(%d, responseFunc(decodeJSON)),", status))
+			}
+		case status >= 300 || code == "default" || code == "4XX" || code == "5XX":
+			e := statusError{status: code, name: name + statusName(code) + "Error"}
+			if err := g.declare(e.name); err != nil {
+				return fmt.Errorf("response %s: %w", code, err)
+			}
+			if hasJSON {
+				if e.body, err = g.goType(media.Schema, e.name+"Body"); err != nil {
+					return fmt.Errorf("response %s: %w", code, err)
+				}
+			}
+			g.declareError(e, route, resp.Description)
+			if status >= 300 {
+				handlers = append(handlers, fmt.Sprintf("httpclient.WithStatusHandler(%d, responseFunc(func(resp *http.Response, _ interface{}) error {\n%s})),", status, decodeErrorStmt(e)))
+			} else {
+				errs = append(errs, e)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		handlers = append(handlers, errorHandler(errs))
+	}
+
+	// 方法签名
+	args := []string{"ctx context.Context"}
+	for _, p := range pathParams {
+		args = append(args, p.ident+" "+p.typ)
+	}
+	if len(otherParams) > 0 {
+		args = append(args, "params *"+name+"Params")
+	}
+	if bodyType != "" {
+		args = append(args, "body "+bodyType)
+	}
+	args = append(args, "opts ...httpclient.RequestOption")
+
+	returns := "error"
+	zero := "err"
+	if resultType != "" {
+		if resultIsStruct {
+			returns = "(*" + resultType + ", error)"
+			zero = "nil, err"
+		} else {
+			returns = "(" + resultType + ", error)"
+			zero = "result, err"
+		}
+	}
+
+	w := &g.methods
+	if doc := strings.TrimSpace(op.Summary + "\n\n" + op.Description); doc != "" {
+		writeComment(w, name, doc)
+		fmt.Fprintf(w, "//\n// %s\n", route)
+	} else {
+		writeComment(w, name, route)
+	}
+	if op.Deprecated {
+		w.WriteString("//\n// Deprecated: 规范中已标记为废弃\n")
+	}
+	fmt.Fprintf(w, "func (c *Client) %s(%s) %s {\n", name, strings.Join(args, ", "), returns)
+	if resultType != "" && !resultIsStruct {
+		fmt.Fprintf(w, "var result %s\n", resultType)
+	}
+
+	// 地址
+	fmt.Fprintf(w, "u := c.baseURL + %s\n", g.pathExpr(path, pathParams))
+	g.writeQuery(w, otherParams)
+
+	// 请求
+	bodyArg := "nil"
+	switch {
+	case bodyIsJSON:
+		fmt.Fprintf(w, "data, err := json.Marshal(body)\nif err != nil {\nreturn %s\n}\n", zero)
+		bodyArg = "data"
+	case bodyType != "":
+		bodyArg = "body"
+	}
+	fmt.Fprintf(w, "req, err := httpclient.NewRequest(ctx, %s, u, %s)\nif err != nil {\nreturn %s\n}\n", methodConst(method), bodyArg, zero)
+
+	fmt.Fprintf(w, "options := []httpclient.RequestOption{\nhttpclient.WithRoute(%q),\n", route)
+	if bodyContentType != "" {
+		fmt.Fprintf(w, "httpclient.WithContentType(%q),\n", bodyContentType)
+	}
+	for _, h := range handlers {
+		w.WriteString(h + "\n")
+	}
+	w.WriteString("}\n")
+	g.writeHeaders(w, otherParams)
+	w.WriteString("options = append(options, opts...)\n\n")
+
+	switch {
+	case resultType == "":
+		w.WriteString("return c.client.Do(ctx, req, nil, options...)\n}\n\n")
+	case resultIsStruct:
+		fmt.Fprintf(w, "var result %s\nif err := c.client.Do(ctx, req, &result, options...); err != nil {\nreturn nil, err\n}\nreturn &result, nil\n}\n\n", resultType)
+	default:
+		w.WriteString("err = c.client.Do(ctx, req, &result, options...)\nreturn result, err\n}\n\n")
+	}
+	return nil
+}
+
+// params 合并路径与操作上的参数，操作上的同名参数优先
+func (g *generator) params(item *PathItem, op *Operation) ([]*param, error) {
+	var params []*param
+	index := map[string]int{}
+	for _, list := range [][]*Parameter{item.Parameters, op.Parameters} {
+		for _, p := range list {
+			resolved, err := g.spec.parameter(p)
+			if err != nil {
+				return nil, err
+			}
+			key := resolved.In + ":" + resolved.Name
+			if i, ok := index[key]; ok {
+				params[i] = &param{Parameter: resolved}
+				continue
+			}
+			index[key] = len(params)
+			params = append(params, &param{Parameter: resolved})
+		}
+	}
+	return params, nil
+}
+
+func (g *generator) declareParams(name, route string, params []*param) {
+	fmt.Fprintf(&g.types, "// %s %s 的查询与请求头参数\ntype %s struct {\n", name, route, name)
+	for _, p := range params {
+		desc := p.Description
+		if desc == "" {
+			desc = p.In + " 参数 " + p.Name
+		}
+		writeComment(&g.types, p.field, desc)
+		fmt.Fprintf(&g.types, "%s %s\n", p.field, p.typ)
+	}
+	g.types.WriteString("}\n\n")
+}
+
+func (g *generator) declareError(e statusError, route, description string) {
+	desc := fmt.Sprintf("%s 返回 %s 时的错误", route, e.status)
+	if description != "" {
+		desc += "：" + description
+	}
+	writeComment(&g.types, e.name, desc)
+	fmt.Fprintf(&g.types, "type %s struct {\nStatusCode int\n", e.name)
+	if e.body == "" {
+		fmt.Fprintf(&g.types, "}\n\nfunc (e *%s) Error() string {\nreturn fmt.Sprintf(\"%s: unexpected status code: %%d\", e.StatusCode)\n}\n\n", e.name, route)
+		return
+	}
+	fmt.Fprintf(&g.types, "Body %s\n// DecodeErr 响应体无法解码为 Body 时的错误\nDecodeErr error\n}\n\n", e.body)
+	fmt.Fprintf(&g.types, "func (e *%s) Error() string {\nmsg := fmt.Sprintf(\"%s: unexpected status code: %%d\", e.StatusCode)\n", e.name, route)
+	fmt.Fprintf(&g.types, "if e.DecodeErr != nil {\nmsg += \": \" + e.DecodeErr.Error()\n}\nreturn msg\n}\n\n")
+	fmt.Fprintf(&g.types, "func (e *%s) Unwrap() error {\nreturn e.DecodeErr\n}\n\n", e.name)
+}
+
+// pathExpr 返回拼接路径参数的表达式
+func (g *generator) pathExpr(path string, params []*param) string {
+	byName := map[string]*param{}
+	for _, p := range params {
+		byName[p.Name] = p
+	}
+	var parts []string
+	literal := ""
+	for len(path) > 0 {
+		start := strings.Index(path, "{")
+		end := strings.Index(path, "}")
+		if start < 0 || end < start {
+			literal += path
+			break
+		}
+		literal += path[:start]
+		name := path[start+1 : end]
+		path = path[end+1:]
+		p, ok := byName[name]
+		if !ok {
+			literal += "{" + name + "}"
+			continue
+		}
+		if literal != "" {
+			parts = append(parts, strconv.Quote(literal))
+			literal = ""
+		}
+		g.imports["net/url"] = true
+		parts = append(parts, "url.PathEscape(formatParam("+p.ident+"))")
+	}
+	if literal != "" || len(parts) == 0 {
+		parts = append(parts, strconv.Quote(literal))
+	}
+	return strings.Join(parts, " + ")
+}
+
+func (g *generator) writeQuery(w *bytes.Buffer, params []*param) {
+	var query []*param
+	for _, p := range params {
+		if p.In == "query" {
+			query = append(query, p)
+		}
+	}
+	if len(query) == 0 {
+		return
+	}
+	g.imports["net/url"] = true
+	w.WriteString("q := url.Values{}\nif params != nil {\n")
+	for _, p := range query {
+		writeParamValue(w, p, `q.Add(%q, formatParam(%s))`)
+	}
+	w.WriteString("}\nif len(q) > 0 {\nu += \"?\" + q.Encode()\n}\n")
+}
+
+func (g *generator) writeHeaders(w *bytes.Buffer, params []*param) {
+	var headers []*param
+	for _, p := range params {
+		if p.In == "header" {
+			headers = append(headers, p)
+		}
+	}
+	if len(headers) == 0 {
+		return
+	}
+	w.WriteString("if params != nil {\n")
+	for _, p := range headers {
+		writeParamValue(w, p, `options = append(options, httpclient.WithHeader(%q, formatParam(%s)))`)
+	}
+	w.WriteString("}\n")
+}
+
+// writeParamValue 按参数类型生成设置语句，format 中依次为参数名与值
+func writeParamValue(w *bytes.Buffer, p *param, format string) {
+	v := "params." + p.field
+	switch {
+	case strings.HasPrefix(p.typ, "*"):
+		fmt.Fprintf(w, "if %s != nil {\n"+format+"\n}\n", v, p.Name, "*"+v)
+	case strings.HasPrefix(p.typ, "[]") && p.typ != "[]byte":
+		fmt.Fprintf(w, "for _, v := range %s {\n"+format+"\n}\n", v, p.Name, "v")
+	default:
+		fmt.Fprintf(w, format+"\n", p.Name, v)
+	}
+}
+
+// decodeErrorStmt 生成返回错误类型的语句，响应体解码失败时记录在 DecodeErr 中
+func decodeErrorStmt(e statusError) string {
+	if e.body == "" {
+		return fmt.Sprintf("return &%s{StatusCode: resp.StatusCode}\n", e.name)
+	}
+	return fmt.Sprintf("e := &%s{StatusCode: resp.StatusCode}\ne.DecodeErr = decodeJSON(resp, &e.Body)\nreturn e\n", e.name)
+}
+
+// errorHandler 生成处理 4XX、5XX 与 default 响应的 WithErrorHandler
+func errorHandler(errs []statusError) string {
+	var cases strings.Builder
+	var fallback *statusError
+	for i, e := range errs {
+		switch e.status {
+		case "4XX":
+			fmt.Fprintf(&cases, "case resp.StatusCode < 500:\n%s", decodeErrorStmt(e))
+		case "5XX":
+			fmt.Fprintf(&cases, "case resp.StatusCode >= 500:\n%s", decodeErrorStmt(e))
+		default:
+			fallback = &errs[i]
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("httpclient.WithErrorHandler(responseFunc(func(resp *http.Response, _ interface{}) error {\n")
+	if cases.Len() > 0 {
+		fmt.Fprintf(&b, "switch {\n%s}\n", cases.String())
+	}
+	if fallback != nil {
+		b.WriteString(decodeErrorStmt(*fallback))
+	} else {
+		b.WriteString("return fmt.Errorf(\"unexpected status code: %d\", resp.StatusCode)\n")
+	}
+	b.WriteString("})),")
+	return b.String()
+}
+
+// pickContent 优先选择 JSON 内容类型
+func pickContent(content map[string]*MediaType) (string, *MediaType) {
+	types := sortedKeys(content)
+	for _, ct := range types {
+		if isJSON(ct) {
+			return ct, content[ct]
+		}
+	}
+	if len(types) > 0 {
+		return types[0], content[types[0]]
+	}
+	return "", nil
+}
+
+func isJSON(contentType string) bool {
+	ct := strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
+	return ct == "application/json" || strings.HasSuffix(ct, "+json")
+}
+
+func methodConst(method string) string {
+	switch method {
+	case "GET":
+		return "http.MethodGet"
+	case "POST":
+		return "http.MethodPost"
+	case "PUT":
+		return "http.MethodPut"
+	case "PATCH":
+		return "http.MethodPatch"
+	case "DELETE":
+		return "http.MethodDelete"
+	case "HEAD":
+		return "http.MethodHead"
+	case "OPTIONS":
+		return "http.MethodOptions"
+	}
+	return strconv.Quote(method)
+}
+
+// statusName 返回状态码对应的类型名称片段，如 404 返回 NotFound
+func statusName(code string) string {
+	switch code {
+	case "default":
+		return "Default"
+	case "4XX", "5XX":
+		return "Status" + code
+	}
+	status, _ := strconv.Atoi(code)
+	if text := http.StatusText(status); text != "" {
+		return goName(text)
+	}
+	return "Status" + code
+}
+
+func stringEnum(s *Schema) []string {
+	var values []string
+	for _, v := range s.Enum {
+		if str, ok := v.(string); ok {
+			values = append(values, str)
+		}
+	}
+	return values
+}
+
+// writeComment 生成以 name 开头的注释，text 为空时只写 name
+func writeComment(w *bytes.Buffer, name, text string) {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	fmt.Fprintf(w, "// %s %s\n", name, strings.TrimSpace(lines[0]))
+	for _, line := range lines[1:] {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			w.WriteString("//\n")
+		} else {
+			fmt.Fprintf(w, "// %s\n", line)
+		}
+	}
+}
+
+// splitWords 按非字母数字字符与大小写边界拆分单词
+func splitWords(s string) []string {
+	var words []string
+	var cur []rune
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(cur) > 0 {
+				words = append(words, string(cur))
+				cur = nil
+			}
+			continue
+		}
+		if len(cur) > 0 && unicode.IsUpper(r) {
+			prev := cur[len(cur)-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				words = append(words, string(cur))
+				cur = nil
+			}
+		}
+		cur = append(cur, r)
+	}
+	if len(cur) > 0 {
+		words = append(words, string(cur))
+	}
+	return words
+}
+
+// goName 转为导出的 Go 标识符，如 pet_id 转为 PetID
+func goName(s string) string {
+	var b strings.Builder
+	for _, word := range splitWords(s) {
+		upper := strings.ToUpper(word)
+		if commonInitialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		runes := []rune(word)
+		b.WriteString(strings.ToUpper(string(runes[0])) + strings.ToLower(string(runes[1:])))
+	}
+	name := b.String()
+	if name != "" && unicode.IsDigit([]rune(name)[0]) {
+		name = "N" + name
+	}
+	return name
+}
+
+// lowerIdent 转为未导出的 Go 标识符，如 PetID 转为 petID
+func lowerIdent(s string) string {
+	words := splitWords(goName(s))
+	if len(words) == 0 {
+		return "param"
+	}
+	if commonInitialisms[words[0]] {
+		words[0] = strings.ToLower(words[0])
+	} else {
+		runes := []rune(words[0])
+		words[0] = strings.ToLower(string(runes[0])) + string(runes[1:])
+	}
+	ident := strings.Join(words, "")
+	if reservedIdents[ident] {
+		ident += "Param"
+	}
+	return ident
+}
+
+// uniqueName 在 name 已被使用时加数字后缀，并记录到 used
+func uniqueName(name string, used map[string]bool) string {
+	unique := name
+	for i := 2; used[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	used[unique] = true
+	return unique
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGenerateExample(t *testing.T) {
+	spec, err := LoadSpec("testdata/petstore.yaml")
+	if err != nil {
+		t.Fatal("Load spec failed. ", err)
+	}
+	src, err := Generate(spec, "petstore")
+	if err != nil {
+		t.Fatal("Generate failed. ", err)
+	}
+	expected, err := os.ReadFile("example/petstore/petstore.go")
+	if err != nil {
+		t.Fatal("Read example failed. ", err)
+	}
+	if !bytes.Equal(src, expected) {
+		t.Fatal("Generated code not match example/petstore/petstore.go, run go generate ./example/...")
+	}
+}
+
+func TestParseSpec(t *testing.T) {
+	if _, err := ParseSpec([]byte(`swagger: "2.0"`)); err == nil {
+		t.Fatal("Expected unsupported version error")
+	}
+
+	// JSON 格式与 3.1 的类型列表
+	spec, err := ParseSpec([]byte(`{"openapi":"3.1.0","components":{"schemas":{"Item":{"type":"object","properties":{"note":{"type":["string","null"]}}}}}}`))
+	if err != nil {
+		t.Fatal("Parse failed. ", err)
+	}
+	note := spec.Components.Schemas["Item"].Properties["note"]
+	if note.Type.Name != "string" || !note.Type.Nullable {
+		t.Fatal("Type not match. ", note.Type)
+	}
+}
+
+func TestGenerateNameConflicts(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+	}{
+		{
+			name: "operation",
+			spec: `{"openapi":"3.0.3","paths":{"/items":{"get":{"responses":{"200":{"description":"ok"}}},"post":{"operationId":"getItems","responses":{"200":{"description":"ok"}}}}}}`,
+		},
+		{
+			name: "schema",
+			spec: `{"openapi":"3.0.3","components":{"schemas":{"pet":{"type":"string"},"Pet":{"type":"integer"}}}}`,
+		},
+		{
+			name: "reserved",
+			spec: `{"openapi":"3.0.3","components":{"schemas":{"Client":{"type":"string"}}}}`,
+		},
+		{
+			name: "error type",
+			spec: `{"openapi":"3.0.3","components":{"schemas":{"GetItemsNotFoundError":{"type":"string"}}},"paths":{"/items":{"get":{"responses":{"404":{"description":"not found"}}}}}}`,
+		},
+	}
+	for _, tt := range tests {
+		spec, err := ParseSpec([]byte(tt.spec))
+		if err != nil {
+			t.Fatal("Parse failed. ", tt.name, err)
+		}
+		if _, err := Generate(spec, "api"); err == nil || !strings.Contains(err.Error(), "conflicts") {
+			t.Fatal("Expected name conflict error. ", tt.name, err)
+		}
+	}
+
+	// 内联类型与组件同名时加后缀
+	spec, err := ParseSpec([]byte(`{"openapi":"3.0.3","components":{"schemas":{"ListItemsResponseItem":{"type":"string"}}},` +
+		`"paths":{"/items":{"get":{"operationId":"listItems","responses":{"200":{"description":"ok","content":{"application/json":{"schema":{"type":"array","items":{"type":"object","properties":{"id":{"type":"integer"}}}}}}}}}}}}`))
+	if err != nil {
+		t.Fatal("Parse failed. ", err)
+	}
+	src, err := Generate(spec, "api")
+	if err != nil {
+		t.Fatal("Generate failed. ", err)
+	}
+	if !bytes.Contains(src, []byte("type ListItemsResponseItem2 struct")) {
+		t.Fatal("Inline type should be renamed. ", string(src))
+	}
+
+	// 属性与参数转为同名字段时加后缀
+	spec, err = ParseSpec([]byte(`{"openapi":"3.0.3","components":{"schemas":{"Item":{"type":"object","properties":{"foo_bar":{"type":"string"},"fooBar":{"type":"integer"}}}}},` +
+		`"paths":{"/items/{item_id}/{itemId}":{"get":{"operationId":"listItems","parameters":[` +
+		`{"name":"item_id","in":"path","required":true,"schema":{"type":"string"}},{"name":"itemId","in":"path","required":true,"schema":{"type":"string"}},` +
+		`{"name":"page_size","in":"query","schema":{"type":"integer"}},{"name":"pageSize","in":"query","schema":{"type":"integer"}}],` +
+		`"responses":{"200":{"description":"ok"}}}}}}`))
+	if err != nil {
+		t.Fatal("Parse failed. ", err)
+	}
+	src, err = Generate(spec, "api")
+	if err != nil {
+		t.Fatal("Generate failed. ", err)
+	}
+	for _, want := range []string{"FooBar  *int64  `json:\"fooBar,omitempty\"`", "FooBar2 *string `json:\"foo_bar,omitempty\"`", "PageSize2 *int64", "itemID string, itemID2 string"} {
+		if !bytes.Contains(src, []byte(want)) {
+			t.Fatal("Conflicting names should be renamed. ", want, string(src))
+		}
+	}
+}
+
+func TestGoName(t *testing.T) {
+	tests := []struct {
+		input string
+		name  string
+		ident string
+	}{
+		{"listPets", "ListPets", "listPets"},
+		{"petId", "PetID", "petID"},
+		{"X-Request-ID", "XRequestID", "xRequestID"},
+		{"user_url", "UserURL", "userURL"},
+		{"id", "ID", "id"},
+		{"HTTPStatus", "HTTPStatus", "httpStatus"},
+		{"type", "Type", "typeParam"},
+		{"2fa", "N2fa", "n2fa"},
+	}
+	for _, tt := range tests {
+		if name := goName(tt.input); name != tt.name {
+			t.Fatalf("Name not match. input=%s, expected=%s, actual=%s", tt.input, tt.name, name)
+		}
+		if ident := lowerIdent(tt.input); ident != tt.ident {
+			t.Fatalf("Ident not match. input=%s, expected=%s, actual=%s", tt.input, tt.ident, ident)
+		}
+	}
+}
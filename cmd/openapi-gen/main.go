@@ -0,0 +1,51 @@
+// openapi-gen 根据 OpenAPI 3 规范生成基于 httpclient.Client 的类型化客户端。
+//
+//	go run github.com/bookiu/gopkg/cmd/openapi-gen -spec api.yaml -package petstore -o petstore.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	specPath := flag.String("spec", "", "OpenAPI 3 规范文件，YAML 或 JSON 格式")
+	pkg := flag.String("package", "", "生成代码的包名，默认为输出文件所在目录名")
+	output := flag.String("o", "", "输出文件，为空时写到标准输出")
+	flag.Parse()
+
+	if err := run(*specPath, *pkg, *output); err != nil {
+		fmt.Fprintln(os.Stderr, "openapi-gen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(specPath, pkg, output string) error {
+	if specPath == "" {
+		return fmt.Errorf("-spec is required")
+	}
+	if pkg == "" {
+		pkg = "client"
+		if output != "" {
+			if abs, err := filepath.Abs(output); err == nil {
+				pkg = filepath.Base(filepath.Dir(abs))
+			}
+		}
+	}
+
+	spec, err := LoadSpec(specPath)
+	if err != nil {
+		return err
+	}
+	src, err := Generate(spec, pkg)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(output, src, 0o644)
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Spec OpenAPI 3 规范中生成客户端需要的部分，YAML 与 JSON 格式均可解析
+type Spec struct {
+	OpenAPI    string               `yaml:"openapi"`
+	Info       Info                 `yaml:"info"`
+	Servers    []Server             `yaml:"servers"`
+	Paths      map[string]*PathItem `yaml:"paths"`
+	Components Components           `yaml:"components"`
+}
+
+type Info struct {
+	Title       string `yaml:"title"`
+	Description string `yaml:"description"`
+	Version     string `yaml:"version"`
+}
+
+type Server struct {
+	URL string `yaml:"url"`
+}
+
+type Components struct {
+	Schemas       map[string]*Schema      `yaml:"schemas"`
+	Parameters    map[string]*Parameter   `yaml:"parameters"`
+	RequestBodies map[string]*RequestBody `yaml:"requestBodies"`
+	Responses     map[string]*Response    `yaml:"responses"`
+}
+
+type PathItem struct {
+	Parameters []*Parameter `yaml:"parameters"`
+	Get        *Operation   `yaml:"get"`
+	Post       *Operation   `yaml:"post"`
+	Put        *Operation   `yaml:"put"`
+	Patch      *Operation   `yaml:"patch"`
+	Delete     *Operation   `yaml:"delete"`
+	Head       *Operation   `yaml:"head"`
+	Options    *Operation   `yaml:"options"`
+}
+
+// operations 按固定顺序返回路径下的操作
+func (p *PathItem) operations() []struct {
+	Method string
+	Op     *Operation
+} {
+	all := []struct {
+		Method string
+		Op     *Operation
+	}{
+		{"GET", p.Get}, {"POST", p.Post}, {"PUT", p.Put}, {"PATCH", p.Patch},
+		{"DELETE", p.Delete}, {"HEAD", p.Head}, {"OPTIONS", p.Options},
+	}
+	ops := all[:0]
+	for _, o := range all {
+		if o.Op != nil {
+			ops = append(ops, o)
+		}
+	}
+	return ops
+}
+
+type Operation struct {
+	OperationID string               `yaml:"operationId"`
+	Summary     string               `yaml:"summary"`
+	Description string               `yaml:"description"`
+	Deprecated  bool                 `yaml:"deprecated"`
+	Parameters  []*Parameter         `yaml:"parameters"`
+	RequestBody *RequestBody         `yaml:"requestBody"`
+	Responses   map[string]*Response `yaml:"responses"`
+}
+
+type Parameter struct {
+	Ref         string  `yaml:"$ref"`
+	Name        string  `yaml:"name"`
+	In          string  `yaml:"in"`
+	Description string  `yaml:"description"`
+	Required    bool    `yaml:"required"`
+	Schema      *Schema `yaml:"schema"`
+}
+
+type RequestBody struct {
+	Ref         string                `yaml:"$ref"`
+	Description string                `yaml:"description"`
+	Required    bool                  `yaml:"required"`
+	Content     map[string]*MediaType `yaml:"content"`
+}
+
+type Response struct {
+	Ref         string                `yaml:"$ref"`
+	Description string                `yaml:"description"`
+	Content     map[string]*MediaType `yaml:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `yaml:"schema"`
+}
+
+type Schema struct {
+	Ref                  string                `yaml:"$ref"`
+	Type                 SchemaType            `yaml:"type"`
+	Format               string                `yaml:"format"`
+	Description          string                `yaml:"description"`
+	Nullable             bool                  `yaml:"nullable"`
+	Enum                 []interface{}         `yaml:"enum"`
+	Properties           map[string]*Schema    `yaml:"properties"`
+	Required             []string              `yaml:"required"`
+	Items                *Schema               `yaml:"items"`
+	AdditionalProperties *AdditionalProperties `yaml:"additionalProperties"`
+	AllOf                []*Schema             `yaml:"allOf"`
+	OneOf                []*Schema             `yaml:"oneOf"`
+	AnyOf                []*Schema             `yaml:"anyOf"`
+}
+
+// SchemaType 3.0 中为单个类型，3.1 中可以是包含 "null" 的类型列表
+type SchemaType struct {
+	Name     string
+	Nullable bool
+}
+
+func (t *SchemaType) UnmarshalYAML(node *yaml.Node) error {
+	var types []string
+	switch node.Kind {
+	case yaml.ScalarNode:
+		types = []string{node.Value}
+	case yaml.SequenceNode:
+		if err := node.Decode(&types); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid schema type at line %d", node.Line)
+	}
+	for _, name := range types {
+		if name == "null" {
+			t.Nullable = true
+		} else if t.Name == "" {
+			t.Name = name
+		}
+	}
+	return nil
+}
+
+// AdditionalProperties 可以是布尔值或 Schema
+type AdditionalProperties struct {
+	Schema *Schema
+}
+
+func (a *AdditionalProperties) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		var allowed bool
+		if err := node.Decode(&allowed); err != nil {
+			return err
+		}
+		if allowed {
+			a.Schema = &Schema{}
+		}
+		return nil
+	}
+	a.Schema = &Schema{}
+	return node.Decode(a.Schema)
+}
+
+func (s *Schema) required(name string) bool {
+	for _, r := range s.Required {
+		if r == name {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadSpec 读取并解析规范文件
+func LoadSpec(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSpec(data)
+}
+
+// ParseSpec 解析 YAML 或 JSON 格式的规范
+func ParseSpec(data []byte) (*Spec, error) {
+	var spec Spec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse spec: %w", err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported openapi version: %q", spec.OpenAPI)
+	}
+	return &spec, nil
+}
+
+// refName 返回 "#/components/<kind>/<name>" 中的 name
+func refName(ref, kind string) (string, error) {
+	prefix := "#/components/" + kind + "/"
+	if !strings.HasPrefix(ref, prefix) {
+		return "", fmt.Errorf("unsupported $ref: %s", ref)
+	}
+	return strings.TrimPrefix(ref, prefix), nil
+}
+
+func (s *Spec) parameter(p *Parameter) (*Parameter, error) {
+	if p.Ref == "" {
+		return p, nil
+	}
+	name, err := refName(p.Ref, "parameters")
+	if err != nil {
+		return nil, err
+	}
+	if resolved, ok := s.Components.Parameters[name]; ok {
+		return s.parameter(resolved)
+	}
+	return nil, fmt.Errorf("parameter not found: %s", p.Ref)
+}
+
+func (s *Spec) requestBody(b *RequestBody) (*RequestBody, error) {
+	if b.Ref == "" {
+		return b, nil
+	}
+	name, err := refName(b.Ref, "requestBodies")
+	if err != nil {
+		return nil, err
+	}
+	if resolved, ok := s.Components.RequestBodies[name]; ok {
+		return s.requestBody(resolved)
+	}
+	return nil, fmt.Errorf("request body not found: %s", b.Ref)
+}
+
+func (s *Spec) response(r *Response) (*Response, error) {
+	if r.Ref == "" {
+		return r, nil
+	}
+	name, err := refName(r.Ref, "responses")
+	if err != nil {
+		return nil, err
+	}
+	if resolved, ok := s.Components.Responses[name]; ok {
+		return s.response(resolved)
+	}
+	return nil, fmt.Errorf("response not found: %s", r.Ref)
+}
+
+func (s *Spec) schema(ref string) (string, *Schema, error) {
+	name, err := refName(ref, "schemas")
+	if err != nil {
+		return "", nil, err
+	}
+	schema, ok := s.Components.Schemas[name]
+	if !ok {
+		return "", nil, fmt.Errorf("schema not found: %s", ref)
+	}
+	return name, schema, nil
+}
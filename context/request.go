@@ -4,10 +4,12 @@ import stdctx "context"
 
 type requestIdKeyType struct{}
 type traceIdKeyType struct{}
+type idempotencyKeyType struct{}
 
 var (
-	requestIdKey requestIdKeyType
-	traceIdKey   traceIdKeyType
+	requestIdKey   requestIdKeyType
+	traceIdKey     traceIdKeyType
+	idempotencyKey idempotencyKeyType
 )
 
 func WithRequestId(ctx stdctx.Context, requestId string) stdctx.Context {
@@ -33,3 +35,16 @@ func GetTraceId(ctx stdctx.Context) string {
 	}
 	return l
 }
+
+// WithIdempotencyKey 设置当前业务操作的幂等键，httpclient 会将其放入非安全请求（默认 POST 与 PATCH）的 Idempotency-Key 请求头
+func WithIdempotencyKey(ctx stdctx.Context, key string) stdctx.Context {
+	return stdctx.WithValue(ctx, idempotencyKey, key)
+}
+
+func GetIdempotencyKey(ctx stdctx.Context) string {
+	l, ok := ctx.Value(idempotencyKey).(string)
+	if !ok {
+		return ""
+	}
+	return l
+}
@@ -0,0 +1,236 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrBatchFailed 批量请求中存在失败的请求
+	ErrBatchFailed = errors.New("batch request failed")
+	// ErrBatchAborted 批量请求被取消或因 fail-fast 中止，请求未被发送
+	ErrBatchAborted = errors.New("batch aborted before request started")
+)
+
+// BatchRequest 批量执行中的单个请求
+type BatchRequest struct {
+	// Method 默认 GET
+	Method string
+	URL    string
+	// Query 编码为查询字符串
+	Query interface{}
+	// Body 请求体，支持的类型与 NewRequest 相同
+	Body interface{}
+	// Result 响应解码的目标
+	Result  interface{}
+	Options []RequestOption
+}
+
+// BatchResult 单个请求的执行结果，Index 为请求在输入中的位置
+type BatchResult struct {
+	Index   int
+	Request *BatchRequest
+	Err     error
+}
+
+// BatchProgress 批量执行进度
+type BatchProgress struct {
+	// Total 请求总数，输入为迭代器时为 0
+	Total  int
+	Done   int
+	Failed int
+}
+
+// BatchOption 批量执行选项
+type BatchOption func(*batchConfig)
+
+type batchConfig struct {
+	concurrency int
+	interval    time.Duration
+	failFast    bool
+	progress    func(BatchProgress)
+}
+
+// WithConcurrency 设置最大并发数，默认 8
+func WithConcurrency(n int) BatchOption {
+	return func(c *batchConfig) {
+		c.concurrency = n
+	}
+}
+
+// WithRateLimit 限制每秒最多发起 perSecond 个请求
+func WithRateLimit(perSecond float64) BatchOption {
+	return func(c *batchConfig) {
+		if perSecond > 0 {
+			c.interval = time.Duration(float64(time.Second) / perSecond)
+		}
+	}
+}
+
+// WithFailFast 任一请求失败后停止发起新请求并取消进行中的请求
+func WithFailFast() BatchOption {
+	return func(c *batchConfig) {
+		c.failFast = true
+	}
+}
+
+// WithProgress 每个请求完成后回调当前进度，回调不会并发执行
+func WithProgress(fn func(BatchProgress)) BatchOption {
+	return func(c *batchConfig) {
+		c.progress = fn
+	}
+}
+
+// Batch 并发执行 reqs，返回的结果与 reqs 一一对应。
+// fail-fast 模式返回第一个失败请求的错误，否则有请求失败时返回 ErrBatchFailed。
+func (c *HTTPClient) Batch(ctx context.Context, reqs []*BatchRequest, opts ...BatchOption) ([]BatchResult, error) {
+	results, err := c.batch(ctx, slices.Values(reqs), len(reqs), opts)
+	for i := len(results); i < len(reqs); i++ {
+		results = append(results, BatchResult{Index: i, Request: reqs[i], Err: ErrBatchAborted})
+	}
+	return results, err
+}
+
+// BatchSeq 与 Batch 相同，但从迭代器中读取请求，结果只包含已读取的请求
+func (c *HTTPClient) BatchSeq(ctx context.Context, reqs iter.Seq[*BatchRequest], opts ...BatchOption) ([]BatchResult, error) {
+	return c.batch(ctx, reqs, 0, opts)
+}
+
+func (c *HTTPClient) batch(ctx context.Context, reqs iter.Seq[*BatchRequest], total int, opts []BatchOption) ([]BatchResult, error) {
+	cfg := &batchConfig{concurrency: 8}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.concurrency < 1 {
+		cfg.concurrency = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		results  []BatchResult
+		progress = BatchProgress{Total: total}
+		firstErr error
+	)
+	sem := make(chan struct{}, cfg.concurrency)
+	limiter := newRateLimiter(cfg.interval)
+
+	finish := func(index int, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		results[index].Err = err
+		progress.Done++
+		if err != nil {
+			progress.Failed++
+			if firstErr == nil {
+				firstErr = err
+			}
+			if cfg.failFast {
+				cancel()
+			}
+		}
+		if cfg.progress != nil {
+			cfg.progress(progress)
+		}
+	}
+
+	index := 0
+	for req := range reqs {
+		if err := limiter.wait(ctx); err != nil {
+			break
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		mu.Lock()
+		results = append(results, BatchResult{Index: index, Request: req})
+		mu.Unlock()
+
+		wg.Add(1)
+		go func(index int, req *BatchRequest) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			finish(index, c.execute(ctx, req))
+		}(index, req)
+		index++
+	}
+	wg.Wait()
+
+	if cfg.failFast && firstErr != nil {
+		return results, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return results, err
+	}
+	if progress.Failed > 0 {
+		return results, fmt.Errorf("%w: %d of %d failed", ErrBatchFailed, progress.Failed, progress.Done)
+	}
+	return results, nil
+}
+
+func (c *HTTPClient) execute(ctx context.Context, r *BatchRequest) error {
+	method := r.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	url, err := appendQuery(r.URL, r.Query)
+	if err != nil {
+		return err
+	}
+	req, err := NewRequest(ctx, method, url, r.Body)
+	if err != nil {
+		return err
+	}
+	return c.Do(ctx, req, r.Result, r.Options...)
+}
+
+// rateLimiter 按固定间隔放行请求
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newRateLimiter(interval time.Duration) *rateLimiter {
+	return &rateLimiter{interval: interval}
+}
+
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l.interval <= 0 {
+		return ctx.Err()
+	}
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
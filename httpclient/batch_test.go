@@ -0,0 +1,154 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type idResult struct {
+	ID int `json:"id"`
+}
+
+// newBatchServer 返回路径中的 id，id 能被 failEvery 整除时返回 500
+func newBatchServer(t *testing.T, failEvery int, inflight, maxInflight *atomic.Int32) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inflight.Add(1)
+		defer inflight.Add(-1)
+		for {
+			m := maxInflight.Load()
+			if n <= m || maxInflight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		id, _ := strconv.Atoi(r.URL.Query().Get("id"))
+		if failEvery > 0 && id%failEvery == 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(idResult{ID: id})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newBatchRequests(url string, n int) []*BatchRequest {
+	reqs := make([]*BatchRequest, n)
+	for i := range reqs {
+		reqs[i] = &BatchRequest{
+			URL: url,
+			Query: struct {
+				ID int `url:"id"`
+			}{ID: i + 1},
+			Result: &idResult{},
+		}
+	}
+	return reqs
+}
+
+func TestBatchCollectAll(t *testing.T) {
+	var inflight, maxInflight atomic.Int32
+	srv := newBatchServer(t, 5, &inflight, &maxInflight)
+	client := NewHTTPClient(&Config{})
+
+	var last BatchProgress
+	reqs := newBatchRequests(srv.URL, 20)
+	results, err := client.Batch(context.Background(), reqs, WithConcurrency(3), WithProgress(func(p BatchProgress) {
+		last = p
+	}))
+	if !errors.Is(err, ErrBatchFailed) {
+		t.Fatalf("Expected ErrBatchFailed, actual=%v", err)
+	}
+	if maxInflight.Load() > 3 {
+		t.Fatal("Concurrency limit exceeded. ", maxInflight.Load())
+	}
+	if last != (BatchProgress{Total: 20, Done: 20, Failed: 4}) {
+		t.Fatal("Progress not match. ", last)
+	}
+	for i, r := range results {
+		if r.Index != i || r.Request != reqs[i] {
+			t.Fatal("Results not in input order. ", i, r.Index)
+		}
+		if (i+1)%5 == 0 {
+			if r.Err == nil {
+				t.Fatal("Expected error. ", i)
+			}
+			continue
+		}
+		if r.Err != nil || r.Request.Result.(*idResult).ID != i+1 {
+			t.Fatal("Result not match. ", i, r.Err)
+		}
+	}
+}
+
+func TestBatchFailFast(t *testing.T) {
+	var inflight, maxInflight atomic.Int32
+	srv := newBatchServer(t, 2, &inflight, &maxInflight)
+	client := NewHTTPClient(&Config{})
+
+	reqs := newBatchRequests(srv.URL, 50)
+	results, err := client.Batch(context.Background(), reqs, WithConcurrency(1), WithFailFast())
+	if err == nil || errors.Is(err, ErrBatchFailed) {
+		t.Fatalf("Expected first request error, actual=%v", err)
+	}
+	if len(results) != 50 || results[0].Err != nil || results[1].Err == nil {
+		t.Fatal("Results not match. ", results[:2])
+	}
+	if !errors.Is(results[49].Err, ErrBatchAborted) {
+		t.Fatal("Remaining requests should be aborted. ", results[49].Err)
+	}
+}
+
+func TestBatchSeqRateLimit(t *testing.T) {
+	var inflight, maxInflight atomic.Int32
+	srv := newBatchServer(t, 0, &inflight, &maxInflight)
+	client := NewHTTPClient(&Config{})
+
+	seq := func(yield func(*BatchRequest) bool) {
+		for i := 1; i <= 5; i++ {
+			req := &BatchRequest{URL: fmt.Sprintf("%s?id=%d", srv.URL, i), Result: &idResult{}}
+			if !yield(req) {
+				return
+			}
+		}
+	}
+
+	start := time.Now()
+	results, err := client.BatchSeq(context.Background(), iter.Seq[*BatchRequest](seq), WithRateLimit(50))
+	if err != nil {
+		t.Fatal("Batch failed. ", err)
+	}
+	if len(results) != 5 {
+		t.Fatal("Result count not match. ", len(results))
+	}
+	// 5 个请求之间有 4 个间隔，每个间隔 20ms
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Fatal("Rate limit not applied. ", elapsed)
+	}
+}
+
+func TestBatchContextCanceled(t *testing.T) {
+	var inflight, maxInflight atomic.Int32
+	srv := newBatchServer(t, 0, &inflight, &maxInflight)
+	client := NewHTTPClient(&Config{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 25*time.Millisecond)
+	defer cancel()
+	results, err := client.Batch(ctx, newBatchRequests(srv.URL, 100), WithConcurrency(1))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded, actual=%v", err)
+	}
+	if !errors.Is(results[99].Err, ErrBatchAborted) {
+		t.Fatal("Remaining requests should be aborted. ", results[99].Err)
+	}
+}
@@ -0,0 +1,78 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ErrRequestBodyTooLarge 可重放请求体超过缓冲上限
+var ErrRequestBodyTooLarge = errors.New("request body exceeds replay buffer limit")
+
+// ReplayableBody 将 io.Reader 标记为可重复发送的请求体
+type ReplayableBody struct {
+	mu     sync.Mutex
+	reader io.Reader
+	limit  int64
+	seeker io.ReadSeeker
+	data   []byte
+	packed bool
+}
+
+// Replayable 首次发送时将 r 读入内存，最多缓冲 limit 字节，超过时返回 ErrRequestBodyTooLarge
+func Replayable(r io.Reader, limit int64) *ReplayableBody {
+	return &ReplayableBody{reader: r, limit: limit}
+}
+
+// ReplayableSeeker 使用可 Seek 的数据源（如 *os.File）作为请求体，每次发送前回到当前位置。
+// 同一时刻只能有一个请求读取该数据源，调用方负责关闭它。
+func ReplayableSeeker(rs io.ReadSeeker) *ReplayableBody {
+	return &ReplayableBody{seeker: rs}
+}
+
+func (b *ReplayableBody) pack() (*packedBody, error) {
+	if b.seeker != nil {
+		return packSeeker(b.seeker)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.packed {
+		data, err := io.ReadAll(io.LimitReader(b.reader, b.limit+1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to buffer request body: %w", err)
+		}
+		if int64(len(data)) > b.limit {
+			return nil, fmt.Errorf("%w: limit=%d", ErrRequestBodyTooLarge, b.limit)
+		}
+		b.data = data
+		b.packed = true
+	}
+	return packBytes(b.data), nil
+}
+
+// packSeeker 记录 rs 的当前位置与剩余长度，每次 GetBody 时回到该位置
+func packSeeker(rs io.ReadSeeker) (*packedBody, error) {
+	offset, err := rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	end, err := rs.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	length := end - offset
+
+	getBody := func() (io.ReadCloser, error) {
+		if _, err := rs.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		return io.NopCloser(io.LimitReader(rs, length)), nil
+	}
+	reader, err := getBody()
+	if err != nil {
+		return nil, err
+	}
+	return &packedBody{reader: reader, getBody: getBody, length: length}, nil
+}
@@ -0,0 +1,127 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type echoResponse struct {
+	Path string `json:"path"`
+	Body string `json:"body"`
+}
+
+// newRedirectServer /redirect 以 307 跳转到 /echo，/echo 返回收到的请求体
+func newRedirectServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/echo", http.StatusTemporaryRedirect)
+	})
+	mux.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write([]byte(`{"path":"` + r.URL.Path + `","body":"` + string(body) + `"}`))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestNewRequestGetBody(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   interface{}
+		length int64
+	}{
+		{name: "string", body: "hello", length: 5},
+		{name: "bytes", body: []byte("hello"), length: 5},
+		{name: "strings reader", body: strings.NewReader("hello"), length: 5},
+		{name: "struct", body: struct {
+			A int `json:"a"`
+		}{A: 1}, length: 8},
+		{name: "replayable reader", body: Replayable(io.MultiReader(strings.NewReader("hel"), strings.NewReader("lo")), 10), length: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := NewRequest(context.Background(), http.MethodPost, "http://example.com", tt.body)
+			if err != nil {
+				t.Fatal("NewRequest failed. ", err)
+			}
+			if req.ContentLength != tt.length {
+				t.Fatalf("ContentLength not match. expected=%d, actual=%d", tt.length, req.ContentLength)
+			}
+			if req.GetBody == nil {
+				t.Fatal("GetBody should be set")
+			}
+			first, _ := io.ReadAll(req.Body)
+			again, err := req.GetBody()
+			if err != nil {
+				t.Fatal("GetBody failed. ", err)
+			}
+			second, _ := io.ReadAll(again)
+			if string(first) != string(second) {
+				t.Fatalf("Replayed body not match. first=%q, second=%q", first, second)
+			}
+		})
+	}
+}
+
+func TestNewRequestOneShotReader(t *testing.T) {
+	req, err := NewRequest(context.Background(), http.MethodPost, "http://example.com", io.MultiReader(strings.NewReader("x")))
+	if err != nil {
+		t.Fatal("NewRequest failed. ", err)
+	}
+	if req.GetBody != nil {
+		t.Fatal("GetBody should be nil for one-shot reader")
+	}
+}
+
+func TestReplayableLimit(t *testing.T) {
+	_, err := NewRequest(context.Background(), http.MethodPost, "http://example.com", Replayable(strings.NewReader("too large"), 3))
+	if !errors.Is(err, ErrRequestBodyTooLarge) {
+		t.Fatalf("Expected ErrRequestBodyTooLarge, actual=%v", err)
+	}
+}
+
+func TestPostFollowsRedirectWithBody(t *testing.T) {
+	srv := newRedirectServer(t)
+	client := NewHTTPClient(&Config{})
+
+	path := filepath.Join(t.TempDir(), "body.txt")
+	if err := os.WriteFile(path, []byte("from-file"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	tests := []struct {
+		name string
+		body interface{}
+		want string
+	}{
+		{name: "string", body: "from-string", want: "from-string"},
+		{name: "replayable reader", body: Replayable(io.MultiReader(strings.NewReader("from-reader")), 1024), want: "from-reader"},
+		{name: "replayable file", body: ReplayableSeeker(f), want: "from-file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp echoResponse
+			if err := client.Post(context.Background(), srv.URL+"/redirect", tt.body, &resp); err != nil {
+				t.Fatal("Post failed. ", err)
+			}
+			if resp.Path != "/echo" || resp.Body != tt.want {
+				t.Fatalf("Redirected body not match. path=%s, body=%q", resp.Path, resp.Body)
+			}
+		})
+	}
+}
@@ -5,9 +5,11 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/bookiu/gopkg/util/types"
@@ -15,64 +17,236 @@ import (
 )
 
 type Client interface {
-	Do(ctx context.Context, req *http.Request, result interface{}) error
+	Do(ctx context.Context, req *http.Request, result interface{}, opts ...RequestOption) error
 	Get(ctx context.Context, url string, query interface{}, result interface{}, opts ...RequestOption) error
 	Post(ctx context.Context, url string, body interface{}, result interface{}, opts ...RequestOption) error
 	PostJson(ctx context.Context, url string, body interface{}, result interface{}, opts ...RequestOption) error
 }
 
 type Config struct {
+	// BaseURL 请求地址没有 Host 时拼接在前面，unix:///var/run/docker.sock 表示通过 Unix socket 访问
+	BaseURL   string
 	Timeout   time.Duration
 	ProxyFunc func(*http.Request) (*url.URL, error)
+	// Proxy 声明式的代理设置，ProxyFunc 不为空时忽略
+	Proxy *ProxyConfig
+	// DialContext 自定义建立连接的方式，优先于 Dial
+	DialContext DialContextFunc
+	// Dial 连接超时、happy eyeballs、DNS 服务器与 DNS 缓存设置
+	Dial *DialConfig
+	// Jar 为 nil 时不保存 cookie，可使用 NewCookieJar 创建
+	Jar http.CookieJar
 
 	Auth     AuthProvider
 	Response ResponseHandler
 	Observe  ObserveProvider
+	// Sanitizer 观测数据中 URL 的脱敏设置，为 nil 时使用默认设置
+	Sanitizer *URLSanitizer
+	Retry     *RetryPolicy
+	// Idempotency 为 nil 时只使用调用方提供的幂等键
+	Idempotency *IdempotencyPolicy
+	// MaxResponseSize 响应体最大字节数，超过时返回 ErrResponseTooLarge，为 0 时不限制
+	MaxResponseSize int64
+	// ValidateResult 解码后对实现了 Validator 的结果调用 Validate
+	ValidateResult bool
+	// Coalesce 不为 nil 时合并相同的并发 GET 请求
+	Coalesce *CoalesceConfig
+	// Fault 注入故障，仅用于测试
+	Fault *FaultInjector
+}
+
+// RequestSettings 单次请求的设置，零值字段表示沿用 Config
+type RequestSettings struct {
+	// Timeout 本次请求的超时时间，包含读取响应体
+	Timeout time.Duration
+	// Response 本次请求使用的 ResponseHandler
+	Response ResponseHandler
+	// StatusHandlers 按状态码选择 ResponseHandler，优先于 Response
+	StatusHandlers map[int]ResponseHandler
+	// ErrorHandler 状态码不小于 400 且不在 StatusHandlers 中时使用的 ResponseHandler
+	ErrorHandler ResponseHandler
+	// SkipAuth 不使用 Config.Auth
+	SkipAuth bool
+	// Retry 本次请求的重试策略
+	Retry *RetryPolicy
+	// Route 路由名称，用于日志与监控指标，如 "GET /users/{id}"
+	Route string
+	// MaxResponseSize 响应体最大字节数，覆盖 Config.MaxResponseSize
+	MaxResponseSize int64
+	// IdempotencyKey 本次请求的幂等键
+	IdempotencyKey string
+	// SkipCoalesce 不与其他请求合并
+	SkipCoalesce bool
+	// Schema 校验 2xx 响应的原始响应体
+	Schema *JSONSchema
+	// ValidateResult 解码后对实现了 Validator 的结果调用 Validate
+	ValidateResult bool
+
+	editors         []func(*http.Request)
+	idempotencyInfo *IdempotencyInfo
+	// auth 本次请求使用的 AuthProvider，重试时不变
+	auth AuthProvider
 }
 
 // RequestOption 定义用于配置请求的函数选项类型
-type RequestOption func(*http.Request)
+type RequestOption func(*RequestSettings)
+
+func newRequestSettings(opts []RequestOption) *RequestSettings {
+	s := &RequestSettings{}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// apply 将请求头等修改应用到 req
+func (s *RequestSettings) apply(req *http.Request) {
+	for _, edit := range s.editors {
+		edit(req)
+	}
+}
+
+// responseHandler 返回处理 status 响应的 ResponseHandler，未设置时使用 fallback
+func (s *RequestSettings) responseHandler(status int, fallback ResponseHandler) ResponseHandler {
+	if h, ok := s.StatusHandlers[status]; ok {
+		return h
+	}
+	if status >= http.StatusBadRequest && s.ErrorHandler != nil {
+		return s.ErrorHandler
+	}
+	if s.Response != nil {
+		return s.Response
+	}
+	return fallback
+}
+
+// WithRequestFunc 直接修改 *http.Request
+func WithRequestFunc(fn func(*http.Request)) RequestOption {
+	return func(s *RequestSettings) {
+		s.editors = append(s.editors, fn)
+	}
+}
 
 // WithHeader 设置单个请求头
 func WithHeader(key, value string) RequestOption {
-	return func(req *http.Request) {
+	return WithRequestFunc(func(req *http.Request) {
 		req.Header.Set(key, value)
-	}
+	})
 }
 
 // WithHeaders 设置多个请求头
 func WithHeaders(headers map[string]string) RequestOption {
-	return func(req *http.Request) {
+	return WithRequestFunc(func(req *http.Request) {
 		for key, value := range headers {
 			req.Header.Set(key, value)
 		}
-	}
+	})
 }
 
 // WithContentType 设置 Content-Type 头
 func WithContentType(contentType string) RequestOption {
-	return func(req *http.Request) {
+	return WithRequestFunc(func(req *http.Request) {
 		req.Header.Set("Content-Type", contentType)
-	}
+	})
 }
 
 // WithBearerToken 设置 Bearer Token 认证头
 func WithBearerToken(token string) RequestOption {
-	return func(req *http.Request) {
+	return WithRequestFunc(func(req *http.Request) {
 		req.Header.Set("Authorization", "Bearer "+token)
-	}
+	})
 }
 
 // WithUserAgent 设置 User-Agent 头
 func WithUserAgent(userAgent string) RequestOption {
-	return func(req *http.Request) {
+	return WithRequestFunc(func(req *http.Request) {
 		req.Header.Set("User-Agent", userAgent)
+	})
+}
+
+// WithTimeout 设置本次请求的超时时间
+func WithTimeout(timeout time.Duration) RequestOption {
+	return func(s *RequestSettings) {
+		s.Timeout = timeout
+	}
+}
+
+// WithResponseHandler 设置本次请求使用的 ResponseHandler
+func WithResponseHandler(handler ResponseHandler) RequestOption {
+	return func(s *RequestSettings) {
+		s.Response = handler
+	}
+}
+
+// WithStatusHandler 响应状态码为 status 时使用 handler 处理响应
+func WithStatusHandler(status int, handler ResponseHandler) RequestOption {
+	return func(s *RequestSettings) {
+		if s.StatusHandlers == nil {
+			s.StatusHandlers = map[int]ResponseHandler{}
+		}
+		s.StatusHandlers[status] = handler
 	}
 }
 
+// WithErrorHandler 响应状态码不小于 400 且没有通过 WithStatusHandler 指定时使用 handler 处理响应
+func WithErrorHandler(handler ResponseHandler) RequestOption {
+	return func(s *RequestSettings) {
+		s.ErrorHandler = handler
+	}
+}
+
+// WithoutAuth 本次请求不使用 Config.Auth
+func WithoutAuth() RequestOption {
+	return func(s *RequestSettings) {
+		s.SkipAuth = true
+	}
+}
+
+// WithRetry 设置本次请求的重试策略
+func WithRetry(policy *RetryPolicy) RequestOption {
+	return func(s *RequestSettings) {
+		s.Retry = policy
+	}
+}
+
+// WithRoute 设置路由名称，用于日志与监控指标
+func WithRoute(route string) RequestOption {
+	return func(s *RequestSettings) {
+		s.Route = route
+	}
+}
+
+// WithMaxResponseSize 设置本次请求响应体的最大字节数
+func WithMaxResponseSize(n int64) RequestOption {
+	return func(s *RequestSettings) {
+		s.MaxResponseSize = n
+	}
+}
+
+type settingsKeyType struct{}
+
+var settingsKey settingsKeyType
+
+// RouteFromContext 返回当前请求通过 WithRoute 设置的路由名称
+func RouteFromContext(ctx context.Context) string {
+	s, ok := ctx.Value(settingsKey).(*RequestSettings)
+	if !ok {
+		return ""
+	}
+	return s.Route
+}
+
 type HTTPClient struct {
-	config *Config
-	client *http.Client
+	config   *Config
+	client   *http.Client
+	observer EventObserveProvider
+	// sanitizer 观测数据中 URL 的脱敏设置
+	sanitizer *URLSanitizer
+	baseURL   *url.URL
+	// coalesce 为 nil 时不合并请求
+	coalesce *coalesceGroup
+	// initErr 初始化时的配置错误，在发送请求时返回
+	initErr error
 }
 
 func NewHTTPClient(config *Config) *HTTPClient {
@@ -83,70 +257,187 @@ func NewHTTPClient(config *Config) *HTTPClient {
 		config.Observe = &NoopObserve{}
 	}
 
+	baseURL, socketPath, err := parseBaseURL(config.BaseURL)
+	transport, terr := newTransport(config, socketPath)
+	var roundTripper http.RoundTripper = transport
+	if config.Fault != nil {
+		roundTripper = config.Fault.Wrap(transport)
+	}
 	return &HTTPClient{
 		config: config,
 		client: &http.Client{
-			Timeout: config.Timeout,
-			Transport: &http.Transport{
-				Proxy: config.ProxyFunc,
-			},
+			Timeout:   config.Timeout,
+			Jar:       config.Jar,
+			Transport: roundTripper,
 		},
+		observer:  eventObserver(config.Observe),
+		sanitizer: newURLSanitizer(config),
+		baseURL:   baseURL,
+		coalesce:  newCoalesceGroup(config.Coalesce),
+		initErr:   errors.Join(err, terr),
 	}
 }
 
-func (c *HTTPClient) Do(ctx context.Context, req *http.Request, result interface{}) error {
-	if c.config.Auth != nil {
-		c.config.Auth.Apply(req)
+// newTransport 根据 Config 创建 http.Transport
+func newTransport(config *Config, socketPath string) (*http.Transport, error) {
+	proxy := config.ProxyFunc
+	if proxy == nil && config.Proxy != nil {
+		var err error
+		if proxy, err = newProxyFunc(config.Proxy); err != nil {
+			return &http.Transport{}, err
+		}
 	}
+	return &http.Transport{
+		Proxy:       proxy,
+		DialContext: newDialContext(config, socketPath),
+	}, nil
+}
 
-	startTime := time.Now()
-	resp, err := c.client.Do(req.WithContext(ctx))
-	if err != nil {
-		c.config.Observe.RecordRequest(ctx, req.Method, req.URL.String(), 0, time.Since(startTime), err)
+func (c *HTTPClient) Do(ctx context.Context, req *http.Request, result interface{}, opts ...RequestOption) error {
+	s := newRequestSettings(opts)
+	s.apply(req)
+	return c.do(ctx, req, result, s)
+}
+
+func (c *HTTPClient) do(ctx context.Context, req *http.Request, result interface{}, s *RequestSettings) error {
+	if c.initErr != nil {
+		return c.initErr
+	}
+	req.URL = resolveURL(c.baseURL, req.URL)
+	if req.Host == "" {
+		req.Host = req.URL.Host
+	}
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+	ctx = context.WithValue(ctx, settingsKey, s)
+	if err := c.bindAuth(s); err != nil {
 		return err
 	}
-	c.config.Observe.RecordRequest(ctx, req.Method, req.URL.String(), resp.StatusCode, time.Since(startTime), err)
+	maxSize := c.config.MaxResponseSize
+	if s.MaxResponseSize > 0 {
+		maxSize = s.MaxResponseSize
+	}
 
-	defer resp.Body.Close()
-	return c.config.Response.Handle(resp, result)
-}
+	var resp *http.Response
+	var err error
+	if key := c.coalesce.key(req, s, maxSize); key != "" {
+		if resp, err = c.sendCoalesced(ctx, key, req, s, maxSize); err != nil {
+			return err
+		}
+	} else {
+		key := c.applyIdempotencyKey(ctx, req, s)
+		if resp, err = c.send(ctx, req, s); err != nil {
+			return err
+		}
+		c.recordIdempotency(key, resp, s)
+		if maxSize > 0 {
+			resp.Body = newLimitedBody(resp.Body, maxSize)
+		}
+	}
+	defer drainBody(resp.Body)
 
-func (c *HTTPClient) Get(ctx context.Context, url string, q interface{}, result interface{}, opts ...RequestOption) error {
-	finalUrl := url
-	if q != nil {
-		v, err := query.Values(q)
+	success := resp.StatusCode >= 200 && resp.StatusCode < 300
+	if s.Schema != nil && success {
+		data, err := io.ReadAll(resp.Body)
 		if err != nil {
+			return fmt.Errorf("failed to read response: %w", err)
+		}
+		if err := s.Schema.ValidateJSON(data); err != nil {
 			return err
 		}
-		finalUrl = url + "?" + v.Encode()
+		resp.Body = io.NopCloser(bytes.NewReader(data))
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, finalUrl, nil)
-	if err != nil {
+	if err := s.responseHandler(resp.StatusCode, c.config.Response).Handle(resp, result); err != nil {
 		return err
 	}
+	if (c.config.ValidateResult || s.ValidateResult) && success {
+		return validateResult(result)
+	}
+	return nil
+}
 
-	for _, opt := range opts {
-		opt(req)
+// send 按重试策略发送请求，调用方负责关闭响应体
+func (c *HTTPClient) send(ctx context.Context, req *http.Request, s *RequestSettings) (*http.Response, error) {
+	policy := c.config.Retry
+	if s.Retry != nil {
+		policy = s.Retry
+	}
+	attempts := policy.attempts()
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		// 请求体无法重放
+		attempts = 1
 	}
 
-	return c.Do(ctx, req, result)
+	for attempt := 1; ; attempt++ {
+		r := req.Clone(ctx)
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r.Body = body
+		}
+
+		resp, err := c.roundTrip(ctx, r, s, attempt)
+		if attempt >= attempts || ctx.Err() != nil || !policy.shouldRetry(resp, err) {
+			return resp, err
+		}
+		if resp != nil {
+			drainBody(resp.Body)
+		}
+		if err := policy.wait(ctx, attempt); err != nil {
+			return nil, err
+		}
+	}
 }
 
-func (c *HTTPClient) Post(ctx context.Context, url string, body interface{}, result interface{}, opts ...RequestOption) error {
-	payload, err := packBody(body)
+// bindAuth 确定本次请求使用的 AuthProvider，凭据会变化时固定为当前凭据
+func (c *HTTPClient) bindAuth(s *RequestSettings) error {
+	if c.config.Auth == nil || s.SkipAuth {
+		return nil
+	}
+	s.auth = c.config.Auth
+	if snapshotter, ok := c.config.Auth.(AuthSnapshotter); ok {
+		auth, err := snapshotter.Snapshot()
+		if err != nil {
+			return fmt.Errorf("failed to load credential: %w", err)
+		}
+		s.auth = auth
+	}
+	return nil
+}
+
+// roundTrip 完成认证、发送单次请求并记录观测数据
+func (c *HTTPClient) roundTrip(ctx context.Context, req *http.Request, s *RequestSettings, attempt int) (*http.Response, error) {
+	if s.auth != nil {
+		s.auth.Apply(req)
+	}
+	return c.observe(ctx, req, attempt, c.client.Do)
+}
+
+func (c *HTTPClient) Get(ctx context.Context, url string, q interface{}, result interface{}, opts ...RequestOption) error {
+	finalUrl, err := appendQuery(url, q)
 	if err != nil {
 		return err
 	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, finalUrl, nil)
 	if err != nil {
 		return err
 	}
+	return c.Do(ctx, req, result, opts...)
+}
 
-	for _, opt := range opts {
-		opt(req)
+func (c *HTTPClient) Post(ctx context.Context, url string, body interface{}, result interface{}, opts ...RequestOption) error {
+	req, err := NewRequest(ctx, http.MethodPost, url, body)
+	if err != nil {
+		return err
 	}
-	return c.Do(ctx, req, result)
+	return c.Do(ctx, req, result, opts...)
 }
 
 func (c *HTTPClient) PostJson(ctx context.Context, url string, body interface{}, result interface{}, opts ...RequestOption) error {
@@ -154,29 +445,88 @@ func (c *HTTPClient) PostJson(ctx context.Context, url string, body interface{},
 	return c.Post(ctx, url, body, result, opts...)
 }
 
-func packBody(body interface{}) (io.Reader, error) {
-	if body == nil {
-		return nil, nil
+// appendQuery 将 q 编码为查询字符串拼接到 url 后
+func appendQuery(url string, q interface{}) (string, error) {
+	if q == nil {
+		return url, nil
 	}
-	// if body is struct, return json.NewEncoder(body)
-	if types.IsStruct(body) {
-		buf := new(bytes.Buffer)
-		err := json.NewEncoder(buf).Encode(body)
-		return buf, err
+	v, err := query.Values(q)
+	if err != nil {
+		return "", err
+	}
+	return url + "?" + v.Encode(), nil
+}
+
+// NewRequest 创建请求，body 支持 struct、string、[]byte、io.Reader 与 *ReplayableBody。
+// 内存中的请求体以及 *ReplayableBody 会设置 GetBody 与 ContentLength，
+// 以便重定向、重试等场景可以重新发送请求体。
+func NewRequest(ctx context.Context, method, url string, body interface{}) (*http.Request, error) {
+	b, err := packBody(body)
+	if err != nil {
+		return nil, err
 	}
-	// if body is io.Reader, return body
-	if body, ok := body.(io.Reader); ok {
-		return body, nil
+	req, err := http.NewRequestWithContext(ctx, method, url, b.reader)
+	if err != nil {
+		return nil, err
 	}
+	if b.getBody != nil {
+		req.ContentLength = b.length
+		req.GetBody = b.getBody
+		if b.length == 0 {
+			req.Body = http.NoBody
+		}
+	}
+	return req, nil
+}
+
+// packedBody 打包后的请求体，getBody 为 nil 表示请求体只能读取一次
+type packedBody struct {
+	reader  io.Reader
+	getBody func() (io.ReadCloser, error)
+	length  int64
+}
 
-	// if body is string, return bytes.NewBufferString(body)
-	if body, ok := body.(string); ok {
-		return bytes.NewBufferString(body), nil
+func packBody(body interface{}) (*packedBody, error) {
+	if body == nil {
+		return &packedBody{}, nil
 	}
 
-	// if body is []byte, return bytes.NewBuffer(body)
-	if body, ok := body.([]byte); ok {
-		return bytes.NewBuffer(body), nil
+	switch v := body.(type) {
+	case *ReplayableBody:
+		return v.pack()
+	case string:
+		return packBytes([]byte(v)), nil
+	case []byte:
+		return packBytes(v), nil
+	case *bytes.Buffer:
+		return packBytes(v.Bytes()), nil
+	case *bytes.Reader:
+		return packSeeker(v)
+	case *strings.Reader:
+		return packSeeker(v)
+	case io.Reader:
+		// 普通 io.Reader 只能读取一次，需要重放时使用 Replayable 或 ReplayableSeeker 标记
+		return &packedBody{reader: v}, nil
+	}
+
+	// if body is struct, encode it as json
+	if types.IsStruct(body) {
+		buf := new(bytes.Buffer)
+		if err := json.NewEncoder(buf).Encode(body); err != nil {
+			return nil, err
+		}
+		return packBytes(buf.Bytes()), nil
 	}
 	return nil, errors.New("unsupported body type")
 }
+
+func packBytes(data []byte) *packedBody {
+	getBody := func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	return &packedBody{
+		reader:  bytes.NewReader(data),
+		getBody: getBody,
+		length:  int64(len(data)),
+	}
+}
@@ -2,6 +2,13 @@ package httpclient
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
 	"testing"
 	"time"
 )
@@ -90,3 +97,208 @@ func TestAuthWithAPIKey(t *testing.T) {
 		t.Fatal("APIKey header not match. ", resp.Headers["Token"])
 	}
 }
+
+// newHeadersServer 返回请求头，行为与 httpbin 的 /headers 接口一致
+func newHeadersServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(headersResponse{Headers: r.Header})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRequestOptionHeaders(t *testing.T) {
+	srv := newHeadersServer(t)
+	client := NewHTTPClient(&Config{
+		Auth: &AuthBearerToken{Token: "config-token"},
+	})
+
+	var resp headersResponse
+	err := client.Get(context.Background(), srv.URL, nil, &resp,
+		WithHeader("X-Test", "1"), WithBearerToken("option-token"), WithUserAgent("gopkg"))
+	if err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+	if resp.Headers["X-Test"][0] != "1" || resp.Headers["User-Agent"][0] != "gopkg" {
+		t.Fatal("Headers not match. ", resp.Headers)
+	}
+	// Config.Auth 在 RequestOption 之后生效
+	if resp.Headers["Authorization"][0] != "Bearer config-token" {
+		t.Fatal("Authorization header not match. ", resp.Headers["Authorization"])
+	}
+
+	resp = headersResponse{}
+	err = client.Get(context.Background(), srv.URL, nil, &resp, WithoutAuth(), WithBearerToken("option-token"))
+	if err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+	if resp.Headers["Authorization"][0] != "Bearer option-token" {
+		t.Fatal("Authorization header not match. ", resp.Headers["Authorization"])
+	}
+}
+
+func TestRequestOptionTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	client := NewHTTPClient(&Config{Timeout: 5 * time.Second})
+
+	err := client.Get(context.Background(), srv.URL, nil, nil, WithTimeout(50*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded, actual=%v", err)
+	}
+}
+
+func TestRequestOptionResponseHandler(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code":0,"msg":"ok","data":{"name":"gopkg"}}`))
+	}))
+	defer srv.Close()
+	client := NewHTTPClient(&Config{})
+
+	var result struct {
+		Name string `json:"name"`
+	}
+	err := client.Get(context.Background(), srv.URL, nil, &result, WithResponseHandler(&CodeWrapperResponseHandler{}))
+	if err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+	if result.Name != "gopkg" {
+		t.Fatal("Result not match. ", result)
+	}
+}
+
+func TestRequestOptionRetry(t *testing.T) {
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		body, _ := io.ReadAll(r.Body)
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"body": string(body)})
+	}))
+	defer srv.Close()
+	client := NewHTTPClient(&Config{Retry: &RetryPolicy{MaxAttempts: 2}})
+
+	var result map[string]string
+	err := client.Post(context.Background(), srv.URL, "payload", &result)
+	if err == nil {
+		t.Fatal("Expected error with config retry policy")
+	}
+
+	attempts = 0
+	err = client.Post(context.Background(), srv.URL, "payload", &result,
+		WithRetry(&RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}))
+	if err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+	if attempts != 3 || result["body"] != "payload" {
+		t.Fatalf("Retry not match. attempts=%d, result=%v", attempts, result)
+	}
+}
+
+func TestRetryPolicyWaitOverflow(t *testing.T) {
+	policy := &RetryPolicy{Backoff: time.Second}
+	// 多次翻倍后不会溢出为负数而跳过等待
+	for _, attempt := range []int{40, 64, 1000} {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		err := policy.wait(ctx, attempt)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Expected wait until deadline. attempt=%d, err=%v", attempt, err)
+		}
+	}
+}
+
+type routeObserve struct {
+	route string
+}
+
+func (o *routeObserve) RecordRequest(ctx context.Context, method, url string, statusCode int, duration time.Duration, err error) {
+	o.route = RouteFromContext(ctx)
+}
+
+func TestRequestOptionRoute(t *testing.T) {
+	srv := newHeadersServer(t)
+	observe := &routeObserve{}
+	client := NewHTTPClient(&Config{Observe: observe})
+
+	var resp headersResponse
+	if err := client.Get(context.Background(), srv.URL, nil, &resp, WithRoute("GET /headers")); err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+	if observe.route != "GET /headers" {
+		t.Fatal("Route not match. ", observe.route)
+	}
+}
+
+func TestRequestOptionMaxResponseSize(t *testing.T) {
+	srv := newHeadersServer(t)
+	client := NewHTTPClient(&Config{})
+
+	var resp headersResponse
+	err := client.Get(context.Background(), srv.URL, nil, &resp, WithMaxResponseSize(10))
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Fatalf("Expected ErrResponseTooLarge, actual=%v", err)
+	}
+
+	err = client.Get(context.Background(), srv.URL, nil, &resp, WithMaxResponseSize(4096))
+	if err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+}
+
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("status %d", e.code)
+}
+
+type responseHandlerFunc func(*http.Response, interface{}) error
+
+func (f responseHandlerFunc) Handle(resp *http.Response, result interface{}) error {
+	return f(resp, result)
+}
+
+func TestRequestOptionStatusHandler(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		code, _ := strconv.Atoi(r.URL.Query().Get("code"))
+		w.WriteHeader(code)
+		_, _ = w.Write([]byte(`{"id":1}`))
+	}))
+	defer srv.Close()
+
+	client := NewHTTPClient(&Config{})
+	errorHandler := WithErrorHandler(responseHandlerFunc(func(resp *http.Response, _ interface{}) error {
+		return &statusError{code: resp.StatusCode}
+	}))
+	created := WithStatusHandler(http.StatusCreated, responseHandlerFunc(func(resp *http.Response, result interface{}) error {
+		return json.NewDecoder(resp.Body).Decode(result)
+	}))
+
+	var result map[string]int
+	err := client.Get(context.Background(), srv.URL+"?code=404", nil, &result, errorHandler, created)
+	var se *statusError
+	if !errors.As(err, &se) || se.code != http.StatusNotFound {
+		t.Fatal("Expected status error. ", err)
+	}
+
+	// 没有 WithStatusHandler 时 DirectResponseHandler 不接受 201
+	if err := client.Get(context.Background(), srv.URL+"?code=201", nil, &result, errorHandler); err == nil {
+		t.Fatal("Expected unexpected status code error")
+	}
+	if err := client.Get(context.Background(), srv.URL+"?code=201", nil, &result, errorHandler, created); err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+	if result["id"] != 1 {
+		t.Fatal("Result not match. ", result)
+	}
+}
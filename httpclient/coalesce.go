@@ -0,0 +1,170 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CoalesceConfig 合并相同的并发 GET 请求，同一时刻只发送一个请求，结果由所有调用方共享。
+// 只有 Timeout、Retry（同一个 *RetryPolicy）和 Route 都相同的请求才会合并，
+// Schema 与 ValidateResult 由各调用方分别校验
+type CoalesceConfig struct {
+	// Headers 参与合并键的请求头，Authorization 与 Cookie 总是参与
+	Headers []string
+}
+
+// WithoutCoalesce 本次请求不与其他请求合并
+func WithoutCoalesce() RequestOption {
+	return func(s *RequestSettings) {
+		s.SkipCoalesce = true
+	}
+}
+
+// coalescedResponse 共享的响应，响应体已读入内存
+type coalescedResponse struct {
+	resp *http.Response
+	body []byte
+	err  error
+}
+
+// response 返回调用方独立使用的响应副本
+func (r *coalescedResponse) response() *http.Response {
+	resp := *r.resp
+	resp.Header = r.resp.Header.Clone()
+	resp.Body = io.NopCloser(bytes.NewReader(r.body))
+	return &resp
+}
+
+type coalescedCall struct {
+	done    chan struct{}
+	result  coalescedResponse
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// coalesceGroup 按键合并进行中的请求。共享请求不受单个调用方的 ctx 影响，
+// 所有调用方都放弃等待后才会取消
+type coalesceGroup struct {
+	headers []string
+
+	mu    sync.Mutex
+	calls map[string]*coalescedCall
+}
+
+func newCoalesceGroup(config *CoalesceConfig) *coalesceGroup {
+	if config == nil {
+		return nil
+	}
+	headers := []string{"Authorization", "Cookie"}
+	for _, h := range config.Headers {
+		h = http.CanonicalHeaderKey(h)
+		if !slices.Contains(headers, h) {
+			headers = append(headers, h)
+		}
+	}
+	slices.Sort(headers)
+	return &coalesceGroup{headers: headers, calls: map[string]*coalescedCall{}}
+}
+
+// key 返回合并键，不能合并时返回空字符串
+func (g *coalesceGroup) key(req *http.Request, s *RequestSettings, maxSize int64) string {
+	if g == nil || s.SkipCoalesce || req.Method != http.MethodGet || (req.Body != nil && req.Body != http.NoBody) {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(req.Method)
+	b.WriteByte(' ')
+	b.WriteString(req.URL.String())
+	for _, h := range g.headers {
+		for _, v := range req.Header.Values(h) {
+			b.WriteByte('\n')
+			b.WriteString(h)
+			b.WriteString(": ")
+			b.WriteString(v)
+		}
+	}
+	b.WriteString("\nskip-auth=" + strconv.FormatBool(s.SkipAuth))
+	b.WriteString("\nmax-size=" + strconv.FormatInt(maxSize, 10))
+	// 共享请求按这些设置发送，设置不同的请求不能合并
+	b.WriteString("\ntimeout=" + s.Timeout.String())
+	b.WriteString("\nroute=" + s.Route)
+	b.WriteString(fmt.Sprintf("\nretry=%p", s.Retry))
+	return b.String()
+}
+
+// do 执行或等待 key 对应的请求，fn 使用与调用方 ctx 解除取消关系的 ctx
+func (g *coalesceGroup) do(ctx context.Context, key string, fn func(ctx context.Context) coalescedResponse) (*http.Response, error) {
+	g.mu.Lock()
+	call, ok := g.calls[key]
+	if !ok {
+		sharedCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		call = &coalescedCall{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = call
+		go func() {
+			call.result = fn(sharedCtx)
+			g.mu.Lock()
+			if g.calls[key] == call {
+				delete(g.calls, key)
+			}
+			g.mu.Unlock()
+			cancel()
+			close(call.done)
+		}()
+	}
+	call.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-call.done:
+		if call.result.err != nil {
+			return nil, call.result.err
+		}
+		return call.result.response(), nil
+	case <-ctx.Done():
+		g.mu.Lock()
+		call.waiters--
+		if call.waiters == 0 {
+			// 没有调用方在等待，取消共享请求，之后的调用方重新发起请求
+			call.cancel()
+			if g.calls[key] == call {
+				delete(g.calls, key)
+			}
+		}
+		g.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// sendCoalesced 发送可能与其他调用方共享的请求，响应体读入内存后各调用方独立解码
+func (c *HTTPClient) sendCoalesced(ctx context.Context, key string, req *http.Request, s *RequestSettings, maxSize int64) (*http.Response, error) {
+	return c.coalesce.do(ctx, key, func(ctx context.Context) coalescedResponse {
+		// 共享请求的 ctx 不继承调用方的 deadline，按相同的 Timeout 重新设置
+		if s.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+			defer cancel()
+		}
+		resp, err := c.send(ctx, req, s)
+		if err != nil {
+			return coalescedResponse{err: err}
+		}
+		var body io.ReadCloser = resp.Body
+		if maxSize > 0 {
+			body = newLimitedBody(body, maxSize)
+		}
+		defer drainBody(body)
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return coalescedResponse{err: err}
+		}
+		resp.Body = nil
+		return coalescedResponse{resp: resp, body: data}
+	})
+}
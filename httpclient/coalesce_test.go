@@ -0,0 +1,192 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newCoalesceServer 请求在 release 关闭前阻塞，canceled 记录服务端看到的请求取消次数
+func newCoalesceServer(t *testing.T) (srv *httptest.Server, hits, canceled *atomic.Int32, release chan struct{}) {
+	hits, canceled = &atomic.Int32{}, &atomic.Int32{}
+	release = make(chan struct{})
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		select {
+		case <-release:
+			_, _ = w.Write([]byte(`{"tenant":"` + r.Header.Get("X-Tenant") + `"}`))
+		case <-r.Context().Done():
+			canceled.Add(1)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, hits, canceled, release
+}
+
+// waitForWaiters 等待合并组中的调用方数量达到 n
+func waitForWaiters(t *testing.T, g *coalesceGroup, n int) {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		g.mu.Lock()
+		total := 0
+		for _, call := range g.calls {
+			total += call.waiters
+		}
+		g.mu.Unlock()
+		if total == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Waiters not match. expected=%d", n)
+}
+
+func TestCoalesceIdenticalGets(t *testing.T) {
+	srv, hits, _, release := newCoalesceServer(t)
+	client := NewHTTPClient(&Config{Coalesce: &CoalesceConfig{Headers: []string{"X-Tenant"}}})
+
+	const n = 10
+	results := make([]map[string]string, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tenant := "a"
+			if i%2 == 1 {
+				tenant = "b"
+			}
+			errs[i] = client.Get(context.Background(), srv.URL+"/users", nil, &results[i], WithHeader("X-Tenant", tenant))
+		}(i)
+	}
+	waitForWaiters(t, client.coalesce, n)
+	close(release)
+	wg.Wait()
+
+	if hits.Load() != 2 {
+		t.Fatalf("Hits not match. expected=%d, actual=%d", 2, hits.Load())
+	}
+	for i := range results {
+		expected := "a"
+		if i%2 == 1 {
+			expected = "b"
+		}
+		if errs[i] != nil || results[i]["tenant"] != expected {
+			t.Fatal("Result not match. ", i, results[i], errs[i])
+		}
+	}
+	// 每个调用方得到独立的结果
+	results[0]["tenant"] = "changed"
+	if results[2]["tenant"] != "a" {
+		t.Fatal("Results should be independent")
+	}
+
+	// 完成后的请求不再合并
+	if err := client.Get(context.Background(), srv.URL+"/users", nil, &results[0], WithHeader("X-Tenant", "a")); err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+	if hits.Load() != 3 {
+		t.Fatalf("Hits not match. expected=%d, actual=%d", 3, hits.Load())
+	}
+}
+
+func TestCoalesceCallerCancel(t *testing.T) {
+	srv, hits, canceled, release := newCoalesceServer(t)
+	client := NewHTTPClient(&Config{Coalesce: &CoalesceConfig{}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		var result map[string]string
+		first <- client.Get(ctx, srv.URL, nil, &result)
+	}()
+	waitForWaiters(t, client.coalesce, 1)
+
+	var result map[string]string
+	second := make(chan error, 1)
+	go func() {
+		second <- client.Get(context.Background(), srv.URL, nil, &result)
+	}()
+	waitForWaiters(t, client.coalesce, 2)
+
+	// 第一个调用方取消不影响共享请求
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, actual=%v", err)
+	}
+	close(release)
+	if err := <-second; err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+	if hits.Load() != 1 || canceled.Load() != 0 {
+		t.Fatal("Shared request should not be canceled. ", hits.Load(), canceled.Load())
+	}
+}
+
+func TestCoalesceAllCallersCancel(t *testing.T) {
+	srv, _, canceled, _ := newCoalesceServer(t)
+	client := NewHTTPClient(&Config{Coalesce: &CoalesceConfig{}})
+
+	var result map[string]string
+	err := client.Get(context.Background(), srv.URL, nil, &result, WithTimeout(20*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded, actual=%v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for canceled.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if canceled.Load() != 1 {
+		t.Fatal("Shared request should be canceled when no caller is waiting")
+	}
+}
+
+func TestWithoutCoalesce(t *testing.T) {
+	srv, hits, _, release := newCoalesceServer(t)
+	client := NewHTTPClient(&Config{Coalesce: &CoalesceConfig{}})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var result map[string]string
+			_ = client.Get(context.Background(), srv.URL, nil, &result, WithoutCoalesce())
+		}()
+	}
+	deadline := time.Now().Add(time.Second)
+	for hits.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	if hits.Load() != 3 {
+		t.Fatalf("Hits not match. expected=%d, actual=%d", 3, hits.Load())
+	}
+}
+
+func TestCoalesceSettingsInKey(t *testing.T) {
+	g := newCoalesceGroup(&CoalesceConfig{})
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/items", nil)
+	policy := &RetryPolicy{MaxAttempts: 3}
+
+	base := g.key(req, &RequestSettings{Retry: policy, Route: "GET /items"}, 0)
+	if base != g.key(req, &RequestSettings{Retry: policy, Route: "GET /items"}, 0) {
+		t.Fatal("Same settings should coalesce")
+	}
+	for name, s := range map[string]*RequestSettings{
+		"timeout": {Retry: policy, Route: "GET /items", Timeout: time.Second},
+		"route":   {Retry: policy, Route: "GET /list"},
+		"retry":   {Retry: &RetryPolicy{MaxAttempts: 3}, Route: "GET /items"},
+	} {
+		if g.key(req, s, 0) == base {
+			t.Fatal("Different settings should not coalesce. ", name)
+		}
+	}
+}
@@ -0,0 +1,390 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bookiu/gopkg/infra/cache"
+	"golang.org/x/net/publicsuffix"
+)
+
+// 确保 CookieJar 实现了 http.CookieJar 接口
+var _ http.CookieJar = (*CookieJar)(nil)
+
+var errIllegalDomain = errors.New("cookie: illegal domain attribute")
+
+// CookieJarOptions CookieJar 选项
+type CookieJarOptions struct {
+	// Store 持久化存储，通常为 *cache.SqliteCache，为 nil 时只保存在内存中
+	Store cache.Cache
+	// KeyPrefix 存储键前缀，默认 "cookiejar:"
+	KeyPrefix string
+	// PersistSessionCookies 同时持久化没有过期时间的会话 cookie，使登录态在重启后仍然有效
+	PersistSessionCookies bool
+}
+
+// CookieJar 按 RFC 6265 管理 cookie，域名匹配时会拒绝设置到公共后缀（如 co.uk）上的 cookie。
+// cookie 按 eTLD+1 分组保存，设置 Store 后每组会写入存储，重启后可恢复。
+type CookieJar struct {
+	mu      sync.Mutex
+	opts    CookieJarOptions
+	entries map[string]map[string]cookieEntry
+	loaded  map[string]bool
+	nextSeq uint64
+}
+
+// cookieEntry 保存的 cookie
+type cookieEntry struct {
+	Name       string    `json:"name"`
+	Value      string    `json:"value"`
+	Domain     string    `json:"domain"`
+	Path       string    `json:"path"`
+	Secure     bool      `json:"secure,omitempty"`
+	HttpOnly   bool      `json:"http_only,omitempty"`
+	HostOnly   bool      `json:"host_only,omitempty"`
+	Persistent bool      `json:"persistent,omitempty"`
+	Expires    time.Time `json:"expires"`
+	Creation   time.Time `json:"creation"`
+	// Seq 用于区分创建时间相同的 cookie
+	Seq uint64 `json:"seq"`
+}
+
+func (e *cookieEntry) id() string {
+	return e.Domain + ";" + e.Path + ";" + e.Name
+}
+
+func (e *cookieEntry) expired(now time.Time) bool {
+	return e.Persistent && !e.Expires.After(now)
+}
+
+func (e *cookieEntry) shouldSend(https bool, host, path string) bool {
+	return e.domainMatch(host) && pathMatch(e.Path, path) && (https || !e.Secure)
+}
+
+func (e *cookieEntry) domainMatch(host string) bool {
+	if e.Domain == host {
+		return true
+	}
+	return !e.HostOnly && hasDotSuffix(host, e.Domain)
+}
+
+// NewCookieJar 创建 CookieJar，opts 为 nil 时只保存在内存中
+func NewCookieJar(opts *CookieJarOptions) *CookieJar {
+	jar := &CookieJar{
+		entries: map[string]map[string]cookieEntry{},
+		loaded:  map[string]bool{},
+	}
+	if opts != nil {
+		jar.opts = *opts
+	}
+	if jar.opts.KeyPrefix == "" {
+		jar.opts.KeyPrefix = "cookiejar:"
+	}
+	return jar
+}
+
+// SetCookies 实现 http.CookieJar 接口
+func (j *CookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	if len(cookies) == 0 || (u.Scheme != "http" && u.Scheme != "https") {
+		return
+	}
+	host, err := canonicalHost(u.Host)
+	if err != nil {
+		return
+	}
+	key := jarKey(host)
+	defPath := defaultPath(u.Path)
+	now := time.Now()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	bucket := j.bucket(key)
+	modified := false
+	for _, c := range cookies {
+		e, remove, err := newCookieEntry(c, now, defPath, host)
+		if err != nil {
+			continue
+		}
+		id := e.id()
+		if remove {
+			if _, ok := bucket[id]; ok {
+				delete(bucket, id)
+				modified = true
+			}
+			continue
+		}
+		if old, ok := bucket[id]; ok {
+			e.Creation = old.Creation
+			e.Seq = old.Seq
+		} else {
+			e.Seq = j.nextSeq
+			j.nextSeq++
+		}
+		bucket[id] = e
+		modified = true
+	}
+	if modified {
+		j.save(key, bucket)
+	}
+}
+
+// Cookies 实现 http.CookieJar 接口
+func (j *CookieJar) Cookies(u *url.URL) []*http.Cookie {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil
+	}
+	host, err := canonicalHost(u.Host)
+	if err != nil {
+		return nil
+	}
+	key := jarKey(host)
+	https := u.Scheme == "https"
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	now := time.Now()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	bucket := j.bucket(key)
+	modified := false
+	var selected []cookieEntry
+	for id, e := range bucket {
+		if e.expired(now) {
+			delete(bucket, id)
+			modified = true
+			continue
+		}
+		if e.shouldSend(https, host, path) {
+			selected = append(selected, e)
+		}
+	}
+	if modified {
+		j.save(key, bucket)
+	}
+
+	// RFC 6265 5.4: path 更长的 cookie 在前，相同时创建早的在前
+	sort.Slice(selected, func(a, b int) bool {
+		if len(selected[a].Path) != len(selected[b].Path) {
+			return len(selected[a].Path) > len(selected[b].Path)
+		}
+		if !selected[a].Creation.Equal(selected[b].Creation) {
+			return selected[a].Creation.Before(selected[b].Creation)
+		}
+		return selected[a].Seq < selected[b].Seq
+	})
+
+	cookies := make([]*http.Cookie, len(selected))
+	for i, e := range selected {
+		cookies[i] = &http.Cookie{Name: e.Name, Value: e.Value}
+	}
+	return cookies
+}
+
+// bucket 返回 key 对应的 cookie，首次访问时从 Store 中加载
+func (j *CookieJar) bucket(key string) map[string]cookieEntry {
+	bucket, ok := j.entries[key]
+	if !ok {
+		bucket = map[string]cookieEntry{}
+		j.entries[key] = bucket
+	}
+	if j.opts.Store == nil || j.loaded[key] {
+		return bucket
+	}
+	j.loaded[key] = true
+
+	val, err := j.opts.Store.Get(context.Background(), j.opts.KeyPrefix+key)
+	if err != nil {
+		return bucket
+	}
+	var data []byte
+	switch v := val.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	}
+	var stored []cookieEntry
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return bucket
+	}
+	for _, e := range stored {
+		if _, ok := bucket[e.id()]; !ok {
+			bucket[e.id()] = e
+		}
+		if e.Seq >= j.nextSeq {
+			j.nextSeq = e.Seq + 1
+		}
+	}
+	return bucket
+}
+
+// save 将 key 对应的 cookie 写入 Store，尽力而为
+func (j *CookieJar) save(key string, bucket map[string]cookieEntry) {
+	if j.opts.Store == nil {
+		return
+	}
+	ctx := context.Background()
+	storeKey := j.opts.KeyPrefix + key
+
+	var stored []cookieEntry
+	var maxExpires time.Time
+	session := false
+	for _, e := range bucket {
+		if !e.Persistent {
+			if !j.opts.PersistSessionCookies {
+				continue
+			}
+			session = true
+		} else if e.Expires.After(maxExpires) {
+			maxExpires = e.Expires
+		}
+		stored = append(stored, e)
+	}
+	if len(stored) == 0 {
+		_ = j.opts.Store.Del(ctx, storeKey)
+		return
+	}
+
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return
+	}
+	var ttl time.Duration
+	if !session {
+		// 向上取整，避免存储按秒过期时提前删除
+		ttl = time.Until(maxExpires).Truncate(time.Second) + time.Second
+	}
+	_ = j.opts.Store.Set(ctx, storeKey, data, ttl)
+}
+
+// newCookieEntry 根据 Set-Cookie 创建 cookieEntry，remove 为 true 表示应删除已有的同名 cookie
+func newCookieEntry(c *http.Cookie, now time.Time, defPath, host string) (e cookieEntry, remove bool, err error) {
+	e.Name = c.Name
+	e.Value = c.Value
+	e.Secure = c.Secure
+	e.HttpOnly = c.HttpOnly
+	e.Creation = now
+
+	if c.Path == "" || c.Path[0] != '/' {
+		e.Path = defPath
+	} else {
+		e.Path = c.Path
+	}
+
+	e.Domain, e.HostOnly, err = cookieDomain(host, c.Domain)
+	if err != nil {
+		return e, false, err
+	}
+
+	// RFC 6265 5.3: Max-Age 优先于 Expires
+	switch {
+	case c.MaxAge < 0:
+		return e, true, nil
+	case c.MaxAge > 0:
+		e.Expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+		e.Persistent = true
+	case !c.Expires.IsZero():
+		if !c.Expires.After(now) {
+			return e, true, nil
+		}
+		e.Expires = c.Expires
+		e.Persistent = true
+	}
+	return e, false, nil
+}
+
+// cookieDomain 校验 Domain 属性，返回 cookie 所属域名以及是否仅限当前 host
+func cookieDomain(host, domain string) (string, bool, error) {
+	if domain == "" {
+		return host, true, nil
+	}
+	if net.ParseIP(host) != nil {
+		// IP 地址只接受与 host 完全相同的 Domain
+		if domain != host {
+			return "", false, errIllegalDomain
+		}
+		return host, true, nil
+	}
+
+	domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+	if domain == "" || strings.HasSuffix(domain, ".") {
+		return "", false, errIllegalDomain
+	}
+
+	// RFC 6265 5.3 第 5 步：拒绝公共后缀，除非与 host 相同（此时视为 host-only）
+	if ps, _ := publicsuffix.PublicSuffix(domain); ps == domain {
+		if host != domain {
+			return "", false, errIllegalDomain
+		}
+		return host, true, nil
+	}
+
+	if host != domain && !hasDotSuffix(host, domain) {
+		return "", false, errIllegalDomain
+	}
+	return domain, false, nil
+}
+
+// canonicalHost 去掉端口并转为小写
+func canonicalHost(host string) (string, error) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	host = strings.Trim(host, "[]")
+	if host == "" {
+		return "", errors.New("cookie: empty host")
+	}
+	return host, nil
+}
+
+// jarKey 返回 host 的 eTLD+1，无法计算时（IP、公共后缀本身）返回 host
+func jarKey(host string) string {
+	if net.ParseIP(host) != nil {
+		return host
+	}
+	key, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+	return key
+}
+
+// defaultPath RFC 6265 5.1.4
+func defaultPath(path string) string {
+	if path == "" || path[0] != '/' {
+		return "/"
+	}
+	i := strings.LastIndex(path, "/")
+	if i == 0 {
+		return "/"
+	}
+	return path[:i]
+}
+
+// pathMatch RFC 6265 5.1.4
+func pathMatch(cookiePath, requestPath string) bool {
+	if requestPath == cookiePath {
+		return true
+	}
+	if !strings.HasPrefix(requestPath, cookiePath) {
+		return false
+	}
+	return strings.HasSuffix(cookiePath, "/") || requestPath[len(cookiePath)] == '/'
+}
+
+func hasDotSuffix(s, suffix string) bool {
+	return len(s) > len(suffix) && s[len(s)-len(suffix)-1] == '.' && s[len(s)-len(suffix):] == suffix
+}
@@ -0,0 +1,162 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bookiu/gopkg/infra/cache"
+)
+
+func mustParseURL(t *testing.T, raw string) *url.URL {
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func cookieString(cookies []*http.Cookie) string {
+	parts := make([]string, len(cookies))
+	for i, c := range cookies {
+		parts[i] = c.Name + "=" + c.Value
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestCookieJarDomainMatching(t *testing.T) {
+	jar := NewCookieJar(nil)
+	jar.SetCookies(mustParseURL(t, "https://www.example.co.uk/login"), []*http.Cookie{
+		{Name: "host", Value: "1"},
+		{Name: "domain", Value: "2", Domain: ".example.co.uk"},
+		{Name: "suffix", Value: "3", Domain: "co.uk"},
+		{Name: "other", Value: "4", Domain: "other.co.uk"},
+	})
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "https://www.example.co.uk/", want: "host=1 domain=2"},
+		{url: "https://api.example.co.uk/", want: "domain=2"},
+		{url: "https://example.co.uk/", want: "domain=2"},
+		{url: "https://other.co.uk/", want: ""},
+	}
+	for _, tt := range tests {
+		got := cookieString(jar.Cookies(mustParseURL(t, tt.url)))
+		if got != tt.want {
+			t.Fatalf("Cookies not match. url=%s, expected=%q, actual=%q", tt.url, tt.want, got)
+		}
+	}
+}
+
+func TestCookieJarPathAndSecure(t *testing.T) {
+	jar := NewCookieJar(nil)
+	jar.SetCookies(mustParseURL(t, "https://example.com/account/login"), []*http.Cookie{
+		{Name: "default", Value: "1"},
+		{Name: "root", Value: "2", Path: "/"},
+		{Name: "secure", Value: "3", Path: "/", Secure: true},
+	})
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "https://example.com/account/profile", want: "default=1 root=2 secure=3"},
+		{url: "https://example.com/accounts", want: "root=2 secure=3"},
+		{url: "http://example.com/account", want: "default=1 root=2"},
+	}
+	for _, tt := range tests {
+		got := cookieString(jar.Cookies(mustParseURL(t, tt.url)))
+		if got != tt.want {
+			t.Fatalf("Cookies not match. url=%s, expected=%q, actual=%q", tt.url, tt.want, got)
+		}
+	}
+}
+
+func TestCookieJarExpiry(t *testing.T) {
+	jar := NewCookieJar(nil)
+	u := mustParseURL(t, "https://example.com/")
+	jar.SetCookies(u, []*http.Cookie{
+		{Name: "a", Value: "1", MaxAge: 1},
+		{Name: "b", Value: "2", Expires: time.Now().Add(time.Hour)},
+		{Name: "c", Value: "3", Expires: time.Now().Add(-time.Hour)},
+	})
+	if got := cookieString(jar.Cookies(u)); got != "a=1 b=2" {
+		t.Fatal("Cookies not match. ", got)
+	}
+
+	jar.SetCookies(u, []*http.Cookie{{Name: "b", MaxAge: -1}})
+	time.Sleep(1100 * time.Millisecond)
+	if got := cookieString(jar.Cookies(u)); got != "" {
+		t.Fatal("Expired cookies should be removed. ", got)
+	}
+}
+
+func TestCookieJarPersistent(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "cookie.db")
+	store, err := cache.NewSqliteCache(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := mustParseURL(t, "https://portal.example.com/")
+
+	jar := NewCookieJar(&CookieJarOptions{Store: store})
+	jar.SetCookies(u, []*http.Cookie{
+		{Name: "remember", Value: "1", MaxAge: 3600},
+		{Name: "session", Value: "2"},
+	})
+	_ = store.Close()
+
+	store, err = cache.NewSqliteCache(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	restored := NewCookieJar(&CookieJarOptions{Store: store})
+	if got := cookieString(restored.Cookies(u)); got != "remember=1" {
+		t.Fatal("Restored cookies not match. ", got)
+	}
+
+	withSession := NewCookieJar(&CookieJarOptions{Store: store, KeyPrefix: "session:", PersistSessionCookies: true})
+	withSession.SetCookies(u, []*http.Cookie{{Name: "session", Value: "2"}})
+	restored = NewCookieJar(&CookieJarOptions{Store: store, KeyPrefix: "session:", PersistSessionCookies: true})
+	if got := cookieString(restored.Cookies(u)); got != "session=2" {
+		t.Fatal("Restored session cookies not match. ", got)
+	}
+}
+
+func TestHTTPClientCookieJar(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "sid", Value: "abc", Path: "/"})
+		}
+		c, err := r.Cookie("sid")
+		value := ""
+		if err == nil {
+			value = c.Value
+		}
+		_, _ = w.Write([]byte(`{"sid":"` + value + `"}`))
+	}))
+	defer srv.Close()
+
+	client := NewHTTPClient(&Config{Jar: NewCookieJar(nil)})
+	var resp struct {
+		Sid string `json:"sid"`
+	}
+	if err := client.Get(context.Background(), srv.URL+"/login", nil, &resp); err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+	if err := client.Get(context.Background(), srv.URL+"/profile", nil, &resp); err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+	if resp.Sid != "abc" {
+		t.Fatal("Cookie not sent. ", resp.Sid)
+	}
+}
@@ -0,0 +1,132 @@
+package httpclient
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrCredentialNotFound 凭据文件不存在或环境变量未设置
+var ErrCredentialNotFound = errors.New("credential not found")
+
+// AuthSnapshotter 凭据会变化的 AuthProvider。HTTPClient 在发送请求前调用 Snapshot，
+// 同一请求（包括重试）使用返回的固定凭据，Snapshot 返回错误时请求不会发送
+type AuthSnapshotter interface {
+	Snapshot() (AuthProvider, error)
+}
+
+// CredentialSource 从文件或环境变量读取凭据，读取时距上次检查超过轮询间隔则重新读取。
+// 文件内容首尾的空白会被去掉。
+type CredentialSource struct {
+	read     func() ([]byte, error)
+	interval time.Duration
+
+	mu        sync.Mutex
+	value     string
+	err       error
+	checkedAt time.Time
+}
+
+// NewFileCredential 从 path 读取凭据，interval 为 0 时默认每 30s 检查一次
+func NewFileCredential(path string, interval time.Duration) *CredentialSource {
+	return newCredentialSource(func() ([]byte, error) {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrCredentialNotFound, path)
+		}
+		return data, err
+	}, interval)
+}
+
+// NewEnvCredential 从环境变量 name 读取凭据，interval 为 0 时默认每 30s 检查一次
+func NewEnvCredential(name string, interval time.Duration) *CredentialSource {
+	return newCredentialSource(func() ([]byte, error) {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return nil, fmt.Errorf("%w: $%s", ErrCredentialNotFound, name)
+		}
+		return []byte(value), nil
+	}, interval)
+}
+
+func newCredentialSource(read func() ([]byte, error), interval time.Duration) *CredentialSource {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	return &CredentialSource{read: read, interval: interval}
+}
+
+// Get 返回当前凭据，文件消失后返回错误，直到文件重新出现
+func (s *CredentialSource) Get() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.checkedAt.IsZero() || time.Since(s.checkedAt) >= s.interval {
+		s.reload()
+	}
+	return s.value, s.err
+}
+
+// reload 重新读取凭据，调用方需持有锁
+func (s *CredentialSource) reload() {
+	s.checkedAt = time.Now()
+	data, err := s.read()
+	if err != nil {
+		s.value, s.err = "", err
+		return
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		s.value, s.err = "", ErrCredentialNotFound
+		return
+	}
+	s.value, s.err = string(data), nil
+}
+
+// AuthBearerTokenSource 从 CredentialSource 读取的 Bearer Token
+type AuthBearerTokenSource struct {
+	Source *CredentialSource
+}
+
+// Apply 实现 AuthProvider 接口，读取凭据失败时不设置认证头
+func (a *AuthBearerTokenSource) Apply(req *http.Request) {
+	if p, err := a.Snapshot(); err == nil {
+		p.Apply(req)
+	}
+}
+
+// Snapshot 实现 AuthSnapshotter 接口
+func (a *AuthBearerTokenSource) Snapshot() (AuthProvider, error) {
+	token, err := a.Source.Get()
+	if err != nil {
+		return nil, err
+	}
+	return &AuthBearerToken{Token: token}, nil
+}
+
+// AuthAPIKeySource 从 CredentialSource 读取的 API Key
+type AuthAPIKeySource struct {
+	Source *CredentialSource
+	In     string // "header" or "query"
+	Name   string // 键名，如 "X-API-Key" 或 "api_key"
+}
+
+// Apply 实现 AuthProvider 接口，读取凭据失败时不设置 API Key
+func (a *AuthAPIKeySource) Apply(req *http.Request) {
+	if p, err := a.Snapshot(); err == nil {
+		p.Apply(req)
+	}
+}
+
+// Snapshot 实现 AuthSnapshotter 接口
+func (a *AuthAPIKeySource) Snapshot() (AuthProvider, error) {
+	key, err := a.Source.Get()
+	if err != nil {
+		return nil, err
+	}
+	return &AuthAPIKey{Key: key, In: a.In, Name: a.Name}, nil
+}
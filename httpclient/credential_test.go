@@ -0,0 +1,119 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newAuthServer 记录每次请求的 Authorization 与 X-API-Key，failFirst 为 true 时第一次请求返回 503
+func newAuthServer(t *testing.T, failFirst bool, onRequest func()) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	var seen []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.Header.Get("Authorization")+r.Header.Get("X-API-Key"))
+		first := len(seen) == 1
+		mu.Unlock()
+		if onRequest != nil {
+			onRequest()
+		}
+		if failFirst && first {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), seen...)
+	}
+}
+
+func writeCredential(t *testing.T, path, value string) {
+	if err := os.WriteFile(path, []byte(value), 0o600); err != nil {
+		t.Fatal("Write credential failed. ", err)
+	}
+}
+
+func TestAuthBearerTokenSourceFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	writeCredential(t, path, "token-1\n")
+	srv, seen := newAuthServer(t, false, nil)
+
+	client := NewHTTPClient(&Config{
+		Auth: &AuthBearerTokenSource{Source: NewFileCredential(path, 10*time.Millisecond)},
+	})
+	var result map[string]string
+	if err := client.Get(context.Background(), srv.URL, nil, &result); err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+
+	writeCredential(t, path, "token-2")
+	time.Sleep(20 * time.Millisecond)
+	if err := client.Get(context.Background(), srv.URL, nil, &result); err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+
+	// 文件消失时返回错误，请求不会发送
+	if err := os.Remove(path); err != nil {
+		t.Fatal("Remove failed. ", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if err := client.Get(context.Background(), srv.URL, nil, &result); !errors.Is(err, ErrCredentialNotFound) {
+		t.Fatalf("Expected ErrCredentialNotFound, actual=%v", err)
+	}
+
+	got := seen()
+	if len(got) != 2 || got[0] != "Bearer token-1" || got[1] != "Bearer token-2" {
+		t.Fatal("Tokens not match. ", got)
+	}
+}
+
+func TestAuthSourceConsistentAcrossRetries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key")
+	writeCredential(t, path, "key-1")
+	// 第一次请求时轮换凭据，重试仍应使用 key-1
+	var once sync.Once
+	srv, seen := newAuthServer(t, true, func() {
+		once.Do(func() { writeCredential(t, path, "key-2") })
+	})
+
+	client := NewHTTPClient(&Config{
+		Auth:  &AuthAPIKeySource{Source: NewFileCredential(path, time.Nanosecond), In: "header", Name: "X-API-Key"},
+		Retry: &RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond},
+	})
+	var result map[string]string
+	if err := client.Get(context.Background(), srv.URL, nil, &result); err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+	if err := client.Get(context.Background(), srv.URL, nil, &result); err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+
+	got := seen()
+	if len(got) != 3 || got[0] != "key-1" || got[1] != "key-1" || got[2] != "key-2" {
+		t.Fatal("Keys not match. ", got)
+	}
+}
+
+func TestEnvCredential(t *testing.T) {
+	t.Setenv("TEST_API_TOKEN", "env-token")
+	source := NewEnvCredential("TEST_API_TOKEN", time.Nanosecond)
+	if token, err := source.Get(); err != nil || token != "env-token" {
+		t.Fatal("Token not match. ", token, err)
+	}
+
+	os.Unsetenv("TEST_API_TOKEN")
+	if _, err := source.Get(); !errors.Is(err, ErrCredentialNotFound) {
+		t.Fatalf("Expected ErrCredentialNotFound, actual=%v", err)
+	}
+}
@@ -0,0 +1,311 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DialContextFunc 与 net.Dialer.DialContext 签名相同
+type DialContextFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// DialConfig 建立连接与域名解析相关的设置
+type DialConfig struct {
+	// Timeout 建立连接的超时时间，默认 30s
+	Timeout time.Duration
+	// KeepAlive TCP keep-alive 间隔，默认 30s
+	KeepAlive time.Duration
+	// FallbackDelay happy eyeballs (RFC 6555) 中首选地址族失败前等待多久开始尝试另一地址族，
+	// 默认 300ms，为负数时禁用并按顺序尝试所有地址
+	FallbackDelay time.Duration
+	// Resolvers 自定义 DNS 服务器地址，如 "8.8.8.8:53"，为空时使用系统配置
+	Resolvers []string
+	// DNSCacheTTL 大于 0 时启用进程内 DNS 缓存
+	DNSCacheTTL time.Duration
+}
+
+// unixSocketHost 以 unix:// 为 BaseURL 时请求使用的 Host
+const unixSocketHost = "unix"
+
+// parseBaseURL 解析 Config.BaseURL，unix:///path/to.sock 返回 socket 路径与 http://unix
+func parseBaseURL(raw string) (*url.URL, string, error) {
+	if raw == "" {
+		return nil, "", nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, "", err
+	}
+	if u.Scheme != "unix" {
+		return u, "", nil
+	}
+	if u.Path == "" {
+		return nil, "", fmt.Errorf("invalid unix socket base url: %s", raw)
+	}
+	return &url.URL{Scheme: "http", Host: unixSocketHost}, u.Path, nil
+}
+
+// resolveURL 为没有 Host 的请求地址拼接 BaseURL
+func resolveURL(base *url.URL, u *url.URL) *url.URL {
+	if base == nil || u.Host != "" {
+		return u
+	}
+	resolved := *base
+	resolved.Path = strings.TrimRight(base.Path, "/") + "/" + strings.TrimLeft(u.Path, "/")
+	resolved.RawPath = ""
+	resolved.RawQuery = u.RawQuery
+	resolved.Fragment = u.Fragment
+	return &resolved
+}
+
+// newDialContext 根据 Config 创建 DialContext，返回 nil 表示使用 http.Transport 的默认行为
+func newDialContext(config *Config, socketPath string) DialContextFunc {
+	if config.DialContext != nil {
+		return config.DialContext
+	}
+	if socketPath != "" {
+		dialer := &net.Dialer{}
+		return func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", socketPath)
+		}
+	}
+	if config.Dial == nil {
+		return nil
+	}
+
+	dc := config.Dial
+	dialer := &net.Dialer{
+		Timeout:       30 * time.Second,
+		KeepAlive:     30 * time.Second,
+		FallbackDelay: dc.FallbackDelay,
+	}
+	if dc.Timeout > 0 {
+		dialer.Timeout = dc.Timeout
+	}
+	if dc.KeepAlive != 0 {
+		dialer.KeepAlive = dc.KeepAlive
+	}
+	resolver := newResolver(dc.Resolvers)
+	dialer.Resolver = resolver
+	if dc.DNSCacheTTL <= 0 {
+		return dialer.DialContext
+	}
+
+	cache := NewDNSCache(dc.DNSCacheTTL, resolver)
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil || net.ParseIP(host) != nil {
+			return dialer.DialContext(ctx, network, addr)
+		}
+		addrs, err := cache.LookupHost(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		return dialAddrs(ctx, dialer, network, addrs, port)
+	}
+}
+
+// newResolver 创建使用指定 DNS 服务器的 net.Resolver，多个服务器轮询使用
+func newResolver(servers []string) *net.Resolver {
+	if len(servers) == 0 {
+		return net.DefaultResolver
+	}
+	var next atomic.Uint32
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			server := servers[int(next.Add(1)-1)%len(servers)]
+			var d net.Dialer
+			return d.DialContext(ctx, network, server)
+		},
+	}
+}
+
+// dialAddrs 按 happy eyeballs 方式连接已解析的地址：先按顺序尝试首选地址族，
+// FallbackDelay 后并行尝试另一地址族，返回最先建立的连接
+func dialAddrs(ctx context.Context, dialer *net.Dialer, network string, addrs []string, port string) (net.Conn, error) {
+	primaries, fallbacks := partitionAddrs(addrs)
+	if len(fallbacks) == 0 || dialer.FallbackDelay < 0 {
+		return dialSerial(ctx, dialer, network, append(primaries, fallbacks...), port)
+	}
+	delay := dialer.FallbackDelay
+	if delay == 0 {
+		delay = 300 * time.Millisecond
+	}
+
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make(chan result, 2)
+	start := func(addrs []string) {
+		conn, err := dialSerial(ctx, dialer, network, addrs, port)
+		results <- result{conn: conn, err: err}
+	}
+	go start(primaries)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	var firstErr error
+	pending, fallbackStarted := 1, false
+	for pending > 0 || !fallbackStarted {
+		select {
+		case <-timer.C:
+			if !fallbackStarted {
+				fallbackStarted = true
+				pending++
+				go start(fallbacks)
+			}
+		case res := <-results:
+			pending--
+			if res.err == nil {
+				// 关闭另一路稍后建立的连接
+				go func(remaining int) {
+					for i := 0; i < remaining; i++ {
+						if r := <-results; r.conn != nil {
+							_ = r.conn.Close()
+						}
+					}
+				}(pending)
+				return res.conn, nil
+			}
+			if firstErr == nil {
+				firstErr = res.err
+			}
+			if !fallbackStarted {
+				fallbackStarted = true
+				pending++
+				go start(fallbacks)
+			}
+		}
+	}
+	return nil, firstErr
+}
+
+func dialSerial(ctx context.Context, dialer *net.Dialer, network string, addrs []string, port string) (net.Conn, error) {
+	var firstErr error
+	for _, addr := range addrs {
+		conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(addr, port))
+		if err == nil {
+			return conn, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if firstErr == nil {
+		firstErr = errors.New("no addresses to dial")
+	}
+	return nil, firstErr
+}
+
+// partitionAddrs 按第一个地址的地址族拆分为首选地址与回退地址
+func partitionAddrs(addrs []string) (primaries, fallbacks []string) {
+	if len(addrs) == 0 {
+		return nil, nil
+	}
+	isV4 := func(addr string) bool {
+		ip := net.ParseIP(addr)
+		return ip != nil && ip.To4() != nil
+	}
+	primaryV4 := isV4(addrs[0])
+	for _, addr := range addrs {
+		if isV4(addr) == primaryV4 {
+			primaries = append(primaries, addr)
+		} else {
+			fallbacks = append(fallbacks, addr)
+		}
+	}
+	return primaries, fallbacks
+}
+
+// DNSCache 进程内 DNS 缓存，解析失败时返回已过期的结果
+type DNSCache struct {
+	ttl    time.Duration
+	lookup func(ctx context.Context, host string) ([]string, error)
+
+	mu      sync.Mutex
+	entries map[string]*dnsEntry
+}
+
+type dnsEntry struct {
+	addrs   []string
+	expires time.Time
+	// done 不为 nil 时表示正在解析，其他请求等待该次解析结果
+	done chan struct{}
+}
+
+// NewDNSCache 创建 DNS 缓存，resolver 为 nil 时使用 net.DefaultResolver
+func NewDNSCache(ttl time.Duration, resolver *net.Resolver) *DNSCache {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	return &DNSCache{
+		ttl:     ttl,
+		lookup:  resolver.LookupHost,
+		entries: map[string]*dnsEntry{},
+	}
+}
+
+// LookupHost 返回 host 的地址，缓存过期后重新解析，解析失败时使用过期的结果
+func (d *DNSCache) LookupHost(ctx context.Context, host string) ([]string, error) {
+	d.mu.Lock()
+	e, ok := d.entries[host]
+	if ok && e.done == nil && time.Now().Before(e.expires) {
+		addrs := e.addrs
+		d.mu.Unlock()
+		return addrs, nil
+	}
+	if ok && e.done != nil {
+		// 等待进行中的解析
+		done := e.done
+		d.mu.Unlock()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		return d.LookupHost(ctx, host)
+	}
+
+	var stale []string
+	if ok {
+		stale = e.addrs
+	}
+	pending := &dnsEntry{addrs: stale, done: make(chan struct{})}
+	d.entries[host] = pending
+	d.mu.Unlock()
+
+	addrs, err := d.lookup(ctx, host)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	defer close(pending.done)
+	if err != nil {
+		if len(stale) > 0 {
+			// 解析失败时继续使用过期的结果，稍后再重新解析
+			d.entries[host] = &dnsEntry{addrs: stale, expires: time.Now().Add(d.staleRetry())}
+			return stale, nil
+		}
+		delete(d.entries, host)
+		return nil, err
+	}
+	d.entries[host] = &dnsEntry{addrs: addrs, expires: time.Now().Add(d.ttl)}
+	return addrs, nil
+}
+
+// staleRetry 解析失败后多久再次尝试解析
+func (d *DNSCache) staleRetry() time.Duration {
+	return min(d.ttl, 5*time.Second)
+}
@@ -0,0 +1,204 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newPathServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"path":"` + r.URL.Path + `","query":"` + r.URL.RawQuery + `"}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestUnixSocketBaseURL(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "test.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal("Listen failed. ", err)
+	}
+	srv := newPathServer(t)
+	srv.Listener = l
+	srv.Start()
+
+	client := NewHTTPClient(&Config{BaseURL: "unix://" + socket})
+	var result map[string]string
+	if err := client.Get(context.Background(), "/v1/containers?all=1", nil, &result); err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+	if result["path"] != "/v1/containers" || result["query"] != "all=1" {
+		t.Fatal("Result not match. ", result)
+	}
+}
+
+func TestBaseURL(t *testing.T) {
+	srv := newPathServer(t)
+	srv.Start()
+
+	client := NewHTTPClient(&Config{BaseURL: srv.URL + "/api/"})
+	var result map[string]string
+	if err := client.Get(context.Background(), "/users", nil, &result); err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+	if result["path"] != "/api/users" {
+		t.Fatal("Path not match. ", result["path"])
+	}
+
+	// 完整地址不拼接 BaseURL
+	if err := client.Get(context.Background(), srv.URL+"/users", nil, &result); err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+	if result["path"] != "/users" {
+		t.Fatal("Path not match. ", result["path"])
+	}
+
+	client = NewHTTPClient(&Config{BaseURL: "unix://"})
+	if err := client.Get(context.Background(), "/users", nil, &result); err == nil {
+		t.Fatal("Expected invalid base url error")
+	}
+}
+
+func TestConfigDialContext(t *testing.T) {
+	srv := newPathServer(t)
+	srv.Start()
+
+	var dialed atomic.Int32
+	client := NewHTTPClient(&Config{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			dialed.Add(1)
+			var d net.Dialer
+			return d.DialContext(ctx, network, srv.Listener.Addr().String())
+		},
+	})
+	var result map[string]string
+	if err := client.Get(context.Background(), "http://api.example.test/users", nil, &result); err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+	if dialed.Load() != 1 || result["path"] != "/users" {
+		t.Fatal("Custom dialer not used. ", dialed.Load(), result)
+	}
+}
+
+func TestDNSCache(t *testing.T) {
+	var lookups atomic.Int32
+	var fail atomic.Bool
+	cache := NewDNSCache(50*time.Millisecond, nil)
+	cache.lookup = func(ctx context.Context, host string) ([]string, error) {
+		lookups.Add(1)
+		if fail.Load() {
+			return nil, errors.New("dns unavailable")
+		}
+		return []string{"127.0.0.1"}, nil
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		addrs, err := cache.LookupHost(ctx, "api.example.test")
+		if err != nil || len(addrs) != 1 {
+			t.Fatal("Lookup failed. ", err)
+		}
+	}
+	if lookups.Load() != 1 {
+		t.Fatalf("Lookup should be cached. expected=%d, actual=%d", 1, lookups.Load())
+	}
+
+	// 过期后解析失败时返回过期的结果
+	time.Sleep(60 * time.Millisecond)
+	fail.Store(true)
+	addrs, err := cache.LookupHost(ctx, "api.example.test")
+	if err != nil || addrs[0] != "127.0.0.1" {
+		t.Fatal("Stale address should be served. ", err)
+	}
+	if lookups.Load() != 2 {
+		t.Fatalf("Lookup count not match. expected=%d, actual=%d", 2, lookups.Load())
+	}
+
+	if _, err := cache.LookupHost(ctx, "other.example.test"); err == nil {
+		t.Fatal("Expected lookup error")
+	}
+}
+
+func TestDialConfigDNSCache(t *testing.T) {
+	srv := newPathServer(t)
+	srv.Start()
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+
+	// 通过本地 UDP 端口确认使用了自定义 DNS 服务器
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Listen failed. ", err)
+	}
+	defer pc.Close()
+	var queried atomic.Bool
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			if _, _, err := pc.ReadFrom(buf); err != nil {
+				return
+			}
+			queried.Store(true)
+		}
+	}()
+
+	client := NewHTTPClient(&Config{
+		Dial: &DialConfig{
+			Timeout:     time.Second,
+			Resolvers:   []string{pc.LocalAddr().String()},
+			DNSCacheTTL: time.Minute,
+		},
+	})
+	var result map[string]string
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := client.Get(ctx, "http://api.example.test:"+port+"/users", nil, &result); err == nil {
+		t.Fatal("Expected lookup error")
+	}
+	if !queried.Load() {
+		t.Fatal("Custom resolver not used")
+	}
+
+	// IP 地址不经过 DNS 解析
+	if err := client.Get(context.Background(), srv.URL+"/users", nil, &result); err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+}
+
+func TestDialAddrsFallback(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Listen failed. ", err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+
+	// 首选地址族不可达时回退到另一地址族
+	dialer := &net.Dialer{Timeout: time.Second, FallbackDelay: 10 * time.Millisecond}
+	conn, err := dialAddrs(context.Background(), dialer, "tcp", []string{"::1", "127.0.0.1"}, port)
+	if err != nil {
+		t.Fatal("Dial failed. ", err)
+	}
+	_ = conn.Close()
+
+	primaries, fallbacks := partitionAddrs([]string{"::1", "127.0.0.1", "fe80::1", "10.0.0.1"})
+	if len(primaries) != 2 || len(fallbacks) != 2 || primaries[1] != "fe80::1" {
+		t.Fatal("Partition not match. ", primaries, fallbacks)
+	}
+}
@@ -0,0 +1,210 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// RequestEvent 单次发送请求的观测数据，重试时每次发送单独记录
+type RequestEvent struct {
+	Method string
+	// URL 经过 Config.Sanitizer 脱敏的地址
+	URL string
+	// Route 通过 WithRoute 设置的路由名称，未设置时为匹配的路径模板
+	Route string
+	// Attempt 第几次发送，从 1 开始
+	Attempt    int
+	StatusCode int
+	Err        error
+
+	// Duration 从发送请求到收到响应头的耗时
+	Duration time.Duration
+	// DNS 域名解析耗时，复用连接或直接使用 IP 时为 0
+	DNS time.Duration
+	// Connect 建立 TCP 连接耗时
+	Connect time.Duration
+	// TLSHandshake TLS 握手耗时
+	TLSHandshake time.Duration
+	// TTFB 从发送请求到收到响应第一个字节的耗时
+	TTFB time.Duration
+	// ConnReused 是否复用了已有连接
+	ConnReused bool
+
+	// RequestSize 实际发送的请求体字节数
+	RequestSize int64
+	// ResponseSize 实际读取的响应体字节数，包含关闭前丢弃的部分
+	ResponseSize int64
+}
+
+// requestTrace 通过 httptrace 收集单次请求的耗时，回调可能在其他 goroutine 中执行
+type requestTrace struct {
+	mu           sync.Mutex
+	start        time.Time
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	event        RequestEvent
+}
+
+func newRequestTrace(start time.Time) *requestTrace {
+	return &requestTrace{start: start}
+}
+
+func (t *requestTrace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mu.Lock()
+			t.dnsStart = time.Now()
+			t.mu.Unlock()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.mu.Lock()
+			t.event.DNS = time.Since(t.dnsStart)
+			t.mu.Unlock()
+		},
+		ConnectStart: func(string, string) {
+			t.mu.Lock()
+			if t.connectStart.IsZero() {
+				t.connectStart = time.Now()
+			}
+			t.mu.Unlock()
+		},
+		ConnectDone: func(_, _ string, err error) {
+			t.mu.Lock()
+			if err == nil {
+				t.event.Connect = time.Since(t.connectStart)
+			}
+			t.mu.Unlock()
+		},
+		TLSHandshakeStart: func() {
+			t.mu.Lock()
+			t.tlsStart = time.Now()
+			t.mu.Unlock()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.mu.Lock()
+			t.event.TLSHandshake = time.Since(t.tlsStart)
+			t.mu.Unlock()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.event.ConnReused = info.Reused
+			t.mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			t.event.TTFB = time.Since(t.start)
+			t.mu.Unlock()
+		},
+	}
+}
+
+// snapshot 返回当前收集到的数据
+func (t *requestTrace) snapshot() RequestEvent {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.event
+}
+
+// countingReader 统计读取的字节数
+type countingReader struct {
+	io.ReadCloser
+	mu sync.Mutex
+	n  int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.mu.Lock()
+	r.n += int64(n)
+	r.mu.Unlock()
+	return n, err
+}
+
+func (r *countingReader) count() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.n
+}
+
+// countRequestBody 统计发送的请求体字节数，没有请求体时返回 nil
+func countRequestBody(req *http.Request) *countingReader {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+	body := &countingReader{ReadCloser: req.Body}
+	req.Body = body
+	return body
+}
+
+// observedBody 响应体关闭时记录事件，此时响应体大小已知
+type observedBody struct {
+	*countingReader
+	once   sync.Once
+	record func(size int64)
+}
+
+func (b *observedBody) Close() error {
+	err := b.countingReader.Close()
+	b.once.Do(func() {
+		b.record(b.count())
+	})
+	return err
+}
+
+// eventObserver 将 ObserveProvider 转为 EventObserveProvider
+func eventObserver(p ObserveProvider) EventObserveProvider {
+	if e, ok := p.(EventObserveProvider); ok {
+		return e
+	}
+	return NewEventObserveAdapter(p)
+}
+
+// observe 发送请求并在响应体关闭时记录 RequestEvent
+func (c *HTTPClient) observe(ctx context.Context, req *http.Request, attempt int, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	start := time.Now()
+	trace := newRequestTrace(start)
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace.clientTrace()))
+	reqBody := countRequestBody(req)
+
+	newEvent := func() *RequestEvent {
+		event := trace.snapshot()
+		event.Method = req.Method
+		event.URL, event.Route = c.sanitizer.Sanitize(req.URL)
+		if route := RouteFromContext(ctx); route != "" {
+			event.Route = route
+		}
+		event.Attempt = attempt
+		if reqBody != nil {
+			event.RequestSize = reqBody.count()
+		}
+		return &event
+	}
+
+	resp, err := send(req)
+	duration := time.Since(start)
+	if err != nil {
+		event := newEvent()
+		event.Duration = duration
+		event.Err = sanitizeError(err, event.URL)
+		c.observer.RecordEvent(ctx, event)
+		return nil, err
+	}
+
+	resp.Body = &observedBody{
+		countingReader: &countingReader{ReadCloser: resp.Body},
+		record: func(size int64) {
+			event := newEvent()
+			event.Duration = duration
+			event.StatusCode = resp.StatusCode
+			event.ResponseSize = size
+			c.observer.RecordEvent(ctx, event)
+		},
+	}
+	return resp, nil
+}
@@ -0,0 +1,99 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type eventObserve struct {
+	mu     sync.Mutex
+	events []RequestEvent
+	// legacy RecordRequest 不应被调用
+	legacy atomic.Int32
+}
+
+func (o *eventObserve) RecordRequest(ctx context.Context, method, url string, statusCode int, duration time.Duration, err error) {
+	o.legacy.Add(1)
+}
+
+func (o *eventObserve) RecordEvent(ctx context.Context, event *RequestEvent) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.events = append(o.events, *event)
+}
+
+func TestObserveRequestEvent(t *testing.T) {
+	body := `{"data":"` + strings.Repeat("a", 1000) + `"}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	observe := &eventObserve{}
+	client := NewHTTPClient(&Config{Observe: observe})
+	var result map[string]string
+	for i := 0; i < 2; i++ {
+		err := client.Post(context.Background(), srv.URL, strings.Repeat("b", 100), &result, WithRoute("POST /data"))
+		if err != nil {
+			t.Fatal("Request failed. ", err)
+		}
+	}
+
+	if observe.legacy.Load() != 0 || len(observe.events) != 2 {
+		t.Fatal("Events not recorded. ", observe.legacy.Load(), len(observe.events))
+	}
+	first, second := observe.events[0], observe.events[1]
+	if first.Route != "POST /data" || first.Attempt != 1 || first.StatusCode != http.StatusOK {
+		t.Fatal("Event not match. ", first)
+	}
+	if first.RequestSize != 100 || first.ResponseSize != int64(len(body)) {
+		t.Fatalf("Size not match. request=%d, response=%d", first.RequestSize, first.ResponseSize)
+	}
+	if first.ConnReused || first.Connect <= 0 || first.TTFB <= 0 || first.TTFB > first.Duration {
+		t.Fatal("First request timing not match. ", first)
+	}
+	if !second.ConnReused || second.Connect != 0 {
+		t.Fatal("Second request should reuse connection. ", second)
+	}
+}
+
+func TestObserveRequestEventRetry(t *testing.T) {
+	var count atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if count.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	observe := &eventObserve{}
+	client := NewHTTPClient(&Config{
+		Observe: observe,
+		Retry:   &RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond},
+	})
+	var result map[string]string
+	if err := client.Get(context.Background(), srv.URL, nil, &result); err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+	if len(observe.events) != 3 {
+		t.Fatalf("Event count not match. expected=%d, actual=%d", 3, len(observe.events))
+	}
+	for i, e := range observe.events {
+		if e.Attempt != i+1 {
+			t.Fatalf("Attempt not match. expected=%d, actual=%d", i+1, e.Attempt)
+		}
+	}
+	if observe.events[0].StatusCode != http.StatusServiceUnavailable || observe.events[2].StatusCode != http.StatusOK {
+		t.Fatal("Status code not match. ", observe.events)
+	}
+}
@@ -0,0 +1,298 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrFaultInjected 由 FaultInjector 注入的错误，连接重置时同时包装 syscall.ECONNRESET
+var ErrFaultInjected = errors.New("fault injected")
+
+// Latency 延迟分布，从 r 中采样一个延迟
+type Latency func(r *rand.Rand) time.Duration
+
+// FixedLatency 固定延迟
+func FixedLatency(d time.Duration) Latency {
+	return func(*rand.Rand) time.Duration { return d }
+}
+
+// UniformLatency [min, max) 内均匀分布的延迟
+func UniformLatency(min, max time.Duration) Latency {
+	return func(r *rand.Rand) time.Duration {
+		if max <= min {
+			return min
+		}
+		return min + time.Duration(r.Int64N(int64(max-min)))
+	}
+}
+
+// NormalLatency 正态分布的延迟，小于 0 时取 0
+func NormalLatency(mean, stddev time.Duration) Latency {
+	return func(r *rand.Rand) time.Duration {
+		return max(0, mean+time.Duration(r.NormFloat64()*float64(stddev)))
+	}
+}
+
+// ExponentialLatency 指数分布的延迟，适合模拟长尾
+func ExponentialLatency(mean time.Duration) Latency {
+	return func(r *rand.Rand) time.Duration {
+		return time.Duration(math.Min(r.ExpFloat64()*float64(mean), math.MaxInt64))
+	}
+}
+
+// Fault 一次注入的故障，多个字段可以同时生效
+type Fault struct {
+	// Latency 发送请求前的延迟
+	Latency Latency
+	// Reset 不发送请求，返回连接被重置的错误
+	Reset bool
+	// StatusCode 不发送请求，直接返回该状态码
+	StatusCode int
+	// TruncateAfter 大于 0 时响应体只返回前 TruncateAfter 字节，之后返回 io.ErrUnexpectedEOF
+	TruncateAfter int64
+	// DripBytes 与 DripInterval 都大于 0 时，响应体每次最多返回 DripBytes 字节，每次读取前等待 DripInterval
+	DripBytes    int
+	DripInterval time.Duration
+}
+
+// FaultRule 按主机与路由匹配请求，以 Probability 的概率注入 Fault
+type FaultRule struct {
+	// Host 匹配的主机，写法与 ProxyConfig.NoProxy 相同，为空时匹配所有主机
+	Host string
+	// Route 匹配的路由名称（WithRoute），为空时匹配所有路由
+	Route string
+	// Probability 注入的概率，取值 0 到 1
+	Probability float64
+	Fault       Fault
+}
+
+type faultRule struct {
+	FaultRule
+	host proxyMatcher
+}
+
+// FaultInjector 在请求中注入延迟、连接重置、状态码与异常响应体，用于测试重试与超时处理。
+// 通过 Config.Fault 或 Wrap 启用，规则可在运行时修改。每个请求按顺序对匹配的规则分别抽样，
+// 相同 seed 下顺序发送的请求得到相同的结果。
+type FaultInjector struct {
+	mu       sync.Mutex
+	rng      *rand.Rand
+	rules    []faultRule
+	disabled bool
+	injected int64
+}
+
+// NewFaultInjector 创建 FaultInjector，seed 为 0 时使用随机种子
+func NewFaultInjector(seed uint64) *FaultInjector {
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	return &FaultInjector{rng: rand.New(rand.NewPCG(seed, seed))}
+}
+
+// SetRules 替换全部规则
+func (f *FaultInjector) SetRules(rules ...FaultRule) error {
+	compiled := make([]faultRule, 0, len(rules))
+	for _, rule := range rules {
+		r := faultRule{FaultRule: rule}
+		if rule.Host != "" {
+			m, err := newProxyMatcher(rule.Host)
+			if err != nil {
+				return fmt.Errorf("invalid fault host %q: %w", rule.Host, err)
+			}
+			r.host = m
+		}
+		compiled = append(compiled, r)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = compiled
+	return nil
+}
+
+// Enable 恢复注入
+func (f *FaultInjector) Enable() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.disabled = false
+}
+
+// Disable 暂停注入，规则保留
+func (f *FaultInjector) Disable() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.disabled = true
+}
+
+// Injected 返回已注入故障的请求数
+func (f *FaultInjector) Injected() int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.injected
+}
+
+// Wrap 返回注入故障的 http.RoundTripper，可用于任意 http.Client
+func (f *FaultInjector) Wrap(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &faultTransport{injector: f, next: next}
+}
+
+// pick 对匹配的规则抽样，合并命中的故障
+func (f *FaultInjector) pick(req *http.Request) (Fault, time.Duration, bool) {
+	host, port := requestHostPort(req.URL)
+	route := RouteFromContext(req.Context())
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var fault Fault
+	var latency time.Duration
+	hit := false
+	if f.disabled {
+		return fault, 0, false
+	}
+	for _, rule := range f.rules {
+		if rule.Host != "" && !rule.host.match(host, port) {
+			continue
+		}
+		if rule.Route != "" && rule.Route != route {
+			continue
+		}
+		if f.rng.Float64() >= rule.Probability {
+			continue
+		}
+		hit = true
+		if rule.Fault.Latency != nil {
+			latency += rule.Fault.Latency(f.rng)
+		}
+		fault.Reset = fault.Reset || rule.Fault.Reset
+		if fault.StatusCode == 0 {
+			fault.StatusCode = rule.Fault.StatusCode
+		}
+		if fault.TruncateAfter == 0 {
+			fault.TruncateAfter = rule.Fault.TruncateAfter
+		}
+		if fault.DripBytes == 0 {
+			fault.DripBytes, fault.DripInterval = rule.Fault.DripBytes, rule.Fault.DripInterval
+		}
+	}
+	if hit {
+		f.injected++
+	}
+	return fault, latency, hit
+}
+
+type faultTransport struct {
+	injector *FaultInjector
+	next     http.RoundTripper
+}
+
+func (t *faultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	fault, latency, hit := t.injector.pick(req)
+	if !hit {
+		return t.next.RoundTrip(req)
+	}
+
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			closeRequestBody(req)
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+	if fault.Reset {
+		closeRequestBody(req)
+		return nil, fmt.Errorf("%w: %w", ErrFaultInjected, &net.OpError{
+			Op:  "read",
+			Net: "tcp",
+			Err: os.NewSyscallError("read", syscall.ECONNRESET),
+		})
+	}
+	if fault.StatusCode > 0 {
+		closeRequestBody(req)
+		return &http.Response{
+			Status:     fmt.Sprintf("%d %s", fault.StatusCode, http.StatusText(fault.StatusCode)),
+			StatusCode: fault.StatusCode,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     http.Header{},
+			Body:       http.NoBody,
+			Request:    req,
+		}, nil
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode == http.StatusSwitchingProtocols {
+		// 升级后的连接需要保留可写的响应体
+		return resp, err
+	}
+	if fault.TruncateAfter > 0 {
+		resp.Body = &truncatedBody{ReadCloser: resp.Body, remaining: fault.TruncateAfter}
+	}
+	if fault.DripBytes > 0 && fault.DripInterval > 0 {
+		resp.Body = &dripBody{ReadCloser: resp.Body, req: req, size: fault.DripBytes, interval: fault.DripInterval}
+	}
+	return resp, nil
+}
+
+// closeRequestBody RoundTripper 不发送请求时也需要关闭请求体
+func closeRequestBody(req *http.Request) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+}
+
+// truncatedBody 读取 remaining 字节后返回 io.ErrUnexpectedEOF
+type truncatedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (b *truncatedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		return 0, fmt.Errorf("%w: %w", ErrFaultInjected, io.ErrUnexpectedEOF)
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
+
+// dripBody 每次读取前等待 interval，最多返回 size 字节，请求取消时返回错误
+type dripBody struct {
+	io.ReadCloser
+	req      *http.Request
+	size     int
+	interval time.Duration
+}
+
+func (b *dripBody) Read(p []byte) (int, error) {
+	timer := time.NewTimer(b.interval)
+	defer timer.Stop()
+	select {
+	case <-b.req.Context().Done():
+		return 0, b.req.Context().Err()
+	case <-timer.C:
+	}
+	if len(p) > b.size {
+		p = p[:b.size]
+	}
+	return b.ReadCloser.Read(p)
+}
@@ -0,0 +1,184 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func newFaultServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":"` + strings.Repeat("a", 64) + `"}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestFaultInjectorDeterministic(t *testing.T) {
+	srv := newFaultServer(t)
+	run := func(seed uint64) string {
+		fault := NewFaultInjector(seed)
+		if err := fault.SetRules(FaultRule{Probability: 0.5, Fault: Fault{StatusCode: http.StatusServiceUnavailable}}); err != nil {
+			t.Fatal("SetRules failed. ", err)
+		}
+		client := NewHTTPClient(&Config{Fault: fault})
+		var outcome strings.Builder
+		for i := 0; i < 20; i++ {
+			var result map[string]string
+			if err := client.Get(context.Background(), srv.URL, nil, &result); err != nil {
+				outcome.WriteByte('x')
+			} else {
+				outcome.WriteByte('.')
+			}
+		}
+		if int64(strings.Count(outcome.String(), "x")) != fault.Injected() {
+			t.Fatal("Injected count not match. ", outcome.String(), fault.Injected())
+		}
+		return outcome.String()
+	}
+
+	first := run(42)
+	if second := run(42); second != first {
+		t.Fatalf("Same seed should give same faults. expected=%s, actual=%s", first, second)
+	}
+	if !strings.Contains(first, "x") || !strings.Contains(first, ".") {
+		t.Fatal("Expected mixed outcome. ", first)
+	}
+}
+
+func TestFaultInjectorReset(t *testing.T) {
+	srv := newFaultServer(t)
+	fault := NewFaultInjector(1)
+	_ = fault.SetRules(FaultRule{Probability: 1, Fault: Fault{Reset: true}})
+	client := NewHTTPClient(&Config{Fault: fault, Retry: &RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}})
+
+	var result map[string]string
+	err := client.Get(context.Background(), srv.URL, nil, &result)
+	if !errors.Is(err, syscall.ECONNRESET) || !errors.Is(err, ErrFaultInjected) {
+		t.Fatalf("Expected connection reset, actual=%v", err)
+	}
+	if fault.Injected() != 3 {
+		t.Fatalf("Retry count not match. expected=%d, actual=%d", 3, fault.Injected())
+	}
+
+	// 运行时关闭注入
+	fault.Disable()
+	if err := client.Get(context.Background(), srv.URL, nil, &result); err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+	fault.Enable()
+	_ = fault.SetRules()
+	if err := client.Get(context.Background(), srv.URL, nil, &result); err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+}
+
+func TestFaultInjectorMatch(t *testing.T) {
+	srv := newFaultServer(t)
+	fault := NewFaultInjector(1)
+	err := fault.SetRules(
+		FaultRule{Host: "api.example.test", Probability: 1, Fault: Fault{StatusCode: http.StatusBadGateway}},
+		FaultRule{Route: "GET /flaky", Probability: 1, Fault: Fault{StatusCode: http.StatusTooManyRequests}},
+	)
+	if err != nil {
+		t.Fatal("SetRules failed. ", err)
+	}
+	client := NewHTTPClient(&Config{Fault: fault})
+
+	var result map[string]string
+	if err := client.Get(context.Background(), srv.URL, nil, &result); err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+	err = client.Get(context.Background(), srv.URL, nil, &result, WithRoute("GET /flaky"))
+	if err == nil || !strings.Contains(err.Error(), "429") {
+		t.Fatalf("Expected 429, actual=%v", err)
+	}
+
+	if err := fault.SetRules(FaultRule{Host: "*.", Probability: 1}); err == nil {
+		t.Fatal("Expected invalid host error")
+	}
+}
+
+func TestFaultInjectorLatency(t *testing.T) {
+	srv := newFaultServer(t)
+	fault := NewFaultInjector(1)
+	_ = fault.SetRules(FaultRule{Probability: 1, Fault: Fault{Latency: FixedLatency(time.Second)}})
+	client := NewHTTPClient(&Config{Fault: fault})
+
+	var result map[string]string
+	start := time.Now()
+	err := client.Get(context.Background(), srv.URL, nil, &result, WithTimeout(20*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) || time.Since(start) > 500*time.Millisecond {
+		t.Fatalf("Expected timeout, actual=%v", err)
+	}
+}
+
+func TestFaultInjectorBody(t *testing.T) {
+	srv := newFaultServer(t)
+	fault := NewFaultInjector(1)
+	client := NewHTTPClient(&Config{Fault: fault})
+	var result map[string]string
+
+	_ = fault.SetRules(FaultRule{Probability: 1, Fault: Fault{TruncateAfter: 10}})
+	if err := client.Get(context.Background(), srv.URL, nil, &result); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("Expected truncated body, actual=%v", err)
+	}
+
+	_ = fault.SetRules(FaultRule{Probability: 1, Fault: Fault{DripBytes: 8, DripInterval: 5 * time.Millisecond}})
+	start := time.Now()
+	if err := client.Get(context.Background(), srv.URL, nil, &result); err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+	if time.Since(start) < 40*time.Millisecond {
+		t.Fatal("Body should be read slowly. ", time.Since(start))
+	}
+	if err := client.Get(context.Background(), srv.URL, nil, &result, WithTimeout(20*time.Millisecond)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected timeout, actual=%v", err)
+	}
+}
+
+func TestFaultInjectorWrap(t *testing.T) {
+	srv := newFaultServer(t)
+	fault := NewFaultInjector(1)
+	_ = fault.SetRules(FaultRule{Probability: 1, Fault: Fault{StatusCode: http.StatusServiceUnavailable}})
+
+	client := &http.Client{Transport: fault.Wrap(nil)}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("Status code not match. expected=%d, actual=%d", http.StatusServiceUnavailable, resp.StatusCode)
+	}
+}
+
+func TestLatencyDistributions(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 1))
+	tests := []struct {
+		name     string
+		latency  Latency
+		min, max time.Duration
+	}{
+		{"fixed", FixedLatency(time.Second), time.Second, time.Second},
+		{"uniform", UniformLatency(10*time.Millisecond, 20*time.Millisecond), 10 * time.Millisecond, 20 * time.Millisecond},
+		{"normal", NormalLatency(10*time.Millisecond, 50*time.Millisecond), 0, time.Second},
+		{"exponential", ExponentialLatency(10 * time.Millisecond), 0, time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 1000; i++ {
+				if d := tt.latency(r); d < tt.min || d > tt.max {
+					t.Fatalf("Latency out of range. min=%v, max=%v, actual=%v", tt.min, tt.max, d)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package graphql
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/bookiu/gopkg/httpclient"
+)
+
+// Request GraphQL 请求体
+type Request struct {
+	Query         string         `json:"query,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+	OperationName string         `json:"operationName,omitempty"`
+	Extensions    map[string]any `json:"extensions,omitempty"`
+}
+
+// Response GraphQL 响应体
+type Response struct {
+	Data       json.RawMessage `json:"data"`
+	Errors     Errors          `json:"errors,omitempty"`
+	Extensions map[string]any  `json:"extensions,omitempty"`
+}
+
+// Client 基于 httpclient.Client 的 GraphQL 客户端
+type Client struct {
+	endpoint  string
+	client    httpclient.Client
+	persisted bool
+}
+
+// Option GraphQL 客户端选项
+type Option func(*Client)
+
+// WithPersistedQueries 启用 Automatic Persisted Queries，先只发送查询的 sha256，
+// 服务端返回 PersistedQueryNotFound 时再发送完整查询。
+// 包含 *Upload 的请求仍发送完整查询，因为文件只能读取一次，无法重发
+func WithPersistedQueries() Option {
+	return func(c *Client) {
+		c.persisted = true
+	}
+}
+
+func NewClient(endpoint string, client httpclient.Client, opts ...Option) *Client {
+	c := &Client{
+		endpoint: endpoint,
+		client:   client,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Query 执行查询并将 data 解码到 result
+func (c *Client) Query(ctx context.Context, query string, variables map[string]any, result any, opts ...httpclient.RequestOption) error {
+	return c.Do(ctx, &Request{Query: query, Variables: variables}, result, opts...)
+}
+
+// Mutate 执行变更并将 data 解码到 result
+func (c *Client) Mutate(ctx context.Context, mutation string, variables map[string]any, result any, opts ...httpclient.RequestOption) error {
+	return c.Do(ctx, &Request{Query: mutation, Variables: variables}, result, opts...)
+}
+
+// Do 发送请求并将 data 解码到 result。
+// 响应中包含 errors 时返回 Errors，此时 result 中仍可能包含部分数据。
+// variables 中包含 *Upload 时按 GraphQL multipart 规范上传文件。
+func (c *Client) Do(ctx context.Context, req *Request, result any, opts ...httpclient.RequestOption) error {
+	var resp *Response
+	var err error
+	if c.persisted && !hasUpload(req.Variables) {
+		resp, err = c.doPersisted(ctx, req, opts)
+	} else {
+		resp, err = c.send(ctx, req, opts)
+	}
+	if err != nil {
+		return err
+	}
+
+	if result != nil && len(resp.Data) > 0 && string(resp.Data) != "null" {
+		if err := json.Unmarshal(resp.Data, result); err != nil {
+			return fmt.Errorf("failed to decode graphql data: %w", err)
+		}
+	}
+	if len(resp.Errors) > 0 {
+		return resp.Errors
+	}
+	return nil
+}
+
+func (c *Client) doPersisted(ctx context.Context, req *Request, opts []httpclient.RequestOption) (*Response, error) {
+	sum := sha256.Sum256([]byte(req.Query))
+	extensions := map[string]any{}
+	for k, v := range req.Extensions {
+		extensions[k] = v
+	}
+	extensions["persistedQuery"] = map[string]any{
+		"version":    1,
+		"sha256Hash": hex.EncodeToString(sum[:]),
+	}
+
+	hashed := *req
+	hashed.Query = ""
+	hashed.Extensions = extensions
+	resp, err := c.send(ctx, &hashed, opts)
+	if err != nil || !resp.Errors.persistedQueryNotFound() {
+		return resp, err
+	}
+
+	// 服务端尚未缓存该查询，同时发送查询与 hash 完成注册
+	hashed.Query = req.Query
+	return c.send(ctx, &hashed, opts)
+}
+
+func (c *Client) send(ctx context.Context, req *Request, opts []httpclient.RequestOption) (*Response, error) {
+	opts = append([]httpclient.RequestOption{httpclient.WithResponseHandler(&responseHandler{})}, opts...)
+
+	var resp Response
+	if hasUpload(req.Variables) {
+		body, contentType, err := newMultipartBody(req)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, httpclient.WithContentType(contentType))
+		if err := c.client.Post(ctx, c.endpoint, body, &resp, opts...); err != nil {
+			return nil, err
+		}
+		return &resp, nil
+	}
+
+	if err := c.client.PostJson(ctx, c.endpoint, req, &resp, opts...); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// responseHandler 解析 GraphQL 响应。
+// 部分服务端在请求出错时返回 4xx 状态码与 errors，只要响应体是 GraphQL 响应就正常解析。
+type responseHandler struct{}
+
+func (h *responseHandler) Handle(resp *http.Response, result interface{}) error {
+	r := result.(*Response)
+	err := json.NewDecoder(resp.Body).Decode(r)
+	if resp.StatusCode != http.StatusOK && (err != nil || len(r.Errors) == 0) {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// Location 错误在查询中的位置
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Error GraphQL 响应中的单个错误
+type Error struct {
+	Message    string         `json:"message"`
+	Locations  []Location     `json:"locations,omitempty"`
+	Path       []any          `json:"path,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if len(e.Path) == 0 {
+		return e.Message
+	}
+	path := make([]string, len(e.Path))
+	for i, p := range e.Path {
+		path[i] = fmt.Sprint(p)
+	}
+	return fmt.Sprintf("%s (path: %s)", e.Message, strings.Join(path, "."))
+}
+
+// Code 返回 extensions.code，不存在时返回空字符串
+func (e *Error) Code() string {
+	code, _ := e.Extensions["code"].(string)
+	return code
+}
+
+// Errors GraphQL 响应中的 errors 数组
+type Errors []Error
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i := range e {
+		msgs[i] = e[i].Error()
+	}
+	return "graphql: " + strings.Join(msgs, "; ")
+}
+
+func (e Errors) persistedQueryNotFound() bool {
+	for i := range e {
+		if e[i].Code() == "PERSISTED_QUERY_NOT_FOUND" || e[i].Message == "PersistedQueryNotFound" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,195 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bookiu/gopkg/httpclient"
+)
+
+type user struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) *Client {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return NewClient(srv.URL, httpclient.NewHTTPClient(&httpclient.Config{}), opts...)
+}
+
+func TestQuery(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if r.Header.Get("Content-Type") != "application/json" || req.Variables["id"] != "1" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"user":{"id":"1","name":"gopkg"}}}`))
+	})
+
+	var result struct {
+		User user `json:"user"`
+	}
+	err := client.Query(context.Background(), `query($id: ID!) { user(id: $id) { id name } }`, map[string]any{"id": "1"}, &result)
+	if err != nil {
+		t.Fatal("Query failed. ", err)
+	}
+	if result.User.Name != "gopkg" {
+		t.Fatal("Result not match. ", result)
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{
+			"data": {"user": null},
+			"errors": [{
+				"message": "user not found",
+				"path": ["user", 0],
+				"locations": [{"line": 1, "column": 3}],
+				"extensions": {"code": "NOT_FOUND"}
+			}]
+		}`))
+	})
+
+	var result struct {
+		User *user `json:"user"`
+	}
+	err := client.Query(context.Background(), `{ user(id: 2) { id } }`, nil, &result)
+
+	var gqlErrs Errors
+	if !errors.As(err, &gqlErrs) {
+		t.Fatalf("Expected Errors, actual=%v", err)
+	}
+	if len(gqlErrs) != 1 || gqlErrs[0].Code() != "NOT_FOUND" || gqlErrs[0].Locations[0].Column != 3 {
+		t.Fatal("Errors not match. ", gqlErrs)
+	}
+	if gqlErrs.Error() != "graphql: user not found (path: user.0)" {
+		t.Fatal("Error message not match. ", gqlErrs.Error())
+	}
+}
+
+func TestPersistedQuery(t *testing.T) {
+	stored := map[string]string{}
+	var requests []Request
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		requests = append(requests, req)
+		hash := req.Extensions["persistedQuery"].(map[string]any)["sha256Hash"].(string)
+		if req.Query != "" {
+			stored[hash] = req.Query
+		}
+		if _, ok := stored[hash]; !ok {
+			_, _ = w.Write([]byte(`{"errors":[{"message":"PersistedQueryNotFound","extensions":{"code":"PERSISTED_QUERY_NOT_FOUND"}}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"ok":true}}`))
+	}, WithPersistedQueries())
+
+	var result struct {
+		OK bool `json:"ok"`
+	}
+	for i := 0; i < 2; i++ {
+		if err := client.Query(context.Background(), `{ ok }`, nil, &result); err != nil {
+			t.Fatal("Query failed. ", err)
+		}
+	}
+	if !result.OK || len(requests) != 3 {
+		t.Fatalf("Persisted query not match. ok=%v, requests=%d", result.OK, len(requests))
+	}
+	if requests[0].Query != "" || requests[1].Query == "" || requests[2].Query != "" {
+		t.Fatal("Persisted query requests not match. ", requests)
+	}
+}
+
+func TestMutateUpload(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var operations Request
+		_ = json.Unmarshal([]byte(r.FormValue("operations")), &operations)
+		var fileMap map[string][]string
+		_ = json.Unmarshal([]byte(r.FormValue("map")), &fileMap)
+
+		var names []string
+		for key, paths := range fileMap {
+			f, header, err := r.FormFile(key)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			content, _ := io.ReadAll(f)
+			names = append(names, paths[0]+"="+header.Filename+":"+string(content))
+		}
+		if operations.Variables["file"] != nil || len(names) != 2 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"files": names}})
+	})
+
+	var result struct {
+		Files []string `json:"files"`
+	}
+	err := client.Mutate(context.Background(), `mutation($file: Upload!, $docs: [Upload!]!) { upload(file: $file, docs: $docs) }`,
+		map[string]any{
+			"file": &Upload{File: strings.NewReader("a"), Filename: "a.txt"},
+			"docs": []any{&Upload{File: strings.NewReader("b"), Filename: "b.txt"}},
+		}, &result)
+	if err != nil {
+		t.Fatal("Mutate failed. ", err)
+	}
+
+	want := map[string]bool{"variables.file=a.txt:a": true, "variables.docs.0=b.txt:b": true}
+	for _, f := range result.Files {
+		if !want[f] {
+			t.Fatal("Uploaded files not match. ", result.Files)
+		}
+	}
+}
+
+func TestPersistedQueryUpload(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var operations Request
+		_ = json.Unmarshal([]byte(r.FormValue("operations")), &operations)
+		if operations.Query == "" {
+			_, _ = w.Write([]byte(`{"errors":[{"message":"PersistedQueryNotFound","extensions":{"code":"PERSISTED_QUERY_NOT_FOUND"}}]}`))
+			return
+		}
+		f, _, err := r.FormFile("0")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		content, _ := io.ReadAll(f)
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"content": string(content)}})
+	}, WithPersistedQueries())
+
+	var result struct {
+		Content string `json:"content"`
+	}
+	err := client.Mutate(context.Background(), `mutation($file: Upload!) { upload(file: $file) }`,
+		map[string]any{"file": &Upload{File: strings.NewReader("gopkg"), Filename: "a.txt"}}, &result)
+	if err != nil {
+		t.Fatal("Mutate failed. ", err)
+	}
+	if result.Content != "gopkg" {
+		t.Fatal("Uploaded content not match. ", result.Content)
+	}
+}
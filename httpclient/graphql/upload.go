@@ -0,0 +1,135 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Upload 作为 variables 中的值时，请求按 GraphQL multipart request 规范发送
+// https://github.com/jaydenseric/graphql-multipart-request-spec
+type Upload struct {
+	File        io.Reader
+	Filename    string
+	ContentType string // 默认 application/octet-stream
+}
+
+func hasUpload(v any) bool {
+	switch val := v.(type) {
+	case *Upload:
+		return true
+	case map[string]any:
+		for _, item := range val {
+			if hasUpload(item) {
+				return true
+			}
+		}
+	case []any:
+		for _, item := range val {
+			if hasUpload(item) {
+				return true
+			}
+		}
+	case []*Upload:
+		return len(val) > 0
+	}
+	return false
+}
+
+// extractUploads 返回将 *Upload 替换为 nil 后的副本，并记录每个文件所在的路径
+func extractUploads(v any, path string, files map[string]*Upload) any {
+	switch val := v.(type) {
+	case *Upload:
+		files[path] = val
+		return nil
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, item := range val {
+			out[k] = extractUploads(item, path+"."+k, files)
+		}
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = extractUploads(item, path+"."+strconv.Itoa(i), files)
+		}
+		return out
+	case []*Upload:
+		out := make([]any, len(val))
+		for i, item := range val {
+			files[path+"."+strconv.Itoa(i)] = item
+		}
+		return out
+	}
+	return v
+}
+
+// newMultipartBody 构造 operations、map 与文件字段，返回请求体与 Content-Type
+func newMultipartBody(req *Request) (*bytes.Buffer, string, error) {
+	files := map[string]*Upload{}
+	operation := *req
+	operation.Variables = extractUploads(req.Variables, "variables", files).(map[string]any)
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	// 保证字段顺序稳定
+	slices.Sort(paths)
+
+	fileMap := make(map[string][]string, len(paths))
+	for i, path := range paths {
+		fileMap[strconv.Itoa(i)] = []string{path}
+	}
+
+	buf := new(bytes.Buffer)
+	w := multipart.NewWriter(buf)
+	operations, err := json.Marshal(operation)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := w.WriteField("operations", string(operations)); err != nil {
+		return nil, "", err
+	}
+	mapping, err := json.Marshal(fileMap)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := w.WriteField("map", string(mapping)); err != nil {
+		return nil, "", err
+	}
+
+	for i, path := range paths {
+		upload := files[path]
+		contentType := upload.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%d"; filename="%s"`, i, escapeQuotes(upload.Filename)))
+		header.Set("Content-Type", contentType)
+		part, err := w.CreatePart(header)
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := io.Copy(part, upload.File); err != nil {
+			return nil, "", fmt.Errorf("failed to read upload %s: %w", path, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf, w.FormDataContentType(), nil
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
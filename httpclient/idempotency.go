@@ -0,0 +1,124 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	pkgctx "github.com/bookiu/gopkg/context"
+)
+
+const (
+	defaultIdempotencyHeader = "Idempotency-Key"
+	defaultReplayedHeader    = "Idempotent-Replayed"
+)
+
+// IdempotencyPolicy 自动为非安全请求生成幂等键，同一次调用的所有重试使用相同的键
+type IdempotencyPolicy struct {
+	// Header 幂等键请求头，默认 "Idempotency-Key"
+	Header string
+	// Methods 自动生成幂等键的方法，默认 POST 与 PATCH
+	Methods []string
+	// Generate 生成幂等键，默认随机 UUID
+	Generate func() string
+	// ReplayedHeader 服务端标识重放响应的响应头，默认 "Idempotent-Replayed"
+	ReplayedHeader string
+}
+
+// IdempotencyInfo 本次请求使用的幂等键以及服务端是否返回了重放的响应
+type IdempotencyInfo struct {
+	Key      string
+	Replayed bool
+}
+
+// WithIdempotencyKey 指定本次请求的幂等键，优先于 context 中的幂等键与自动生成的幂等键
+func WithIdempotencyKey(key string) RequestOption {
+	return func(s *RequestSettings) {
+		s.IdempotencyKey = key
+	}
+}
+
+// WithIdempotencyInfo 请求完成后将幂等键以及是否为重放响应写入 info
+func WithIdempotencyInfo(info *IdempotencyInfo) RequestOption {
+	return func(s *RequestSettings) {
+		s.idempotencyInfo = info
+	}
+}
+
+func (p *IdempotencyPolicy) header() string {
+	if p == nil || p.Header == "" {
+		return defaultIdempotencyHeader
+	}
+	return p.Header
+}
+
+func (p *IdempotencyPolicy) replayedHeader() string {
+	if p == nil || p.ReplayedHeader == "" {
+		return defaultReplayedHeader
+	}
+	return p.ReplayedHeader
+}
+
+// shouldGenerate 判断是否为 method 自动生成幂等键，未配置策略时不生成
+func (p *IdempotencyPolicy) shouldGenerate(method string) bool {
+	return p != nil && p.appliesTo(method)
+}
+
+// appliesTo 判断 method 是否使用幂等键，未配置策略时为 POST 与 PATCH
+func (p *IdempotencyPolicy) appliesTo(method string) bool {
+	if p == nil || len(p.Methods) == 0 {
+		return method == http.MethodPost || method == http.MethodPatch
+	}
+	return slices.Contains(p.Methods, method)
+}
+
+func (p *IdempotencyPolicy) generate() string {
+	if p.Generate != nil {
+		return p.Generate()
+	}
+	return newUUID()
+}
+
+// applyIdempotencyKey 按 RequestOption、context、已有请求头、自动生成的顺序确定幂等键并写入请求头。
+// context 中的幂等键属于一次业务操作，只用于策略中的方法，避免同一操作中的 GET 等请求也带上该键
+func (c *HTTPClient) applyIdempotencyKey(ctx context.Context, req *http.Request, s *RequestSettings) string {
+	policy := c.config.Idempotency
+	header := policy.header()
+
+	key := s.IdempotencyKey
+	if key == "" && policy.appliesTo(req.Method) {
+		key = pkgctx.GetIdempotencyKey(ctx)
+	}
+	if key == "" {
+		key = req.Header.Get(header)
+	}
+	if key == "" && policy.shouldGenerate(req.Method) {
+		key = policy.generate()
+	}
+	if key != "" {
+		req.Header.Set(header, key)
+	}
+	return key
+}
+
+// recordIdempotency 将幂等键以及响应是否为重放写入 WithIdempotencyInfo 指定的位置
+func (c *HTTPClient) recordIdempotency(key string, resp *http.Response, s *RequestSettings) {
+	if s.idempotencyInfo == nil {
+		return
+	}
+	s.idempotencyInfo.Key = key
+	replayed, _ := strconv.ParseBool(resp.Header.Get(c.config.Idempotency.replayedHeader()))
+	s.idempotencyInfo.Replayed = replayed
+}
+
+// newUUID 生成随机的 UUID v4
+func newUUID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
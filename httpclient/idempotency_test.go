@@ -0,0 +1,123 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	pkgctx "github.com/bookiu/gopkg/context"
+)
+
+// newIdempotencyServer 前两次请求返回 503，同一幂等键的后续请求返回重放标识
+func newIdempotencyServer(t *testing.T) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	var keys []string
+	seen := map[string]bool{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		key := r.Header.Get("Idempotency-Key")
+		keys = append(keys, key)
+		if len(keys)%3 != 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if seen[key] {
+			w.Header().Set("Idempotent-Replayed", "true")
+		}
+		seen[key] = true
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), keys...)
+	}
+}
+
+func TestIdempotencyKeyAcrossRetries(t *testing.T) {
+	srv, keys := newIdempotencyServer(t)
+	client := NewHTTPClient(&Config{
+		Retry:       &RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond},
+		Idempotency: &IdempotencyPolicy{},
+	})
+
+	var info IdempotencyInfo
+	var result map[string]any
+	if err := client.PostJson(context.Background(), srv.URL, "{}", &result, WithIdempotencyInfo(&info)); err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+	got := keys()
+	if len(got) != 3 || got[0] == "" || got[0] != got[1] || got[1] != got[2] {
+		t.Fatal("Idempotency keys not stable across retries. ", got)
+	}
+	if info.Key != got[0] || info.Replayed {
+		t.Fatal("Idempotency info not match. ", info)
+	}
+
+	// 新的调用生成新的幂等键
+	if err := client.PostJson(context.Background(), srv.URL, "{}", &result); err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+	if got := keys(); got[3] == got[0] {
+		t.Fatal("Idempotency key should differ between calls. ", got)
+	}
+}
+
+func TestIdempotencyKeyFromCaller(t *testing.T) {
+	srv, keys := newIdempotencyServer(t)
+	client := NewHTTPClient(&Config{
+		Retry: &RetryPolicy{MaxAttempts: 3},
+	})
+
+	ctx := pkgctx.WithIdempotencyKey(context.Background(), "order-1")
+	var result map[string]any
+	if err := client.PostJson(ctx, srv.URL, "{}", &result); err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+
+	var info IdempotencyInfo
+	err := client.PostJson(ctx, srv.URL, "{}", &result, WithIdempotencyKey("order-1"), WithIdempotencyInfo(&info))
+	if err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+	for _, key := range keys() {
+		if key != "order-1" {
+			t.Fatal("Idempotency key not match. ", keys())
+		}
+	}
+	if !info.Replayed {
+		t.Fatal("Replayed response not detected")
+	}
+}
+
+func TestIdempotencyKeySkipsSafeMethods(t *testing.T) {
+	srv := newHeadersServer(t)
+	client := NewHTTPClient(&Config{Idempotency: &IdempotencyPolicy{}})
+
+	var resp headersResponse
+	if err := client.Get(context.Background(), srv.URL, nil, &resp); err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+	if _, ok := resp.Headers["Idempotency-Key"]; ok {
+		t.Fatal("GET should not carry an idempotency key")
+	}
+}
+
+func TestIdempotencyKeyFromContextSkipsSafeMethods(t *testing.T) {
+	srv := newHeadersServer(t)
+	client := NewHTTPClient(&Config{Idempotency: &IdempotencyPolicy{}})
+
+	ctx := pkgctx.WithIdempotencyKey(context.Background(), "order-1")
+	var resp headersResponse
+	if err := client.Get(ctx, srv.URL, nil, &resp); err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+	if _, ok := resp.Headers["Idempotency-Key"]; ok {
+		t.Fatal("GET should not carry the idempotency key from context")
+	}
+}
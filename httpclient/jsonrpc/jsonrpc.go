@@ -0,0 +1,225 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/bookiu/gopkg/httpclient"
+)
+
+const version = "2.0"
+
+// 规范中定义的错误码
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// ErrMissingResponse 批量请求中某个调用没有对应的响应
+var ErrMissingResponse = errors.New("jsonrpc: missing response")
+
+// Error JSON-RPC 错误对象
+type Error struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if len(e.Data) == 0 {
+		return fmt.Sprintf("jsonrpc: code=%d, msg=%s", e.Code, e.Message)
+	}
+	return fmt.Sprintf("jsonrpc: code=%d, msg=%s, data=%s", e.Code, e.Message, e.Data)
+}
+
+type request struct {
+	JSONRPC string  `json:"jsonrpc"`
+	ID      *uint64 `json:"id,omitempty"`
+	Method  string  `json:"method"`
+	Params  any     `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result"`
+	Error   *Error          `json:"error"`
+}
+
+// Call 批量请求中的单个调用
+type Call struct {
+	Method string
+	Params any
+	// Result 用于解码结果，为 nil 时忽略结果
+	Result any
+	// Notification 为 true 时不携带 id，服务端不返回响应
+	Notification bool
+	// Error 该调用的错误，RPC 错误为 *Error
+	Error error
+
+	id uint64
+}
+
+// NewCall 创建需要响应的调用
+func NewCall(method string, params any, result any) *Call {
+	return &Call{Method: method, Params: params, Result: result}
+}
+
+// NewNotification 创建通知调用
+func NewNotification(method string, params any) *Call {
+	return &Call{Method: method, Params: params, Notification: true}
+}
+
+// Client 基于 httpclient.Client 的 JSON-RPC 2.0 客户端，认证与观测沿用 httpclient 的配置
+type Client struct {
+	endpoint string
+	client   httpclient.Client
+	nextID   atomic.Uint64
+}
+
+func NewClient(endpoint string, client httpclient.Client) *Client {
+	return &Client{
+		endpoint: endpoint,
+		client:   client,
+	}
+}
+
+// Call 调用 method 并将结果解码到 result
+func (c *Client) Call(ctx context.Context, method string, params any, result any, opts ...httpclient.RequestOption) error {
+	call := NewCall(method, params, result)
+	if err := c.Batch(ctx, []*Call{call}, opts...); err != nil {
+		return err
+	}
+	return call.Error
+}
+
+// Notify 发送通知，不等待结果
+func (c *Client) Notify(ctx context.Context, method string, params any, opts ...httpclient.RequestOption) error {
+	return c.Batch(ctx, []*Call{NewNotification(method, params)}, opts...)
+}
+
+// Batch 批量发送调用，返回的错误仅表示请求失败，每个调用的结果与错误写入对应的 Call。
+// 只有一个调用时不使用批量格式。
+func (c *Client) Batch(ctx context.Context, calls []*Call, opts ...httpclient.RequestOption) error {
+	if len(calls) == 0 {
+		return nil
+	}
+
+	reqs := make([]request, len(calls))
+	pending := make(map[string]*Call, len(calls))
+	for i, call := range calls {
+		reqs[i] = request{JSONRPC: version, Method: call.Method, Params: call.Params}
+		if !call.Notification {
+			call.id = c.nextID.Add(1)
+			reqs[i].ID = &call.id
+			pending[strconv.FormatUint(call.id, 10)] = call
+		}
+	}
+
+	var payload any = reqs
+	route := "batch"
+	if len(reqs) == 1 {
+		payload = reqs[0]
+		route = reqs[0].Method
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	opts = append([]httpclient.RequestOption{
+		httpclient.WithResponseHandler(&responseHandler{}),
+		httpclient.WithRoute(route),
+	}, opts...)
+
+	var raw json.RawMessage
+	if err := c.client.PostJson(ctx, c.endpoint, body, &raw, opts...); err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	resps, err := decodeResponses(raw)
+	if err != nil {
+		return err
+	}
+	for _, resp := range resps {
+		call, ok := pending[string(resp.ID)]
+		if !ok {
+			// 请求无法解析时服务端返回 id 为 null 的错误，作用于所有尚未响应的调用
+			if resp.Error != nil && (len(resp.ID) == 0 || string(resp.ID) == "null") {
+				for id, call := range pending {
+					call.Error = resp.Error
+					delete(pending, id)
+				}
+			}
+			continue
+		}
+		delete(pending, string(resp.ID))
+		call.Error = resp.result(call.Result)
+	}
+	for id, call := range pending {
+		call.Error = fmt.Errorf("%w: id=%s", ErrMissingResponse, id)
+	}
+	return nil
+}
+
+func decodeResponses(raw json.RawMessage) ([]response, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	if raw[0] == '[' {
+		var resps []response
+		if err := json.Unmarshal(raw, &resps); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+		return resps, nil
+	}
+	var resp response
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return []response{resp}, nil
+}
+
+func (r *response) result(result any) error {
+	if r.Error != nil {
+		return r.Error
+	}
+	if result == nil || len(r.Result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(r.Result, result); err != nil {
+		return fmt.Errorf("failed to decode result: %w", err)
+	}
+	return nil
+}
+
+// responseHandler 读取原始响应体，通知请求的响应可能为空或状态码为 204
+type responseHandler struct{}
+
+func (h *responseHandler) Handle(resp *http.Response, result interface{}) error {
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusAccepted, http.StatusNoContent:
+	default:
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	*result.(*json.RawMessage) = data
+	return nil
+}
@@ -0,0 +1,150 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bookiu/gopkg/httpclient"
+)
+
+// rpcServer 实现 add 与 log 两个方法，批量响应按逆序返回
+func newRPCServer(t *testing.T, observe httpclient.ObserveProvider) *Client {
+	handle := func(req map[string]json.RawMessage) *response {
+		var method string
+		_ = json.Unmarshal(req["method"], &method)
+		id, ok := req["id"]
+		if !ok {
+			return nil
+		}
+		resp := &response{JSONRPC: version, ID: id}
+		switch method {
+		case "add":
+			var params []int
+			_ = json.Unmarshal(req["params"], &params)
+			resp.Result, _ = json.Marshal(params[0] + params[1])
+		default:
+			resp.Error = &Error{Code: CodeMethodNotFound, Message: "Method not found", Data: json.RawMessage(`"` + method + `"`)}
+		}
+		return resp
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var raw json.RawMessage
+		_ = json.NewDecoder(r.Body).Decode(&raw)
+		if raw[0] != '[' {
+			var req map[string]json.RawMessage
+			_ = json.Unmarshal(raw, &req)
+			resp := handle(req)
+			if resp == nil {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(resp)
+			return
+		}
+
+		var reqs []map[string]json.RawMessage
+		_ = json.Unmarshal(raw, &reqs)
+		var resps []*response
+		for _, req := range reqs {
+			if resp := handle(req); resp != nil {
+				resps = append(resps, resp)
+			}
+		}
+		if len(resps) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		slices.Reverse(resps)
+		_ = json.NewEncoder(w).Encode(resps)
+	}))
+	t.Cleanup(srv.Close)
+
+	return NewClient(srv.URL, httpclient.NewHTTPClient(&httpclient.Config{
+		Auth:    &httpclient.AuthBearerToken{Token: "token"},
+		Observe: observe,
+	}))
+}
+
+func TestCall(t *testing.T) {
+	client := newRPCServer(t, nil)
+
+	var sum int
+	if err := client.Call(context.Background(), "add", []int{1, 2}, &sum); err != nil {
+		t.Fatal("Call failed. ", err)
+	}
+	if sum != 3 {
+		t.Fatal("Result not match. ", sum)
+	}
+
+	err := client.Call(context.Background(), "sub", []int{1, 2}, &sum)
+	var rpcErr *Error
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("Expected *Error, actual=%v", err)
+	}
+	if rpcErr.Code != CodeMethodNotFound || string(rpcErr.Data) != `"sub"` {
+		t.Fatal("Error not match. ", rpcErr)
+	}
+}
+
+func TestNotify(t *testing.T) {
+	client := newRPCServer(t, nil)
+	if err := client.Notify(context.Background(), "log", map[string]string{"msg": "hello"}); err != nil {
+		t.Fatal("Notify failed. ", err)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	client := newRPCServer(t, nil)
+
+	var a, b int
+	calls := []*Call{
+		NewCall("add", []int{1, 2}, &a),
+		NewNotification("log", "ignored"),
+		NewCall("sub", []int{3, 4}, nil),
+		NewCall("add", []int{5, 6}, &b),
+	}
+	if err := client.Batch(context.Background(), calls); err != nil {
+		t.Fatal("Batch failed. ", err)
+	}
+	if a != 3 || b != 11 {
+		t.Fatalf("Results not match. a=%d, b=%d", a, b)
+	}
+	if calls[0].Error != nil || calls[1].Error != nil || calls[3].Error != nil {
+		t.Fatal("Unexpected call error. ", calls)
+	}
+	var rpcErr *Error
+	if !errors.As(calls[2].Error, &rpcErr) || rpcErr.Code != CodeMethodNotFound {
+		t.Fatal("Error not match. ", calls[2].Error)
+	}
+}
+
+type recordObserve struct {
+	routes []string
+}
+
+func (o *recordObserve) RecordRequest(ctx context.Context, method, url string, statusCode int, duration time.Duration, err error) {
+	o.routes = append(o.routes, httpclient.RouteFromContext(ctx))
+}
+
+func TestObserveRoute(t *testing.T) {
+	observe := &recordObserve{}
+	client := newRPCServer(t, observe)
+
+	var sum int
+	_ = client.Call(context.Background(), "add", []int{1, 2}, &sum)
+	_ = client.Batch(context.Background(), []*Call{NewCall("add", []int{1, 2}, &sum), NewCall("add", []int{1, 2}, &sum)})
+	if !slices.Equal(observe.routes, []string{"add", "batch"}) {
+		t.Fatal("Routes not match. ", observe.routes)
+	}
+}
@@ -0,0 +1,512 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// JSONSchema JSON Schema draft 2020-12 的子集：type、enum、const、properties、required、
+// additionalProperties、items、prefixItems、数值与长度约束、pattern、allOf/anyOf/oneOf/not
+// 以及指向 $defs 的本地 $ref。format 只作为注解，不做校验。
+// 通过 json.Unmarshal 得到的 schema 在第一次校验时编译。
+type JSONSchema struct {
+	Type  schemaTypes     `json:"type,omitempty"`
+	Enum  []interface{}   `json:"enum,omitempty"`
+	Const json.RawMessage `json:"const,omitempty"`
+
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty"`
+	MinProperties        *int                   `json:"minProperties,omitempty"`
+	MaxProperties        *int                   `json:"maxProperties,omitempty"`
+
+	Items       *JSONSchema   `json:"items,omitempty"`
+	PrefixItems []*JSONSchema `json:"prefixItems,omitempty"`
+	MinItems    *int          `json:"minItems,omitempty"`
+	MaxItems    *int          `json:"maxItems,omitempty"`
+	UniqueItems bool          `json:"uniqueItems,omitempty"`
+
+	MinLength *int   `json:"minLength,omitempty"`
+	MaxLength *int   `json:"maxLength,omitempty"`
+	Pattern   string `json:"pattern,omitempty"`
+	Format    string `json:"format,omitempty"`
+
+	Minimum          *float64 `json:"minimum,omitempty"`
+	Maximum          *float64 `json:"maximum,omitempty"`
+	ExclusiveMinimum *float64 `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum *float64 `json:"exclusiveMaximum,omitempty"`
+	MultipleOf       *float64 `json:"multipleOf,omitempty"`
+
+	AllOf []*JSONSchema `json:"allOf,omitempty"`
+	AnyOf []*JSONSchema `json:"anyOf,omitempty"`
+	OneOf []*JSONSchema `json:"oneOf,omitempty"`
+	Not   *JSONSchema   `json:"not,omitempty"`
+
+	Ref  string                 `json:"$ref,omitempty"`
+	Defs map[string]*JSONSchema `json:"$defs,omitempty"`
+
+	// boolean 为 true/false 形式的 schema
+	boolean *bool
+	pattern *regexp.Regexp
+	ref     *JSONSchema
+	constV  interface{}
+	// once 保证 schema 只编译一次，编译根 schema 时子 schema 也被标记为已编译
+	once       sync.Once
+	compileErr error
+}
+
+// schemaTypes type 可以是字符串或字符串数组
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = schemaTypes{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*t = list
+	return nil
+}
+
+func (s *JSONSchema) UnmarshalJSON(data []byte) error {
+	switch string(bytes.TrimSpace(data)) {
+	case "true", "false":
+		b := string(bytes.TrimSpace(data)) == "true"
+		*s = JSONSchema{boolean: &b}
+		return nil
+	}
+	type plain JSONSchema
+	return json.Unmarshal(data, (*plain)(s))
+}
+
+// ParseJSONSchema 解析并编译 JSON Schema，pattern 或 $ref 无效时返回错误
+func ParseJSONSchema(data []byte) (*JSONSchema, error) {
+	var s JSONSchema
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&s); err != nil {
+		return nil, fmt.Errorf("failed to parse json schema: %w", err)
+	}
+	if err := s.ensureCompiled(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// MustParseJSONSchema 与 ParseJSONSchema 相同，出错时 panic，用于包级变量
+func MustParseJSONSchema(data string) *JSONSchema {
+	s, err := ParseJSONSchema([]byte(data))
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// ensureCompiled 以 s 为根编译 schema，并检查不消耗数据的引用循环
+func (s *JSONSchema) ensureCompiled() error {
+	s.once.Do(func() {
+		if s.compileErr = s.compile(s); s.compileErr == nil {
+			s.compileErr = s.checkCycles()
+		}
+	})
+	return s.compileErr
+}
+
+func (s *JSONSchema) compile(root *JSONSchema) error {
+	if s == nil || s.boolean != nil {
+		return nil
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", s.Pattern, err)
+		}
+		s.pattern = re
+	}
+	if s.Ref != "" {
+		ref, err := root.resolve(s.Ref)
+		if err != nil {
+			return err
+		}
+		s.ref = ref
+	}
+	if len(s.Const) > 0 {
+		if err := decodeJSONValue(s.Const, &s.constV); err != nil {
+			return fmt.Errorf("invalid const: %w", err)
+		}
+	}
+
+	for _, c := range s.subschemas() {
+		if c == nil {
+			continue
+		}
+		c.once.Do(func() {})
+		if err := c.compile(root); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkCycles 检查 $ref、allOf、anyOf、oneOf、not 组成的循环。这些关键字作用于同一个值，
+// 循环会导致校验无限递归；经过 properties、items 等关键字的循环会消耗数据，不受影响
+func (s *JSONSchema) checkCycles() error {
+	// state 为 1 表示在当前路径上，2 表示已检查
+	state := make(map[*JSONSchema]int)
+	var visit func(n *JSONSchema) error
+	visit = func(n *JSONSchema) error {
+		if n == nil || n.boolean != nil || state[n] == 2 {
+			return nil
+		}
+		if state[n] == 1 {
+			return fmt.Errorf("json schema reference cycle without consuming data")
+		}
+		state[n] = 1
+		for _, c := range n.inPlace() {
+			if err := visit(c); err != nil {
+				return err
+			}
+		}
+		state[n] = 2
+		return nil
+	}
+
+	var walk func(n *JSONSchema) error
+	walk = func(n *JSONSchema) error {
+		if n == nil {
+			return nil
+		}
+		if err := visit(n); err != nil {
+			return err
+		}
+		for _, c := range n.subschemas() {
+			if err := walk(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(s)
+}
+
+// inPlace 返回作用于同一个值的子 schema
+func (s *JSONSchema) inPlace() []*JSONSchema {
+	list := []*JSONSchema{s.ref, s.Not}
+	list = append(list, s.AllOf...)
+	list = append(list, s.AnyOf...)
+	return append(list, s.OneOf...)
+}
+
+// subschemas 返回直接包含的子 schema，不包括 $ref 指向的 schema
+func (s *JSONSchema) subschemas() []*JSONSchema {
+	list := []*JSONSchema{s.AdditionalProperties, s.Items, s.Not}
+	list = append(list, s.PrefixItems...)
+	list = append(list, s.AllOf...)
+	list = append(list, s.AnyOf...)
+	list = append(list, s.OneOf...)
+	for _, c := range s.Properties {
+		list = append(list, c)
+	}
+	for _, c := range s.Defs {
+		list = append(list, c)
+	}
+	return list
+}
+
+// resolve 解析本地引用，支持 "#" 与 "#/$defs/name"
+func (s *JSONSchema) resolve(ref string) (*JSONSchema, error) {
+	if ref == "#" {
+		return s, nil
+	}
+	name, ok := strings.CutPrefix(ref, "#/$defs/")
+	if ok {
+		name = strings.NewReplacer("~1", "/", "~0", "~").Replace(name)
+		if def, ok := s.Defs[name]; ok {
+			return def, nil
+		}
+	}
+	return nil, fmt.Errorf("unresolved json schema reference: %s", ref)
+}
+
+// ValidateJSON 校验原始 JSON，不符合时返回 *ValidationError
+func (s *JSONSchema) ValidateJSON(data []byte) error {
+	var v interface{}
+	if err := decodeJSONValue(data, &v); err != nil {
+		return &ValidationError{Violations: []Violation{{Message: "invalid json: " + err.Error()}}, err: err}
+	}
+	return s.Validate(v)
+}
+
+// Validate 校验 json.Unmarshal 得到的值，数字可以是 float64 或 json.Number。
+// schema 本身无效时返回编译错误
+func (s *JSONSchema) Validate(v interface{}) error {
+	if err := s.ensureCompiled(); err != nil {
+		return err
+	}
+	var violations []Violation
+	s.validate(v, "", &violations)
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+	return nil
+}
+
+func decodeJSONValue(data []byte, v *interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+func (s *JSONSchema) validate(v interface{}, path string, out *[]Violation) {
+	if s == nil {
+		return
+	}
+	if s.boolean != nil {
+		if !*s.boolean {
+			*out = append(*out, formatViolation(path, "not allowed"))
+		}
+		return
+	}
+	if s.ref != nil {
+		s.ref.validate(v, path, out)
+	}
+
+	if len(s.Type) > 0 && !s.matchType(v) {
+		*out = append(*out, formatViolation(path, "expected %s, got %s", strings.Join(s.Type, " or "), jsonType(v)))
+		// 类型不符时其他约束没有意义
+		return
+	}
+	if len(s.Enum) > 0 {
+		found := false
+		for _, e := range s.Enum {
+			found = found || jsonEqual(v, e)
+		}
+		if !found {
+			*out = append(*out, formatViolation(path, "must be one of the enum values"))
+		}
+	}
+	if len(s.Const) > 0 && !jsonEqual(v, s.constV) {
+		*out = append(*out, formatViolation(path, "must be %s", string(s.Const)))
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		s.validateObject(v, path, out)
+	case []interface{}:
+		s.validateArray(v, path, out)
+	case string:
+		s.validateString(v, path, out)
+	case json.Number, float64:
+		if f, ok := jsonNumber(v); ok {
+			s.validateNumber(f, path, out)
+		}
+	}
+
+	for _, sub := range s.AllOf {
+		sub.validate(v, path, out)
+	}
+	if len(s.AnyOf) > 0 && countMatches(s.AnyOf, v, path) == 0 {
+		*out = append(*out, formatViolation(path, "must match at least one schema in anyOf"))
+	}
+	if len(s.OneOf) > 0 {
+		if n := countMatches(s.OneOf, v, path); n != 1 {
+			*out = append(*out, formatViolation(path, "must match exactly one schema in oneOf, matched %d", n))
+		}
+	}
+	if s.Not != nil && countMatches([]*JSONSchema{s.Not}, v, path) == 1 {
+		*out = append(*out, formatViolation(path, "must not match schema in not"))
+	}
+}
+
+func (s *JSONSchema) validateObject(v map[string]interface{}, path string, out *[]Violation) {
+	for _, name := range s.Required {
+		if _, ok := v[name]; !ok {
+			*out = append(*out, formatViolation(pointerJoin(path, name), "required property is missing"))
+		}
+	}
+	if s.MinProperties != nil && len(v) < *s.MinProperties {
+		*out = append(*out, formatViolation(path, "must have at least %d properties", *s.MinProperties))
+	}
+	if s.MaxProperties != nil && len(v) > *s.MaxProperties {
+		*out = append(*out, formatViolation(path, "must have at most %d properties", *s.MaxProperties))
+	}
+	for _, name := range slices.Sorted(maps.Keys(v)) {
+		child := pointerJoin(path, name)
+		if prop, ok := s.Properties[name]; ok {
+			prop.validate(v[name], child, out)
+		} else if s.AdditionalProperties != nil {
+			if b := s.AdditionalProperties.boolean; b != nil && !*b {
+				*out = append(*out, formatViolation(child, "additional property is not allowed"))
+			} else {
+				s.AdditionalProperties.validate(v[name], child, out)
+			}
+		}
+	}
+}
+
+func (s *JSONSchema) validateArray(v []interface{}, path string, out *[]Violation) {
+	if s.MinItems != nil && len(v) < *s.MinItems {
+		*out = append(*out, formatViolation(path, "must have at least %d items", *s.MinItems))
+	}
+	if s.MaxItems != nil && len(v) > *s.MaxItems {
+		*out = append(*out, formatViolation(path, "must have at most %d items", *s.MaxItems))
+	}
+	for i, item := range v {
+		child := pointerJoin(path, strconv.Itoa(i))
+		if i < len(s.PrefixItems) {
+			s.PrefixItems[i].validate(item, child, out)
+		} else if s.Items != nil {
+			s.Items.validate(item, child, out)
+		}
+	}
+	if s.UniqueItems {
+		for i := range v {
+			for j := i + 1; j < len(v); j++ {
+				if jsonEqual(v[i], v[j]) {
+					*out = append(*out, formatViolation(path, "items %d and %d are equal", i, j))
+				}
+			}
+		}
+	}
+}
+
+func (s *JSONSchema) validateString(v string, path string, out *[]Violation) {
+	n := utf8.RuneCountInString(v)
+	if s.MinLength != nil && n < *s.MinLength {
+		*out = append(*out, formatViolation(path, "length must be at least %d", *s.MinLength))
+	}
+	if s.MaxLength != nil && n > *s.MaxLength {
+		*out = append(*out, formatViolation(path, "length must be at most %d", *s.MaxLength))
+	}
+	if s.pattern != nil && !s.pattern.MatchString(v) {
+		*out = append(*out, formatViolation(path, "must match pattern %q", s.Pattern))
+	}
+}
+
+func (s *JSONSchema) validateNumber(v float64, path string, out *[]Violation) {
+	if s.Minimum != nil && v < *s.Minimum {
+		*out = append(*out, formatViolation(path, "must be >= %v", *s.Minimum))
+	}
+	if s.Maximum != nil && v > *s.Maximum {
+		*out = append(*out, formatViolation(path, "must be <= %v", *s.Maximum))
+	}
+	if s.ExclusiveMinimum != nil && v <= *s.ExclusiveMinimum {
+		*out = append(*out, formatViolation(path, "must be > %v", *s.ExclusiveMinimum))
+	}
+	if s.ExclusiveMaximum != nil && v >= *s.ExclusiveMaximum {
+		*out = append(*out, formatViolation(path, "must be < %v", *s.ExclusiveMaximum))
+	}
+	if s.MultipleOf != nil && *s.MultipleOf > 0 {
+		if q := v / *s.MultipleOf; math.Abs(q-math.Round(q)) > 1e-9 {
+			*out = append(*out, formatViolation(path, "must be a multiple of %v", *s.MultipleOf))
+		}
+	}
+}
+
+func (s *JSONSchema) matchType(v interface{}) bool {
+	actual := jsonType(v)
+	for _, t := range s.Type {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// countMatches 返回 v 符合的 schema 数量
+func countMatches(schemas []*JSONSchema, v interface{}, path string) int {
+	n := 0
+	for _, s := range schemas {
+		var violations []Violation
+		s.validate(v, path, &violations)
+		if len(violations) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// jsonType 返回 JSON Schema 中的类型名，没有小数部分的数字为 integer
+func jsonType(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	case json.Number, float64:
+		if f, ok := jsonNumber(v); ok && f == math.Trunc(f) && !math.IsInf(f, 0) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func jsonNumber(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// jsonEqual 比较两个 JSON 值，数字按数值比较
+func jsonEqual(a, b interface{}) bool {
+	if fa, ok := jsonNumber(a); ok {
+		fb, ok := jsonNumber(b)
+		return ok && fa == fb
+	}
+	switch a := a.(type) {
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !jsonEqual(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k, av := range a {
+			bv, ok := b[k]
+			if !ok || !jsonEqual(av, bv) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
+
+// pointerJoin 按 RFC 6901 拼接 JSON Pointer
+func pointerJoin(path, token string) string {
+	return path + "/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
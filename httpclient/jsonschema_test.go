@@ -0,0 +1,184 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const userSchema = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"type": "object",
+	"required": ["id", "name", "tags"],
+	"properties": {
+		"id": {"type": "integer", "minimum": 1},
+		"name": {"type": "string", "minLength": 2, "pattern": "^[a-z]+$"},
+		"email": {"type": ["string", "null"], "format": "email"},
+		"role": {"enum": ["admin", "member"]},
+		"score": {"type": "number", "exclusiveMaximum": 100, "multipleOf": 0.5},
+		"tags": {"type": "array", "items": {"type": "string"}, "maxItems": 3, "uniqueItems": true},
+		"address": {"$ref": "#/$defs/address"}
+	},
+	"additionalProperties": false,
+	"$defs": {
+		"address": {
+			"type": "object",
+			"required": ["city"],
+			"properties": {"city": {"type": "string"}, "zip": {"type": "string", "pattern": "^[0-9]{6}$"}}
+		}
+	}
+}`
+
+func TestJSONSchemaValidate(t *testing.T) {
+	schema, err := ParseJSONSchema([]byte(userSchema))
+	if err != nil {
+		t.Fatal("Parse schema failed. ", err)
+	}
+
+	tests := []struct {
+		name     string
+		data     string
+		expected []Violation
+	}{
+		{
+			name: "valid",
+			data: `{"id": 1, "name": "gopkg", "email": null, "role": "admin", "score": 99.5, "tags": ["a", "b"], "address": {"city": "sz", "zip": "518000"}}`,
+		},
+		{
+			name: "integer as float",
+			data: `{"id": 2.0, "name": "go", "tags": []}`,
+		},
+		{
+			name: "all violations",
+			data: `{"id": 0, "name": "A", "role": "owner", "score": 100.2, "tags": ["a", "a", 1, "b"], "address": {"zip": "x"}, "extra": true}`,
+			expected: []Violation{
+				{"/address/city", "required property is missing"},
+				{"/address/zip", `must match pattern "^[0-9]{6}$"`},
+				{"/extra", "additional property is not allowed"},
+				{"/id", "must be >= 1"},
+				{"/name", "length must be at least 2"},
+				{"/name", `must match pattern "^[a-z]+$"`},
+				{"/role", "must be one of the enum values"},
+				{"/score", "must be < 100"},
+				{"/score", "must be a multiple of 0.5"},
+				{"/tags", "must have at most 3 items"},
+				{"/tags/2", "expected string, got integer"},
+				{"/tags", "items 0 and 1 are equal"},
+			},
+		},
+		{
+			name: "missing and wrong type",
+			data: `{"id": "1", "tags": null}`,
+			expected: []Violation{
+				{"/name", "required property is missing"},
+				{"/id", "expected integer, got string"},
+				{"/tags", "expected array, got null"},
+			},
+		},
+		{
+			name:     "root type",
+			data:     `[]`,
+			expected: []Violation{{"", "expected object, got array"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schema.ValidateJSON([]byte(tt.data))
+			if tt.expected == nil {
+				if err != nil {
+					t.Fatal("Validate failed. ", err)
+				}
+				return
+			}
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Expected *ValidationError, actual=%v", err)
+			}
+			if !reflect.DeepEqual(verr.Violations, tt.expected) {
+				t.Fatalf("Violations not match.\nexpected=%v\nactual=%v", tt.expected, verr.Violations)
+			}
+		})
+	}
+}
+
+func TestJSONSchemaCombinators(t *testing.T) {
+	schema := MustParseJSONSchema(`{
+		"oneOf": [
+			{"type": "object", "required": ["cat"], "properties": {"cat": {"const": {"lives": 9}}}},
+			{"type": "object", "required": ["dog"]}
+		],
+		"anyOf": [{"required": ["cat"]}, {"required": ["dog"]}],
+		"not": {"required": ["fish"]},
+		"allOf": [true, {"minProperties": 1}],
+		"prefixItems": [{"type": "string"}]
+	}`)
+
+	tests := []struct {
+		data  string
+		valid bool
+	}{
+		{`{"cat": {"lives": 9}}`, true},
+		{`{"dog": 1}`, true},
+		{`{"cat": {"lives": 8}}`, false},
+		{`{"cat": {"lives": 9}, "dog": 1}`, false},
+		{`{"dog": 1, "fish": 1}`, false},
+		{`{}`, false},
+	}
+	for _, tt := range tests {
+		if err := schema.ValidateJSON([]byte(tt.data)); (err == nil) != tt.valid {
+			t.Fatalf("Validate not match. data=%s, err=%v", tt.data, err)
+		}
+	}
+
+	tuple := MustParseJSONSchema(`{"prefixItems": [{"type": "string"}, {"type": "integer"}], "items": false}`)
+	if err := tuple.ValidateJSON([]byte(`["a", 1]`)); err != nil {
+		t.Fatal("Validate failed. ", err)
+	}
+	if err := tuple.ValidateJSON([]byte(`["a", 1, 2]`)); err == nil {
+		t.Fatal("Expected extra item error")
+	}
+
+	tree := MustParseJSONSchema(`{"type": "object", "properties": {"children": {"type": "array", "items": {"$ref": "#"}}}, "required": ["name"]}`)
+	err := tree.ValidateJSON([]byte(`{"name": "root", "children": [{"name": "a"}, {"children": []}]}`))
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Violations) != 1 || verr.Violations[0].Path != "/children/1/name" {
+		t.Fatal("Recursive ref not match. ", err)
+	}
+}
+
+func TestParseJSONSchemaInvalid(t *testing.T) {
+	for _, data := range []string{
+		`{"$ref": "#/$defs/missing"}`, `{"pattern": "("}`, `{"type": 1}`,
+		`{"$ref": "#"}`,
+		`{"$defs": {"a": {"allOf": [{"$ref": "#/$defs/b"}]}, "b": {"not": {"$ref": "#/$defs/a"}}}, "$ref": "#/$defs/a"}`,
+	} {
+		if _, err := ParseJSONSchema([]byte(data)); err == nil {
+			t.Fatal("Expected parse error. ", data)
+		}
+	}
+}
+
+func TestJSONSchemaUnmarshal(t *testing.T) {
+	var schema JSONSchema
+	data := `{"type": "object", "properties": {"kind": {"const": "user"}, "code": {"$ref": "#/$defs/code"}}, "$defs": {"code": {"type": "string", "pattern": "^[a-z]+$"}}}`
+	if err := json.Unmarshal([]byte(data), &schema); err != nil {
+		t.Fatal("Unmarshal schema failed. ", err)
+	}
+	if err := schema.ValidateJSON([]byte(`{"kind": "user", "code": "abc"}`)); err != nil {
+		t.Fatal("Valid value rejected. ", err)
+	}
+	var verr *ValidationError
+	err := schema.ValidateJSON([]byte(`{"kind": "admin", "code": "ABC"}`))
+	if !errors.As(err, &verr) || len(verr.Violations) != 2 {
+		t.Fatal("Expected const and pattern violations. ", err)
+	}
+
+	var cyclic JSONSchema
+	if err := json.Unmarshal([]byte(`{"$ref": "#"}`), &cyclic); err != nil {
+		t.Fatal("Unmarshal schema failed. ", err)
+	}
+	if err := cyclic.ValidateJSON([]byte(`1`)); err == nil || errors.As(err, &verr) {
+		t.Fatal("Expected compile error. ", err)
+	}
+}
@@ -0,0 +1,330 @@
+package httpclient
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 支持的 JWT 签名算法
+const (
+	JWTAlgHS256 = "HS256"
+	JWTAlgRS256 = "RS256"
+	JWTAlgES256 = "ES256"
+)
+
+var (
+	// ErrJWTInvalid token 格式、算法或签名不正确
+	ErrJWTInvalid = errors.New("jwt: invalid token")
+	// ErrJWTExpired token 已过期或尚未生效
+	ErrJWTExpired = errors.New("jwt: token expired")
+	// ErrJWTClaims iss、aud 等声明不匹配
+	ErrJWTClaims = errors.New("jwt: claims mismatch")
+)
+
+var jwtEncoding = base64.RawURLEncoding
+
+// AuthJWT 在本地签发 JWT 并以 Bearer Token 发送，token 在过期前会被缓存复用
+type AuthJWT struct {
+	// Algorithm HS256、RS256 或 ES256
+	Algorithm string
+	// Key HS256 为 []byte，RS256 为 *rsa.PrivateKey，ES256 为 *ecdsa.PrivateKey（P-256）
+	Key interface{}
+	// KeyID 写入 header 的 kid，为空时不写入
+	KeyID    string
+	Issuer   string
+	Subject  string
+	Audience []string
+	// Claims 自定义声明，不会覆盖 iss、sub、aud、iat、nbf、exp、jti
+	Claims map[string]interface{}
+	// Lifetime token 有效期，默认 5 分钟
+	Lifetime time.Duration
+	// RefreshBefore 剩余有效期小于该值时重新签发，默认为 Lifetime 的 1/5
+	RefreshBefore time.Duration
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+	// now 用于测试
+	now func() time.Time
+}
+
+// Apply 实现 AuthProvider 接口，签发失败时不设置认证头
+func (a *AuthJWT) Apply(req *http.Request) {
+	if token, err := a.Token(); err == nil {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+}
+
+// Snapshot 实现 AuthSnapshotter 接口，签发失败时请求不会发送
+func (a *AuthJWT) Snapshot() (AuthProvider, error) {
+	token, err := a.Token()
+	if err != nil {
+		return nil, err
+	}
+	return &AuthBearerToken{Token: token}, nil
+}
+
+// Token 返回缓存的 token，即将过期时重新签发
+func (a *AuthJWT) Token() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	if a.now != nil {
+		now = a.now()
+	}
+	lifetime := a.Lifetime
+	if lifetime <= 0 {
+		lifetime = 5 * time.Minute
+	}
+	refresh := a.RefreshBefore
+	if refresh <= 0 {
+		refresh = lifetime / 5
+	}
+	if a.token != "" && a.expires.Sub(now) > refresh {
+		return a.token, nil
+	}
+
+	expires := now.Add(lifetime)
+	token, err := a.sign(now, expires)
+	if err != nil {
+		return "", err
+	}
+	a.token, a.expires = token, expires
+	return token, nil
+}
+
+func (a *AuthJWT) sign(now, expires time.Time) (string, error) {
+	claims := make(map[string]interface{}, len(a.Claims)+7)
+	for k, v := range a.Claims {
+		claims[k] = v
+	}
+	if a.Issuer != "" {
+		claims["iss"] = a.Issuer
+	}
+	if a.Subject != "" {
+		claims["sub"] = a.Subject
+	}
+	switch len(a.Audience) {
+	case 0:
+	case 1:
+		claims["aud"] = a.Audience[0]
+	default:
+		claims["aud"] = a.Audience
+	}
+	claims["iat"] = now.Unix()
+	claims["nbf"] = now.Unix()
+	claims["exp"] = expires.Unix()
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", err
+	}
+	claims["jti"] = hex.EncodeToString(jti)
+
+	header := map[string]string{"alg": a.Algorithm, "typ": "JWT"}
+	if a.KeyID != "" {
+		header["kid"] = a.KeyID
+	}
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode jwt claims: %w", err)
+	}
+
+	signingInput := jwtEncoding.EncodeToString(headerJSON) + "." + jwtEncoding.EncodeToString(claimsJSON)
+	sig, err := jwtSign(a.Algorithm, a.Key, signingInput)
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + jwtEncoding.EncodeToString(sig), nil
+}
+
+func jwtSign(alg string, key interface{}, signingInput string) ([]byte, error) {
+	digest := sha256.Sum256([]byte(signingInput))
+	switch alg {
+	case JWTAlgHS256:
+		secret, ok := key.([]byte)
+		if !ok || len(secret) == 0 {
+			return nil, fmt.Errorf("jwt: %s requires a []byte key", alg)
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signingInput))
+		return mac.Sum(nil), nil
+	case JWTAlgRS256:
+		priv, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("jwt: %s requires an *rsa.PrivateKey", alg)
+		}
+		return rsa.SignPKCS1v15(rand.Reader, priv, crypto.SHA256, digest[:])
+	case JWTAlgES256:
+		priv, ok := key.(*ecdsa.PrivateKey)
+		if !ok || priv.Curve.Params().BitSize != 256 {
+			return nil, fmt.Errorf("jwt: %s requires a P-256 *ecdsa.PrivateKey", alg)
+		}
+		r, s, err := ecdsa.Sign(rand.Reader, priv, digest[:])
+		if err != nil {
+			return nil, err
+		}
+		// RFC 7518 3.4: r 与 s 各 32 字节拼接
+		sig := make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+		return sig, nil
+	}
+	return nil, fmt.Errorf("jwt: unsupported algorithm: %s", alg)
+}
+
+// JWTVerifier 校验 AuthJWT 签发的 token，供接收方使用
+type JWTVerifier struct {
+	// Algorithm 只接受该算法签名的 token
+	Algorithm string
+	// Key HS256 为 []byte，RS256 为 *rsa.PublicKey，ES256 为 *ecdsa.PublicKey
+	Key interface{}
+	// Issuer 不为空时要求 iss 相同
+	Issuer string
+	// Audience 不为空时要求 aud 包含该值
+	Audience string
+	// Leeway 校验 exp、nbf 时允许的时钟误差
+	Leeway time.Duration
+
+	// now 用于测试
+	now func() time.Time
+}
+
+// Verify 校验 token 的签名与声明，返回全部声明
+func (v *JWTVerifier) Verify(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrJWTInvalid
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := jwtDecodePart(parts[0], &header); err != nil {
+		return nil, err
+	}
+	if header.Alg != v.Algorithm {
+		return nil, fmt.Errorf("%w: unexpected algorithm %q", ErrJWTInvalid, header.Alg)
+	}
+	sig, err := jwtEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrJWTInvalid
+	}
+	if !jwtVerify(v.Algorithm, v.Key, parts[0]+"."+parts[1], sig) {
+		return nil, fmt.Errorf("%w: signature mismatch", ErrJWTInvalid)
+	}
+
+	claims := map[string]interface{}{}
+	if err := jwtDecodePart(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if err := v.checkClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// VerifyRequest 从 Authorization 请求头中读取 Bearer Token 并校验
+func (v *JWTVerifier) VerifyRequest(req *http.Request) (map[string]interface{}, error) {
+	auth := req.Header.Get("Authorization")
+	token, ok := strings.CutPrefix(auth, "Bearer ")
+	if !ok || token == "" {
+		return nil, fmt.Errorf("%w: missing bearer token", ErrJWTInvalid)
+	}
+	return v.Verify(token)
+}
+
+func (v *JWTVerifier) checkClaims(claims map[string]interface{}) error {
+	now := time.Now()
+	if v.now != nil {
+		now = v.now()
+	}
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return fmt.Errorf("%w: missing exp", ErrJWTClaims)
+	}
+	if !now.Before(time.Unix(int64(exp), 0).Add(v.Leeway)) {
+		return ErrJWTExpired
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(v.Leeway).Before(time.Unix(int64(nbf), 0)) {
+		return ErrJWTExpired
+	}
+
+	if v.Issuer != "" && claims["iss"] != v.Issuer {
+		return fmt.Errorf("%w: iss", ErrJWTClaims)
+	}
+	if v.Audience != "" && !jwtHasAudience(claims["aud"], v.Audience) {
+		return fmt.Errorf("%w: aud", ErrJWTClaims)
+	}
+	return nil
+}
+
+func jwtVerify(alg string, key interface{}, signingInput string, sig []byte) bool {
+	digest := sha256.Sum256([]byte(signingInput))
+	switch alg {
+	case JWTAlgHS256:
+		secret, ok := key.([]byte)
+		if !ok || len(secret) == 0 {
+			return false
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signingInput))
+		return hmac.Equal(sig, mac.Sum(nil))
+	case JWTAlgRS256:
+		pub, ok := key.(*rsa.PublicKey)
+		return ok && rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig) == nil
+	case JWTAlgES256:
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(sig) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		return ecdsa.Verify(pub, digest[:], r, s)
+	}
+	return false
+}
+
+func jwtDecodePart(part string, v interface{}) error {
+	data, err := jwtEncoding.DecodeString(part)
+	if err != nil {
+		return ErrJWTInvalid
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return ErrJWTInvalid
+	}
+	return nil
+}
+
+// jwtHasAudience aud 可以是字符串或字符串数组
+func jwtHasAudience(aud interface{}, expected string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == expected
+	case []interface{}:
+		for _, a := range aud {
+			if a == expected {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,170 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAuthJWTSignVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal("Generate rsa key failed. ", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("Generate ecdsa key failed. ", err)
+	}
+	secret := []byte("shared-secret")
+
+	tests := []struct {
+		alg     string
+		signKey interface{}
+		pubKey  interface{}
+	}{
+		{alg: JWTAlgHS256, signKey: secret, pubKey: secret},
+		{alg: JWTAlgRS256, signKey: rsaKey, pubKey: &rsaKey.PublicKey},
+		{alg: JWTAlgES256, signKey: ecKey, pubKey: &ecKey.PublicKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			auth := &AuthJWT{
+				Algorithm: tt.alg,
+				Key:       tt.signKey,
+				Issuer:    "order-service",
+				Subject:   "svc:order",
+				Audience:  []string{"user-service"},
+				Claims:    map[string]interface{}{"scope": "read", "iss": "ignored"},
+			}
+			token, err := auth.Token()
+			if err != nil {
+				t.Fatal("Sign failed. ", err)
+			}
+
+			verifier := &JWTVerifier{Algorithm: tt.alg, Key: tt.pubKey, Issuer: "order-service", Audience: "user-service"}
+			claims, err := verifier.Verify(token)
+			if err != nil {
+				t.Fatal("Verify failed. ", err)
+			}
+			if claims["sub"] != "svc:order" || claims["scope"] != "read" || claims["iss"] != "order-service" {
+				t.Fatal("Claims not match. ", claims)
+			}
+
+			// 篡改 payload
+			tampered := token[:len(token)-4] + "AAAA"
+			if _, err := verifier.Verify(tampered); !errors.Is(err, ErrJWTInvalid) {
+				t.Fatalf("Expected ErrJWTInvalid, actual=%v", err)
+			}
+		})
+	}
+}
+
+func TestJWTVerifierRejects(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Now()
+	auth := &AuthJWT{Algorithm: JWTAlgHS256, Key: secret, Audience: []string{"a", "b"}, Lifetime: time.Minute}
+	token, err := auth.Token()
+	if err != nil {
+		t.Fatal("Sign failed. ", err)
+	}
+
+	tests := []struct {
+		name     string
+		verifier *JWTVerifier
+		expected error
+	}{
+		{"wrong key", &JWTVerifier{Algorithm: JWTAlgHS256, Key: []byte("other")}, ErrJWTInvalid},
+		{"algorithm confusion", &JWTVerifier{Algorithm: JWTAlgRS256, Key: secret}, ErrJWTInvalid},
+		{"audience", &JWTVerifier{Algorithm: JWTAlgHS256, Key: secret, Audience: "c"}, ErrJWTClaims},
+		{"issuer", &JWTVerifier{Algorithm: JWTAlgHS256, Key: secret, Issuer: "x"}, ErrJWTClaims},
+		{"expired", &JWTVerifier{Algorithm: JWTAlgHS256, Key: secret, now: func() time.Time { return now.Add(2 * time.Minute) }}, ErrJWTExpired},
+		{"not yet valid", &JWTVerifier{Algorithm: JWTAlgHS256, Key: secret, now: func() time.Time { return now.Add(-time.Minute) }}, ErrJWTExpired},
+		{"leeway", &JWTVerifier{Algorithm: JWTAlgHS256, Key: secret, Audience: "b", Leeway: 2 * time.Minute, now: func() time.Time { return now.Add(2 * time.Minute) }}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.verifier.Verify(token)
+			if tt.expected == nil && err != nil {
+				t.Fatal("Verify failed. ", err)
+			}
+			if tt.expected != nil && !errors.Is(err, tt.expected) {
+				t.Fatalf("Expected %v, actual=%v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestJWTVerifierExpBoundary(t *testing.T) {
+	auth := &AuthJWT{Algorithm: JWTAlgHS256, Key: []byte("secret"), Lifetime: time.Minute}
+	token, err := auth.Token()
+	if err != nil {
+		t.Fatal("Sign failed. ", err)
+	}
+	claims, err := (&JWTVerifier{Algorithm: JWTAlgHS256, Key: []byte("secret")}).Verify(token)
+	if err != nil {
+		t.Fatal("Verify failed. ", err)
+	}
+	// exp + leeway 时刻起令牌过期
+	deadline := time.Unix(int64(claims["exp"].(float64)), 0).Add(time.Second)
+	verifier := &JWTVerifier{Algorithm: JWTAlgHS256, Key: []byte("secret"), Leeway: time.Second}
+	verifier.now = func() time.Time { return deadline.Add(-time.Nanosecond) }
+	if _, err := verifier.Verify(token); err != nil {
+		t.Fatal("Verify failed. ", err)
+	}
+	verifier.now = func() time.Time { return deadline }
+	if _, err := verifier.Verify(token); !errors.Is(err, ErrJWTExpired) {
+		t.Fatalf("Expected %v, actual=%v", ErrJWTExpired, err)
+	}
+}
+
+func TestAuthJWTCache(t *testing.T) {
+	now := time.Now()
+	auth := &AuthJWT{Algorithm: JWTAlgHS256, Key: []byte("secret"), Lifetime: 10 * time.Minute}
+	auth.now = func() time.Time { return now }
+
+	first, _ := auth.Token()
+	now = now.Add(7 * time.Minute)
+	if second, _ := auth.Token(); second != first {
+		t.Fatal("Token should be cached")
+	}
+	// 剩余有效期小于 Lifetime 的 1/5 时重新签发
+	now = now.Add(2 * time.Minute)
+	if third, _ := auth.Token(); third == first {
+		t.Fatal("Token should be re-minted before expiry")
+	}
+}
+
+func TestAuthJWTClient(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("Generate ecdsa key failed. ", err)
+	}
+	verifier := &JWTVerifier{Algorithm: JWTAlgES256, Key: &ecKey.PublicKey, Audience: "api"}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := verifier.VerifyRequest(r); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	client := NewHTTPClient(&Config{Auth: &AuthJWT{Algorithm: JWTAlgES256, Key: ecKey, Audience: []string{"api"}}})
+	var result map[string]string
+	if err := client.Get(context.Background(), srv.URL, nil, &result); err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+
+	// 密钥类型错误时请求不会发送
+	client = NewHTTPClient(&Config{Auth: &AuthJWT{Algorithm: JWTAlgES256, Key: []byte("secret")}})
+	if err := client.Get(context.Background(), srv.URL, nil, &result); err == nil {
+		t.Fatal("Expected signing error")
+	}
+}
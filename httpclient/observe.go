@@ -13,6 +13,25 @@ type ObserveProvider interface {
 	RecordRequest(ctx context.Context, method, url string, statusCode int, duration time.Duration, err error)
 }
 
+// EventObserveProvider 接收完整的 RequestEvent，Config.Observe 实现该接口时不再调用 RecordRequest。
+// 事件在响应体关闭后记录，此时响应体大小已知。
+type EventObserveProvider interface {
+	RecordEvent(ctx context.Context, event *RequestEvent)
+}
+
+// NewEventObserveAdapter 将只实现了 ObserveProvider 的观测者转为 EventObserveProvider
+func NewEventObserveAdapter(p ObserveProvider) EventObserveProvider {
+	return &eventObserveAdapter{provider: p}
+}
+
+type eventObserveAdapter struct {
+	provider ObserveProvider
+}
+
+func (a *eventObserveAdapter) RecordEvent(ctx context.Context, event *RequestEvent) {
+	a.provider.RecordRequest(ctx, event.Method, event.URL, event.StatusCode, event.Duration, event.Err)
+}
+
 type ObserveRequest struct {
 }
 
@@ -21,23 +40,44 @@ func NewObserveRequest() ObserveProvider {
 }
 
 func (o *ObserveRequest) RecordRequest(ctx context.Context, method, url string, statusCode int, duration time.Duration, err error) {
+	o.RecordEvent(ctx, &RequestEvent{
+		Method:     method,
+		URL:        url,
+		Route:      RouteFromContext(ctx),
+		StatusCode: statusCode,
+		Duration:   duration,
+		Err:        err,
+	})
+}
+
+func (o *ObserveRequest) RecordEvent(ctx context.Context, event *RequestEvent) {
 	log := pkgctx.GetLogger(ctx)
-	if err != nil {
-		log.Error("Request failed",
-			zap.String("method", method),
-			zap.String("url", url),
-			zap.Int("status_code", statusCode),
-			zap.Duration("duration", duration),
-			zap.Error(err),
-		)
+	if event.Route != "" {
+		log = log.With(zap.String("route", event.Route))
+	}
+	fields := []zap.Field{
+		zap.String("method", event.Method),
+		zap.String("url", event.URL),
+		zap.Int("status_code", event.StatusCode),
+		zap.Duration("duration", event.Duration),
+	}
+	if event.Attempt > 1 {
+		fields = append(fields, zap.Int("attempt", event.Attempt))
+	}
+	if event.Err != nil {
+		log.Error("Request failed", append(fields, zap.Error(event.Err))...)
 		return
 	}
-	log.Info("Rcv response",
-		zap.String("method", method),
-		zap.String("url", url),
-		zap.Int("status_code", statusCode),
-		zap.Duration("duration", duration),
+	fields = append(fields,
+		zap.Duration("dns", event.DNS),
+		zap.Duration("connect", event.Connect),
+		zap.Duration("tls_handshake", event.TLSHandshake),
+		zap.Duration("ttfb", event.TTFB),
+		zap.Bool("conn_reused", event.ConnReused),
+		zap.Int64("request_size", event.RequestSize),
+		zap.Int64("response_size", event.ResponseSize),
 	)
+	log.Info("Rcv response", fields...)
 }
 
 type NoopObserve struct {
@@ -45,3 +85,6 @@ type NoopObserve struct {
 
 func (o *NoopObserve) RecordRequest(ctx context.Context, method, url string, statusCode int, duration time.Duration, err error) {
 }
+
+func (o *NoopObserve) RecordEvent(ctx context.Context, event *RequestEvent) {
+}
@@ -0,0 +1,411 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-querystring/query"
+)
+
+// ErrMaxPagesExceeded 分页数量达到 MaxPages 上限但仍有下一页
+var ErrMaxPagesExceeded = errors.New("pagination exceeded max pages")
+
+// PageResponse 单页响应，供 PageStrategy 计算下一页
+type PageResponse struct {
+	Header http.Header
+	// Body 经 ResponseHandler 处理后的原始 JSON
+	Body json.RawMessage
+	// Count 当前页的条目数
+	Count int
+}
+
+// PageStrategy 定义分页方式
+type PageStrategy interface {
+	// Start 设置第一页的请求参数
+	Start(u *url.URL)
+	// Next 根据当前页的响应返回下一页的地址，返回 nil 表示没有更多数据
+	Next(cur *url.URL, page *PageResponse) (*url.URL, error)
+}
+
+// PageNumberStrategy page/size 分页
+type PageNumberStrategy struct {
+	PageParam string // 默认 "page"
+	SizeParam string // 默认 "size"
+	Size      int    // 每页条目数，为 0 时不发送 SizeParam
+	FirstPage int    // 起始页码，默认 1
+}
+
+func (s *PageNumberStrategy) Start(u *url.URL) {
+	first := s.FirstPage
+	if first == 0 {
+		first = 1
+	}
+	q := u.Query()
+	q.Set(paramOrDefault(s.PageParam, "page"), strconv.Itoa(first))
+	if s.Size > 0 {
+		q.Set(paramOrDefault(s.SizeParam, "size"), strconv.Itoa(s.Size))
+	}
+	u.RawQuery = q.Encode()
+}
+
+func (s *PageNumberStrategy) Next(cur *url.URL, page *PageResponse) (*url.URL, error) {
+	if page.Count == 0 || (s.Size > 0 && page.Count < s.Size) {
+		return nil, nil
+	}
+	pageParam := paramOrDefault(s.PageParam, "page")
+	q := cur.Query()
+	n, err := strconv.Atoi(q.Get(pageParam))
+	if err != nil {
+		return nil, fmt.Errorf("invalid page param %q: %w", q.Get(pageParam), err)
+	}
+	q.Set(pageParam, strconv.Itoa(n+1))
+	return withQuery(cur, q), nil
+}
+
+// OffsetLimitStrategy offset/limit 分页
+type OffsetLimitStrategy struct {
+	OffsetParam string // 默认 "offset"
+	LimitParam  string // 默认 "limit"
+	Limit       int    // 每页条目数，为 0 时不发送 LimitParam
+}
+
+func (s *OffsetLimitStrategy) Start(u *url.URL) {
+	q := u.Query()
+	q.Set(paramOrDefault(s.OffsetParam, "offset"), "0")
+	if s.Limit > 0 {
+		q.Set(paramOrDefault(s.LimitParam, "limit"), strconv.Itoa(s.Limit))
+	}
+	u.RawQuery = q.Encode()
+}
+
+func (s *OffsetLimitStrategy) Next(cur *url.URL, page *PageResponse) (*url.URL, error) {
+	if page.Count == 0 || (s.Limit > 0 && page.Count < s.Limit) {
+		return nil, nil
+	}
+	offsetParam := paramOrDefault(s.OffsetParam, "offset")
+	q := cur.Query()
+	offset, err := strconv.Atoi(q.Get(offsetParam))
+	if err != nil {
+		return nil, fmt.Errorf("invalid offset param %q: %w", q.Get(offsetParam), err)
+	}
+	q.Set(offsetParam, strconv.Itoa(offset+page.Count))
+	return withQuery(cur, q), nil
+}
+
+// CursorStrategy 游标分页，游标从响应体中读取
+type CursorStrategy struct {
+	Param string // 请求参数名，默认 "cursor"
+	Field string // 响应体中游标字段路径，以 "." 分隔，如 "meta.next_cursor"
+}
+
+func (s *CursorStrategy) Start(u *url.URL) {}
+
+func (s *CursorStrategy) Next(cur *url.URL, page *PageResponse) (*url.URL, error) {
+	raw, err := lookupJSONPath(page.Body, s.Field)
+	if err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, nil
+	}
+	var cursor any
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return nil, fmt.Errorf("failed to decode cursor: %w", err)
+	}
+	var next string
+	switch v := cursor.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		next = v
+	case float64:
+		next = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return nil, fmt.Errorf("unsupported cursor type %T", cursor)
+	}
+	if next == "" {
+		return nil, nil
+	}
+	q := cur.Query()
+	q.Set(paramOrDefault(s.Param, "cursor"), next)
+	return withQuery(cur, q), nil
+}
+
+// LinkHeaderStrategy 按 RFC 5988 Link 头中 rel="next" 分页
+type LinkHeaderStrategy struct{}
+
+func (s *LinkHeaderStrategy) Start(u *url.URL) {}
+
+func (s *LinkHeaderStrategy) Next(cur *url.URL, page *PageResponse) (*url.URL, error) {
+	for _, header := range page.Header.Values("Link") {
+		for _, link := range splitLinkHeader(header) {
+			target, params, ok := parseLink(link)
+			if !ok || !hasRel(params["rel"], "next") {
+				continue
+			}
+			next, err := cur.Parse(target)
+			if err != nil {
+				return nil, fmt.Errorf("invalid next link %q: %w", target, err)
+			}
+			return next, nil
+		}
+	}
+	return nil, nil
+}
+
+// PaginateOption 分页选项
+type PaginateOption func(*paginateConfig)
+
+type paginateConfig struct {
+	maxPages  int
+	prefetch  bool
+	itemsPath string
+	opts      []RequestOption
+}
+
+// WithMaxPages 设置最多请求的页数，超过后返回 ErrMaxPagesExceeded
+func WithMaxPages(n int) PaginateOption {
+	return func(c *paginateConfig) {
+		c.maxPages = n
+	}
+}
+
+// WithPrefetch 在处理当前页时预取下一页
+func WithPrefetch() PaginateOption {
+	return func(c *paginateConfig) {
+		c.prefetch = true
+	}
+}
+
+// WithItemsPath 设置条目数组在响应体中的路径，默认响应体本身就是数组
+func WithItemsPath(path string) PaginateOption {
+	return func(c *paginateConfig) {
+		c.itemsPath = path
+	}
+}
+
+// WithPageRequestOptions 设置每一页请求使用的 RequestOption
+func WithPageRequestOptions(opts ...RequestOption) PaginateOption {
+	return func(c *paginateConfig) {
+		c.opts = append(c.opts, opts...)
+	}
+}
+
+type pageResult[T any] struct {
+	items []T
+	next  *url.URL
+	err   error
+}
+
+// Paginate 按 strategy 逐页 GET rawURL，并依次返回每一页中的条目
+func Paginate[T any](ctx context.Context, c *HTTPClient, rawURL string, q interface{}, strategy PageStrategy, opts ...PaginateOption) iter.Seq2[T, error] {
+	cfg := &paginateConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return func(yield func(T, error) bool) {
+		var zero T
+		first, err := url.Parse(rawURL)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		if q != nil {
+			v, err := query.Values(q)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			values := first.Query()
+			for key, vals := range v {
+				values[key] = vals
+			}
+			first.RawQuery = values.Encode()
+		}
+		strategy.Start(first)
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		fetch := func(u *url.URL) <-chan pageResult[T] {
+			ch := make(chan pageResult[T], 1)
+			if cfg.prefetch {
+				go func() { ch <- fetchPage[T](ctx, c, u, strategy, cfg) }()
+			} else {
+				ch <- fetchPage[T](ctx, c, u, strategy, cfg)
+			}
+			return ch
+		}
+
+		pending := fetch(first)
+		for pages := 1; ; pages++ {
+			res := <-pending
+			if res.err != nil {
+				yield(zero, res.err)
+				return
+			}
+			limited := cfg.maxPages > 0 && pages >= cfg.maxPages
+			if res.next != nil && !limited && cfg.prefetch {
+				pending = fetch(res.next)
+			}
+
+			for _, item := range res.items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			if res.next == nil {
+				return
+			}
+			if limited {
+				yield(zero, fmt.Errorf("%w: %d", ErrMaxPagesExceeded, cfg.maxPages))
+				return
+			}
+			if !cfg.prefetch {
+				pending = fetch(res.next)
+			}
+		}
+	}
+}
+
+func fetchPage[T any](ctx context.Context, c *HTTPClient, u *url.URL, strategy PageStrategy, cfg *paginateConfig) pageResult[T] {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return pageResult[T]{err: err}
+	}
+	s := newRequestSettings(cfg.opts)
+	s.apply(req)
+	handler := &headerCapture{next: c.config.Response}
+	if s.Response != nil {
+		handler.next = s.Response
+	}
+	s.Response = handler
+
+	var body json.RawMessage
+	if err := c.do(ctx, req, &body, s); err != nil {
+		return pageResult[T]{err: err}
+	}
+
+	var items []T
+	raw, err := lookupJSONPath(body, cfg.itemsPath)
+	if err != nil {
+		return pageResult[T]{err: err}
+	}
+	if raw != nil {
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return pageResult[T]{err: fmt.Errorf("failed to decode page items: %w", err)}
+		}
+	}
+
+	next, err := strategy.Next(u, &PageResponse{
+		Header: handler.header,
+		Body:   body,
+		Count:  len(items),
+	})
+	return pageResult[T]{items: items, next: next, err: err}
+}
+
+// headerCapture 记录响应头后交给下一个 ResponseHandler 处理
+type headerCapture struct {
+	next   ResponseHandler
+	header http.Header
+}
+
+func (h *headerCapture) Handle(resp *http.Response, result interface{}) error {
+	h.header = resp.Header
+	return h.next.Handle(resp, result)
+}
+
+// lookupJSONPath 按 "a.b.c" 路径读取 JSON 对象中的字段，字段不存在时返回 nil
+func lookupJSONPath(raw json.RawMessage, path string) (json.RawMessage, error) {
+	if path == "" {
+		return raw, nil
+	}
+	for _, key := range strings.Split(path, ".") {
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			return nil, fmt.Errorf("failed to lookup %q: %w", path, err)
+		}
+		v, ok := obj[key]
+		if !ok {
+			return nil, nil
+		}
+		raw = v
+	}
+	return raw, nil
+}
+
+func paramOrDefault(param, def string) string {
+	if param == "" {
+		return def
+	}
+	return param
+}
+
+func withQuery(u *url.URL, q url.Values) *url.URL {
+	next := *u
+	next.RawQuery = q.Encode()
+	return &next
+}
+
+// splitLinkHeader 按逗号拆分 Link 头，忽略 <...> 与引号内的逗号
+func splitLinkHeader(header string) []string {
+	var links []string
+	var inURL, inQuote bool
+	start := 0
+	for i, r := range header {
+		switch {
+		case r == '<' && !inQuote:
+			inURL = true
+		case r == '>' && !inQuote:
+			inURL = false
+		case r == '"' && !inURL:
+			inQuote = !inQuote
+		case r == ',' && !inURL && !inQuote:
+			links = append(links, header[start:i])
+			start = i + 1
+		}
+	}
+	return append(links, header[start:])
+}
+
+// parseLink 解析形如 `<url>; rel="next"; title="x"` 的单个链接
+func parseLink(link string) (string, map[string]string, bool) {
+	link = strings.TrimSpace(link)
+	if !strings.HasPrefix(link, "<") {
+		return "", nil, false
+	}
+	end := strings.Index(link, ">")
+	if end < 0 {
+		return "", nil, false
+	}
+	target := link[1:end]
+	params := map[string]string{}
+	for _, param := range strings.Split(link[end+1:], ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok {
+			continue
+		}
+		params[strings.ToLower(strings.TrimSpace(key))] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+	return target, params, true
+}
+
+// hasRel 判断以空格分隔的 rel 列表中是否包含 want
+func hasRel(rel, want string) bool {
+	for _, r := range strings.Fields(rel) {
+		if strings.EqualFold(r, want) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+type pageItem struct {
+	ID int `json:"id"`
+}
+
+// newItemsServer 返回 total 个条目，handler 负责从请求中决定返回哪一段
+func newItemsServer(t *testing.T, handler func(w http.ResponseWriter, r *http.Request, items []pageItem)) *httptest.Server {
+	items := make([]pageItem, 25)
+	for i := range items {
+		items[i] = pageItem{ID: i + 1}
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler(w, r, items)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func collect(t *testing.T, seq func(func(pageItem, error) bool)) []int {
+	var ids []int
+	for item, err := range seq {
+		if err != nil {
+			t.Fatal("Paginate failed. ", err)
+		}
+		ids = append(ids, item.ID)
+	}
+	return ids
+}
+
+func assertSequence(t *testing.T, ids []int, n int) {
+	if len(ids) != n {
+		t.Fatalf("Item count not match. expected=%d, actual=%d", n, len(ids))
+	}
+	for i, id := range ids {
+		if id != i+1 {
+			t.Fatalf("Item order not match. index=%d, id=%d", i, id)
+		}
+	}
+}
+
+func TestPaginatePageNumber(t *testing.T) {
+	srv := newItemsServer(t, func(w http.ResponseWriter, r *http.Request, items []pageItem) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		size, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		start := min((page-1)*size, len(items))
+		end := min(start+size, len(items))
+		_ = json.NewEncoder(w).Encode(items[start:end])
+	})
+
+	client := NewHTTPClient(&Config{})
+	seq := Paginate[pageItem](context.Background(), client, srv.URL, nil, &PageNumberStrategy{SizeParam: "per_page", Size: 10})
+	assertSequence(t, collect(t, seq), 25)
+}
+
+func TestPaginateOffsetLimitWithPrefetch(t *testing.T) {
+	srv := newItemsServer(t, func(w http.ResponseWriter, r *http.Request, items []pageItem) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		start := min(offset, len(items))
+		end := min(start+limit, len(items))
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"items": items[start:end]}})
+	})
+
+	client := NewHTTPClient(&Config{})
+	seq := Paginate[pageItem](context.Background(), client, srv.URL, nil, &OffsetLimitStrategy{Limit: 7},
+		WithItemsPath("data.items"), WithPrefetch())
+	assertSequence(t, collect(t, seq), 25)
+}
+
+func TestPaginateCursor(t *testing.T) {
+	srv := newItemsServer(t, func(w http.ResponseWriter, r *http.Request, items []pageItem) {
+		start, _ := strconv.Atoi(r.URL.Query().Get("after"))
+		end := min(start+10, len(items))
+		next := ""
+		if end < len(items) {
+			next = strconv.Itoa(end)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"items": items[start:end],
+			"meta":  map[string]any{"next": next},
+		})
+	})
+
+	client := NewHTTPClient(&Config{})
+	seq := Paginate[pageItem](context.Background(), client, srv.URL, nil, &CursorStrategy{Param: "after", Field: "meta.next"},
+		WithItemsPath("items"))
+	assertSequence(t, collect(t, seq), 25)
+}
+
+func TestPaginateLinkHeader(t *testing.T) {
+	srv := newItemsServer(t, func(w http.ResponseWriter, r *http.Request, items []pageItem) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("p"))
+		if page == 0 {
+			page = 1
+		}
+		start := min((page-1)*10, len(items))
+		end := min(start+10, len(items))
+		if end < len(items) {
+			w.Header().Set("Link", fmt.Sprintf(`</items?p=1>; rel="first", </items?p=%d>; rel="next"`, page+1))
+		}
+		_ = json.NewEncoder(w).Encode(items[start:end])
+	})
+
+	client := NewHTTPClient(&Config{})
+	seq := Paginate[pageItem](context.Background(), client, srv.URL+"/items", nil, &LinkHeaderStrategy{})
+	assertSequence(t, collect(t, seq), 25)
+}
+
+func TestPaginateMaxPages(t *testing.T) {
+	srv := newItemsServer(t, func(w http.ResponseWriter, r *http.Request, items []pageItem) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		_ = json.NewEncoder(w).Encode(items[(page-1)*5 : page*5])
+	})
+
+	client := NewHTTPClient(&Config{})
+	seq := Paginate[pageItem](context.Background(), client, srv.URL, nil, &PageNumberStrategy{Size: 5}, WithMaxPages(2))
+
+	count := 0
+	var lastErr error
+	for _, err := range seq {
+		if err != nil {
+			lastErr = err
+			break
+		}
+		count++
+	}
+	if count != 10 {
+		t.Fatalf("Item count not match. expected=%d, actual=%d", 10, count)
+	}
+	if !errors.Is(lastErr, ErrMaxPagesExceeded) {
+		t.Fatalf("Expected ErrMaxPagesExceeded, actual=%v", lastErr)
+	}
+}
+
+func TestPaginateStopEarly(t *testing.T) {
+	requests := 0
+	srv := newItemsServer(t, func(w http.ResponseWriter, r *http.Request, items []pageItem) {
+		requests++
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		_ = json.NewEncoder(w).Encode(items[(page-1)*5 : page*5])
+	})
+
+	client := NewHTTPClient(&Config{})
+	seq := Paginate[pageItem](context.Background(), client, srv.URL, nil, &PageNumberStrategy{Size: 5})
+	for item, err := range seq {
+		if err != nil {
+			t.Fatal("Paginate failed. ", err)
+		}
+		if item.ID == 3 {
+			break
+		}
+	}
+	if requests != 1 {
+		t.Fatalf("Request count not match. expected=%d, actual=%d", 1, requests)
+	}
+}
@@ -0,0 +1,310 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+)
+
+// testProxy 本地代理服务器，不论请求的 host 是什么都转发到 target
+type testProxy struct {
+	URL      string
+	target   string
+	requests atomic.Int32
+	// host 最近一次代理请求的目标 host
+	host atomic.Value
+}
+
+func (p *testProxy) record(host string) {
+	p.requests.Add(1)
+	p.host.Store(host)
+}
+
+func (p *testProxy) lastHost() string {
+	h, _ := p.host.Load().(string)
+	return h
+}
+
+// newHTTPProxy 创建 HTTP 正向代理，user 不为空时要求 Basic 认证
+func newHTTPProxy(t *testing.T, target, user, password string) *testProxy {
+	p := &testProxy{target: target}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user != "" {
+			auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password))
+			if r.Header.Get("Proxy-Authorization") != auth {
+				w.WriteHeader(http.StatusProxyAuthRequired)
+				return
+			}
+		}
+		p.record(r.URL.Hostname())
+
+		out := r.Clone(r.Context())
+		out.RequestURI = ""
+		out.URL.Host = target
+		out.Header.Del("Proxy-Authorization")
+		resp, err := http.DefaultTransport.RoundTrip(out)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		w.WriteHeader(resp.StatusCode)
+		_, _ = io.Copy(w, resp.Body)
+	}))
+	t.Cleanup(srv.Close)
+	p.URL = srv.URL
+	return p
+}
+
+// newSocks5Proxy 创建 SOCKS5 代理，只支持 CONNECT 与用户名密码认证（RFC 1928、RFC 1929）
+func newSocks5Proxy(t *testing.T, target, user, password string) *testProxy {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Listen failed. ", err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+	p := &testProxy{URL: "socks5://" + l.Addr().String(), target: target}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go p.serveSocks5(conn, user, password)
+		}
+	}()
+	return p
+}
+
+func (p *testProxy) serveSocks5(conn net.Conn, user, password string) {
+	defer conn.Close()
+	buf := make([]byte, 256)
+	readN := func(n int) []byte {
+		if _, err := io.ReadFull(conn, buf[:n]); err != nil {
+			return nil
+		}
+		return buf[:n]
+	}
+
+	// 协商认证方式
+	head := readN(2)
+	if head == nil || head[0] != 5 || readN(int(head[1])) == nil {
+		return
+	}
+	if user == "" {
+		_, _ = conn.Write([]byte{5, 0})
+	} else {
+		_, _ = conn.Write([]byte{5, 2})
+		ver := readN(2)
+		if ver == nil {
+			return
+		}
+		u := string(readN(int(ver[1])))
+		plen := readN(1)
+		if plen == nil {
+			return
+		}
+		pw := string(readN(int(plen[0])))
+		if u != user || pw != password {
+			_, _ = conn.Write([]byte{1, 1})
+			return
+		}
+		_, _ = conn.Write([]byte{1, 0})
+	}
+
+	// CONNECT 请求
+	req := readN(4)
+	if req == nil || req[1] != 1 {
+		return
+	}
+	var host string
+	switch req[3] {
+	case 1:
+		host = net.IP(readN(4)).String()
+	case 3:
+		n := readN(1)
+		host = string(readN(int(n[0])))
+	case 4:
+		host = net.IP(readN(16)).String()
+	}
+	readN(2)
+	p.record(host)
+
+	upstream, err := net.Dial("tcp", p.target)
+	if err != nil {
+		_, _ = conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer upstream.Close()
+	_, _ = conn.Write([]byte{5, 0, 0, 1, 127, 0, 0, 1, 0, 0})
+
+	go func() {
+		_, _ = io.Copy(upstream, conn)
+	}()
+	_, _ = io.Copy(conn, upstream)
+}
+
+func newProxyTarget(t *testing.T) string {
+	srv := newPathServer(t)
+	srv.Start()
+	return srv.Listener.Addr().String()
+}
+
+func TestProxyConfigHTTP(t *testing.T) {
+	target := newProxyTarget(t)
+	proxy := newHTTPProxy(t, target, "user", "p@ss")
+	u, _ := url.Parse(proxy.URL)
+	u.User = url.UserPassword("user", "p@ss")
+
+	client := NewHTTPClient(&Config{Proxy: &ProxyConfig{URL: u.String()}})
+	var result map[string]string
+	if err := client.Get(context.Background(), "http://api.example.test/users", nil, &result); err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+	if proxy.requests.Load() != 1 || proxy.lastHost() != "api.example.test" || result["path"] != "/users" {
+		t.Fatal("Request not proxied. ", proxy.requests.Load(), result)
+	}
+
+	// 错误的密码
+	u.User = url.UserPassword("user", "wrong")
+	client = NewHTTPClient(&Config{Proxy: &ProxyConfig{URL: u.String()}})
+	if err := client.Get(context.Background(), "http://api.example.test/users", nil, &result); err == nil {
+		t.Fatal("Expected proxy auth error")
+	}
+}
+
+func TestProxyConfigSocks5(t *testing.T) {
+	target := newProxyTarget(t)
+	proxy := newSocks5Proxy(t, target, "user", "secret")
+	u, _ := url.Parse(proxy.URL)
+	u.User = url.UserPassword("user", "secret")
+
+	client := NewHTTPClient(&Config{Proxy: &ProxyConfig{URL: u.String()}})
+	var result map[string]string
+	if err := client.Get(context.Background(), "http://api.example.test/users", nil, &result); err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+	if proxy.requests.Load() != 1 || proxy.lastHost() != "api.example.test" || result["path"] != "/users" {
+		t.Fatal("Request not proxied. ", proxy.requests.Load(), result)
+	}
+}
+
+func TestProxyConfigRules(t *testing.T) {
+	target := newProxyTarget(t)
+	defaultProxy := newHTTPProxy(t, target, "", "")
+	socksProxy := newSocks5Proxy(t, target, "", "")
+
+	client := NewHTTPClient(&Config{Proxy: &ProxyConfig{
+		URL:     defaultProxy.URL,
+		NoProxy: []string{"127.0.0.0/8", ".internal.test"},
+		Rules: []ProxyRule{
+			{Host: "*.socks.test", URL: socksProxy.URL},
+		},
+	}})
+
+	tests := []struct {
+		url   string
+		proxy *testProxy
+	}{
+		{url: "http://api.example.test/", proxy: defaultProxy},
+		{url: "http://api.socks.test/", proxy: socksProxy},
+		{url: "http://" + target + "/", proxy: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			d, s := defaultProxy.requests.Load(), socksProxy.requests.Load()
+			var result map[string]string
+			if err := client.Get(context.Background(), tt.url, nil, &result); err != nil {
+				t.Fatal("Request failed. ", err)
+			}
+			d, s = defaultProxy.requests.Load()-d, socksProxy.requests.Load()-s
+			switch tt.proxy {
+			case defaultProxy:
+				if d != 1 || s != 0 {
+					t.Fatal("Expected default proxy. ", d, s)
+				}
+			case socksProxy:
+				if d != 0 || s != 1 {
+					t.Fatal("Expected socks proxy. ", d, s)
+				}
+			default:
+				if d != 0 || s != 0 {
+					t.Fatal("Expected direct connection. ", d, s)
+				}
+			}
+		})
+	}
+}
+
+func TestProxyMatcher(t *testing.T) {
+	tests := []struct {
+		pattern string
+		host    string
+		port    string
+		match   bool
+	}{
+		{"*", "example.com", "80", true},
+		{"example.com", "example.com", "80", true},
+		{"example.com", "api.example.com", "443", true},
+		{"example.com", "badexample.com", "80", false},
+		{".example.com", "example.com", "80", false},
+		{"*.example.com", "api.example.com", "80", true},
+		{"*.example.com", "example.com", "80", false},
+		{"example.com:8080", "example.com", "80", false},
+		{"example.com:8080", "example.com", "8080", true},
+		{"10.0.0.0/8", "10.1.2.3", "80", true},
+		{"10.0.0.0/8", "11.1.2.3", "80", false},
+		{"10.0.0.0/8", "example.com", "80", false},
+		{"::1", "::1", "80", true},
+		{"fd00::/8", "fd12::1", "80", true},
+		{"192.168.1.1:8080", "192.168.1.1", "8080", true},
+		{"192.168.1.1:8080", "192.168.1.1", "80", false},
+	}
+	for _, tt := range tests {
+		m, err := newProxyMatcher(tt.pattern)
+		if err != nil {
+			t.Fatal("Invalid pattern. ", tt.pattern, err)
+		}
+		if m.match(tt.host, tt.port) != tt.match {
+			t.Fatalf("Match not expected. pattern=%s, host=%s:%s, expected=%v", tt.pattern, tt.host, tt.port, tt.match)
+		}
+	}
+}
+
+func TestProxyConfigFromEnvironment(t *testing.T) {
+	target := newProxyTarget(t)
+	proxy := newHTTPProxy(t, target, "", "")
+	t.Setenv("HTTP_PROXY", proxy.URL)
+	t.Setenv("NO_PROXY", "skip.example.test")
+
+	client := NewHTTPClient(&Config{Proxy: &ProxyConfig{FromEnvironment: true}})
+	var result map[string]string
+	if err := client.Get(context.Background(), "http://api.example.test/", nil, &result); err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+	if proxy.requests.Load() != 1 {
+		t.Fatal("Environment proxy not used")
+	}
+
+	// NO_PROXY 中的地址直连，无法解析
+	if err := client.Get(context.Background(), "http://skip.example.test/", nil, &result); err == nil {
+		t.Fatal("Expected direct connection error")
+	}
+	if proxy.requests.Load() != 1 {
+		t.Fatal("NO_PROXY not applied")
+	}
+}
+
+func TestProxyConfigInvalid(t *testing.T) {
+	client := NewHTTPClient(&Config{Proxy: &ProxyConfig{URL: "ftp://127.0.0.1:21"}})
+	err := client.Get(context.Background(), "http://api.example.test/", nil, nil)
+	if err == nil {
+		t.Fatal("Expected unsupported proxy scheme error. ", err)
+	}
+}
@@ -2,10 +2,15 @@ package httpclient
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 )
 
+// ErrResponseTooLarge 响应体超过最大字节数
+var ErrResponseTooLarge = errors.New("response body too large")
+
 type ResponseHandler interface {
 	Handle(*http.Response, interface{}) error
 }
@@ -19,14 +24,19 @@ type CodeWrapperResponse struct {
 }
 
 // DirectResponseHandler handle response directly
-type DirectResponseHandler struct{}
+type DirectResponseHandler struct {
+	// Strict 响应中包含 result 中不存在的字段时返回错误
+	Strict bool
+	// UseNumber 解码到 interface{} 时使用 json.Number，避免 int64 ID 丢失精度
+	UseNumber bool
+}
 
 func (d *DirectResponseHandler) Handle(resp *http.Response, result interface{}) error {
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+	if err := newDecoder(resp.Body, d.Strict, d.UseNumber).Decode(result); err != nil {
 		return fmt.Errorf("failed to decode response: %w", err)
 	}
 
@@ -34,17 +44,21 @@ func (d *DirectResponseHandler) Handle(resp *http.Response, result interface{})
 }
 
 // CodeWrapperResponseHandler handle response with code wrapper
-type CodeWrapperResponseHandler struct{}
+type CodeWrapperResponseHandler struct {
+	// Strict 响应中包含 CodeWrapperResponse 或 result 中不存在的字段时返回错误
+	Strict bool
+	// UseNumber 解码到 interface{} 时使用 json.Number，避免 int64 ID 丢失精度
+	UseNumber bool
+}
 
 func (c *CodeWrapperResponseHandler) Handle(resp *http.Response, result interface{}) error {
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
-	defer resp.Body.Close()
 
 	var codeWrapper CodeWrapperResponse
 	codeWrapper.Data = result
-	if err := json.NewDecoder(resp.Body).Decode(&codeWrapper); err != nil {
+	if err := newDecoder(resp.Body, c.Strict, c.UseNumber).Decode(&codeWrapper); err != nil {
 		return fmt.Errorf("failed to decode response: %w", err)
 	}
 
@@ -54,3 +68,50 @@ func (c *CodeWrapperResponseHandler) Handle(resp *http.Response, result interfac
 
 	return nil
 }
+
+func newDecoder(r io.Reader, strict, useNumber bool) *json.Decoder {
+	decoder := json.NewDecoder(r)
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+	if useNumber {
+		decoder.UseNumber()
+	}
+	return decoder
+}
+
+// maxDrainSize 关闭响应体前最多丢弃的字节数，超过时放弃复用连接
+const maxDrainSize = 64 << 10
+
+// drainBody 读取并丢弃剩余的响应体，使 keep-alive 连接可以被复用
+func drainBody(body io.ReadCloser) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(body, maxDrainSize))
+	_ = body.Close()
+}
+
+// limitedBody 读取超过 limit 字节时返回 ErrResponseTooLarge
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	limit     int64
+}
+
+func newLimitedBody(body io.ReadCloser, limit int64) *limitedBody {
+	return &limitedBody{ReadCloser: body, remaining: limit, limit: limit}
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, fmt.Errorf("%w: limit=%d", ErrResponseTooLarge, b.limit)
+	}
+	// 多读一个字节用于判断是否超过上限
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return n + int(b.remaining), fmt.Errorf("%w: limit=%d", ErrResponseTooLarge, b.limit)
+	}
+	return n, err
+}
@@ -0,0 +1,109 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"strings"
+	"testing"
+)
+
+func newJSONServer(t *testing.T, body string) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestConfigMaxResponseSize(t *testing.T) {
+	srv := newJSONServer(t, `{"name":"`+strings.Repeat("a", 100)+`"}`)
+	client := NewHTTPClient(&Config{MaxResponseSize: 64})
+
+	var result map[string]string
+	err := client.Get(context.Background(), srv.URL, nil, &result)
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Fatalf("Expected ErrResponseTooLarge, actual=%v", err)
+	}
+
+	// RequestOption 覆盖 Config
+	if err := client.Get(context.Background(), srv.URL, nil, &result, WithMaxResponseSize(1024)); err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+}
+
+func TestDirectResponseHandlerStrict(t *testing.T) {
+	srv := newJSONServer(t, `{"id":1,"extra":true}`)
+
+	var result struct {
+		ID int `json:"id"`
+	}
+	client := NewHTTPClient(&Config{})
+	if err := client.Get(context.Background(), srv.URL, nil, &result); err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+
+	client = NewHTTPClient(&Config{Response: &DirectResponseHandler{Strict: true}})
+	if err := client.Get(context.Background(), srv.URL, nil, &result); err == nil {
+		t.Fatal("Expected unknown field error")
+	}
+}
+
+func TestResponseHandlerUseNumber(t *testing.T) {
+	srv := newJSONServer(t, `{"code":0,"msg":"ok","data":{"id":9007199254740993}}`)
+
+	tests := []struct {
+		name    string
+		handler ResponseHandler
+		data    func(map[string]any) any
+	}{
+		{
+			name:    "direct",
+			handler: &DirectResponseHandler{UseNumber: true},
+			data:    func(m map[string]any) any { return m["data"].(map[string]any)["id"] },
+		},
+		{
+			name:    "code wrapper",
+			handler: &CodeWrapperResponseHandler{UseNumber: true},
+			data:    func(m map[string]any) any { return m["id"] },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewHTTPClient(&Config{Response: tt.handler})
+			var result map[string]any
+			if err := client.Get(context.Background(), srv.URL, nil, &result); err != nil {
+				t.Fatal("Request failed. ", err)
+			}
+			id, ok := tt.data(result).(json.Number)
+			if !ok || id.String() != "9007199254740993" {
+				t.Fatal("ID lost precision. ", tt.data(result))
+			}
+		})
+	}
+}
+
+func TestResponseBodyDrained(t *testing.T) {
+	// JSON 之后的空白不会被 Decoder 读取，需要丢弃后连接才能复用
+	srv := newJSONServer(t, `{"id":1}`+strings.Repeat(" ", 32<<10))
+	client := NewHTTPClient(&Config{})
+
+	reused := false
+	ctx := httptrace.WithClientTrace(context.Background(), &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			reused = info.Reused
+		},
+	})
+	var result map[string]int
+	for i := 0; i < 2; i++ {
+		if err := client.Get(ctx, srv.URL, nil, &result); err != nil {
+			t.Fatal("Request failed. ", err)
+		}
+	}
+	if !reused {
+		t.Fatal("Connection should be reused")
+	}
+}
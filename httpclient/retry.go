@@ -0,0 +1,79 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy 请求重试策略，只有可重放的请求体才会被重试
+type RetryPolicy struct {
+	// MaxAttempts 最大尝试次数（包含第一次），小于等于 1 时不重试
+	MaxAttempts int
+	// Backoff 首次重试前的等待时间，之后每次翻倍
+	Backoff time.Duration
+	// MaxBackoff 等待时间上限，为 0 时不限制
+	MaxBackoff time.Duration
+	// ShouldRetry 判断是否需要重试，默认对网络错误以及 429、502、503、504 重试。
+	// resp 与 err 有且只有一个不为 nil，网络错误时 resp 为 nil
+	ShouldRetry func(resp *http.Response, err error) bool
+}
+
+func (p *RetryPolicy) attempts() int {
+	if p == nil || p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+func (p *RetryPolicy) shouldRetry(resp *http.Response, err error) bool {
+	if p.ShouldRetry != nil {
+		return p.ShouldRetry(resp, err)
+	}
+	return DefaultShouldRetry(resp, err)
+}
+
+// wait 等待第 attempt 次尝试后的退避时间
+func (p *RetryPolicy) wait(ctx context.Context, attempt int) error {
+	backoff := p.Backoff
+	for i := 1; i < attempt && backoff > 0; i++ {
+		if backoff > math.MaxInt64/2 {
+			// 继续翻倍会溢出为负数
+			backoff = math.MaxInt64
+			break
+		}
+		backoff *= 2
+		if p.MaxBackoff > 0 && backoff >= p.MaxBackoff {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+	if backoff <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// DefaultShouldRetry 对网络错误以及 429、502、503、504 状态码重试
+func DefaultShouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package httpclient
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// redacted 脱敏后的值
+const redacted = "REDACTED"
+
+// DefaultRedactParams 默认脱敏的查询参数，不区分大小写
+var DefaultRedactParams = []string{
+	"access_token", "api_key", "apikey", "auth", "key", "password",
+	"secret", "sig", "signature", "token",
+}
+
+// idSegment 看起来像 ID 的路径段：纯数字、UUID 或长十六进制串
+var idSegment = regexp.MustCompile(`^(\d+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|[0-9a-fA-F]{16,})$`)
+
+// URLSanitizer 观测数据（日志、指标）中 URL 的脱敏与路径归一化设置，零值即默认行为：
+// 脱敏 DefaultRedactParams 中的查询参数与 userinfo
+type URLSanitizer struct {
+	// RedactParams 额外需要脱敏的查询参数，不区分大小写
+	RedactParams []string
+	// SkipDefaultParams 不脱敏 DefaultRedactParams
+	SkipDefaultParams bool
+	// KeepUserinfo 保留 URL 中的用户名密码
+	KeepUserinfo bool
+	// Routes 路径模板，如 "/users/{id}/orders"，匹配的路径替换为模板；
+	// 未通过 WithRoute 设置路由名称时，匹配的模板同时作为 RequestEvent.Route
+	Routes []string
+	// NormalizeIDs 未匹配 Routes 时将数字、UUID 等路径段替换为 {id}
+	NormalizeIDs bool
+}
+
+// Sanitize 返回脱敏后的 URL，以及匹配的路径模板
+func (s *URLSanitizer) Sanitize(u *url.URL) (string, string) {
+	out := *u
+	if out.User != nil && !s.KeepUserinfo {
+		out.User = url.User(redacted)
+	}
+	// 模板中的 {id} 不转义，便于阅读
+	path := out.EscapedPath()
+	route := s.matchRoute(out.Path)
+	switch {
+	case route != "":
+		path = route
+	case s.NormalizeIDs:
+		path = normalizeIDs(path)
+	}
+	query := out.RawQuery
+	out.Path, out.RawPath, out.RawQuery, out.Fragment = "", "", "", ""
+
+	sanitized := out.String() + path
+	if query != "" {
+		sanitized += "?" + s.redactQuery(query)
+	}
+	return sanitized, route
+}
+
+// redactQuery 保持参数顺序，只替换需要脱敏的值
+func (s *URLSanitizer) redactQuery(rawQuery string) string {
+	parts := strings.Split(rawQuery, "&")
+	for i, part := range parts {
+		key, _, _ := strings.Cut(part, "=")
+		if name, err := url.QueryUnescape(key); err == nil && s.shouldRedact(name) {
+			parts[i] = key + "=" + redacted
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func (s *URLSanitizer) shouldRedact(name string) bool {
+	for _, p := range s.RedactParams {
+		if strings.EqualFold(p, name) {
+			return true
+		}
+	}
+	if s.SkipDefaultParams {
+		return false
+	}
+	for _, p := range DefaultRedactParams {
+		if strings.EqualFold(p, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRoute 返回第一个与 path 匹配的模板，{name} 匹配任意单个路径段
+func (s *URLSanitizer) matchRoute(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for _, route := range s.Routes {
+		tmpl := strings.Split(strings.Trim(route, "/"), "/")
+		if len(tmpl) != len(segments) {
+			continue
+		}
+		matched := true
+		for i, t := range tmpl {
+			if strings.HasPrefix(t, "{") && strings.HasSuffix(t, "}") {
+				if segments[i] == "" {
+					matched = false
+					break
+				}
+				continue
+			}
+			if t != segments[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return route
+		}
+	}
+	return ""
+}
+
+func normalizeIDs(path string) string {
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if idSegment.MatchString(seg) {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// sanitizeError 替换 *url.Error 中的 URL，其余错误原样返回
+func sanitizeError(err error, sanitized string) error {
+	urlErr, ok := err.(*url.Error)
+	if !ok {
+		return err
+	}
+	return &url.Error{Op: urlErr.Op, URL: sanitized, Err: urlErr.Err}
+}
+
+// newURLSanitizer 返回 Config 中的 URLSanitizer，查询参数形式的 AuthAPIKey 自动加入脱敏列表
+func newURLSanitizer(config *Config) *URLSanitizer {
+	s := &URLSanitizer{}
+	if config.Sanitizer != nil {
+		*s = *config.Sanitizer
+	}
+	var name string
+	switch a := config.Auth.(type) {
+	case *AuthAPIKey:
+		if a.In == "query" {
+			name = a.Name
+		}
+	case *AuthAPIKeySource:
+		if a.In == "query" {
+			name = a.Name
+		}
+	}
+	if name != "" {
+		s.RedactParams = append(append([]string(nil), s.RedactParams...), name)
+	}
+	return s
+}
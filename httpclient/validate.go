@@ -0,0 +1,86 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Validator 结果类型实现该接口并开启 ValidateResult 时，解码后调用 Validate
+type Validator interface {
+	Validate() error
+}
+
+// Violation 一处不符合 schema 的位置，Path 为 JSON Pointer，根节点为空字符串
+type Violation struct {
+	Path    string
+	Message string
+}
+
+// ValidationError 响应校验失败，Violations 列出所有违反的路径
+type ValidationError struct {
+	Violations []Violation
+	// err Validate 返回的原始错误
+	err error
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		path := v.Path
+		if path == "" {
+			path = "(root)"
+		}
+		parts = append(parts, path+": "+v.Message)
+	}
+	return "response validation failed: " + strings.Join(parts, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.err
+}
+
+// WithSchema 使用 JSON Schema 校验 2xx 响应的原始响应体，校验失败时不会解码
+func WithSchema(schema *JSONSchema) RequestOption {
+	return func(s *RequestSettings) {
+		s.Schema = schema
+	}
+}
+
+// WithValidateResult 解码后对实现了 Validator 的结果调用 Validate
+func WithValidateResult() RequestOption {
+	return func(s *RequestSettings) {
+		s.ValidateResult = true
+	}
+}
+
+// validateResult 调用 result 的 Validate 方法，返回的错误转为 *ValidationError
+func validateResult(result interface{}) error {
+	v, ok := result.(Validator)
+	if !ok {
+		return nil
+	}
+	err := v.Validate()
+	if err == nil {
+		return nil
+	}
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		return err
+	}
+	// errors.Join 返回的多个错误分别作为一处违反
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+	verr = &ValidationError{err: err}
+	for _, e := range errs {
+		verr.Violations = append(verr.Violations, Violation{Message: e.Error()})
+	}
+	return verr
+}
+
+// formatViolation 生成 Violation，Message 按 format 格式化
+func formatViolation(path, format string, args ...interface{}) Violation {
+	return Violation{Path: path, Message: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,98 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type validatedUser struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func (u *validatedUser) Validate() error {
+	var errs []error
+	if u.ID <= 0 {
+		errs = append(errs, errors.New("id must be positive"))
+	}
+	if u.Name == "" {
+		errs = append(errs, errors.New("name is required"))
+	}
+	return errors.Join(errs...)
+}
+
+func newValidateServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/renamed":
+			// 上游把 name 改成了 username
+			_, _ = w.Write([]byte(`{"id": 1, "username": "gopkg"}`))
+		case "/empty":
+			_, _ = w.Write([]byte(`{}`))
+		case "/error":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"message": "bad request"}`))
+		default:
+			_, _ = w.Write([]byte(`{"id": 1, "name": "gopkg"}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestWithSchema(t *testing.T) {
+	srv := newValidateServer(t)
+	schema := MustParseJSONSchema(`{"type": "object", "required": ["id", "name"], "properties": {"id": {"type": "integer"}, "name": {"type": "string"}}}`)
+	client := NewHTTPClient(&Config{})
+
+	var user validatedUser
+	if err := client.Get(context.Background(), srv.URL, nil, &user, WithSchema(schema)); err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+	if user.Name != "gopkg" {
+		t.Fatal("Result not match. ", user)
+	}
+
+	err := client.Get(context.Background(), srv.URL+"/renamed", nil, &validatedUser{}, WithSchema(schema))
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Violations) != 1 || verr.Violations[0].Path != "/name" {
+		t.Fatal("Violations not match. ", err)
+	}
+
+	// 错误响应不做 schema 校验
+	err = client.Get(context.Background(), srv.URL+"/error", nil, &validatedUser{}, WithSchema(schema))
+	if err == nil || errors.As(err, &verr) {
+		t.Fatal("Expected status code error. ", err)
+	}
+}
+
+func TestValidateResult(t *testing.T) {
+	srv := newValidateServer(t)
+	client := NewHTTPClient(&Config{ValidateResult: true})
+
+	var user validatedUser
+	if err := client.Get(context.Background(), srv.URL, nil, &user); err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+
+	err := client.Get(context.Background(), srv.URL+"/empty", nil, &validatedUser{})
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Violations) != 2 {
+		t.Fatal("Violations not match. ", err)
+	}
+	if verr.Error() != "response validation failed: (root): id must be positive; (root): name is required" {
+		t.Fatal("Error message not match. ", verr.Error())
+	}
+
+	// 未开启时不调用 Validate
+	client = NewHTTPClient(&Config{})
+	if err := client.Get(context.Background(), srv.URL+"/empty", nil, &validatedUser{}); err != nil {
+		t.Fatal("Request failed. ", err)
+	}
+	if err := client.Get(context.Background(), srv.URL+"/empty", nil, &validatedUser{}, WithValidateResult()); err == nil {
+		t.Fatal("Expected validation error")
+	}
+}
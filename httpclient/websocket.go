@@ -0,0 +1,390 @@
+package httpclient
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrWebSocketHandshake 握手失败，如状态码不是 101 或 Sec-WebSocket-Accept 不正确
+	ErrWebSocketHandshake = errors.New("websocket: bad handshake")
+	// ErrWebSocketClosed 连接已被本端关闭
+	ErrWebSocketClosed = errors.New("websocket: connection closed")
+	// ErrWebSocketPongTimeout 发送 ping 后没有按时收到 pong
+	ErrWebSocketPongTimeout = errors.New("websocket: pong timeout")
+)
+
+// wsGUID RFC 6455 1.3 中用于计算 Sec-WebSocket-Accept 的 GUID
+const wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocketConfig WebSocket 连接设置
+type WebSocketConfig struct {
+	// Subprotocols 通过 Sec-WebSocket-Protocol 协商的子协议
+	Subprotocols []string
+	// Compression 协商 permessage-deflate（RFC 7692），只支持 no_context_takeover
+	Compression bool
+	// PingInterval 发送 ping 的间隔，为 0 时不发送
+	PingInterval time.Duration
+	// PongTimeout 等待 pong 的时间，超时后断开连接，默认与 PingInterval 相同
+	PongTimeout time.Duration
+	// FragmentSize 发送消息时每帧的最大字节数，为 0 时不分片
+	FragmentSize int
+	// MaxMessageSize 接收消息的最大字节数，默认 32MB
+	MaxMessageSize int64
+	// Reconnect 连接异常断开后的重连策略，为 nil 时不重连。每次断开后的重连与一次请求的重试相同：
+	// 最多握手 MaxAttempts 次，小于等于 1 时只握手一次，第 n 次握手前等待第 n 次的退避时间
+	Reconnect *RetryPolicy
+	// OnConnect 每次建立连接后调用，包括第一次连接，可用于重新订阅
+	OnConnect func(ws *WebSocket)
+}
+
+// WebSocket 通过 HTTPClient 建立的 WebSocket 连接，握手请求使用 Config 中的 Auth、代理与观测设置。
+// 可以有一个 goroutine 读取、多个 goroutine 同时写入。
+type WebSocket struct {
+	client *HTTPClient
+	http   *http.Client
+	url    string
+	config WebSocketConfig
+	opts   []RequestOption
+
+	// ctx 在 Close 时取消，用于中断重连
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	conn   *wsConn
+	closed bool
+	// dialMu 保证同一时刻只有一个 goroutine 重连
+	dialMu sync.Mutex
+}
+
+// DialWebSocket 建立 WebSocket 连接，url 可以是 ws、wss、http 或 https 地址，没有 Host 时拼接 BaseURL。
+// opts 用于设置握手请求，WithTimeout 为握手超时时间。
+func (c *HTTPClient) DialWebSocket(ctx context.Context, url string, config *WebSocketConfig, opts ...RequestOption) (*WebSocket, error) {
+	if config == nil {
+		config = &WebSocketConfig{}
+	}
+	ws := &WebSocket{
+		client: c,
+		// 不使用 http.Client.Timeout，否则升级后的连接不可写
+		http: &http.Client{
+			Jar:       c.client.Jar,
+			Transport: c.client.Transport,
+		},
+		url:    url,
+		config: *config,
+		opts:   opts,
+	}
+	if ws.config.MaxMessageSize <= 0 {
+		ws.config.MaxMessageSize = 32 << 20
+	}
+	if ws.config.PongTimeout <= 0 {
+		ws.config.PongTimeout = ws.config.PingInterval
+	}
+
+	conn, _, err := ws.dial(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
+	ws.conn = conn
+	ws.ctx, ws.cancel = context.WithCancel(context.Background())
+	if ws.config.OnConnect != nil {
+		ws.config.OnConnect(ws)
+	}
+	return ws, nil
+}
+
+// dial 发送握手请求，attempt 为第几次连接。状态码不是 101 时同时返回已关闭响应体的握手响应
+func (ws *WebSocket) dial(ctx context.Context, attempt int) (*wsConn, *http.Response, error) {
+	c := ws.client
+	if c.initErr != nil {
+		return nil, nil, c.initErr
+	}
+	s := newRequestSettings(ws.opts)
+	timeout := c.config.Timeout
+	if s.Timeout > 0 {
+		timeout = s.Timeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	ctx = context.WithValue(ctx, settingsKey, s)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ws.url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.URL = resolveURL(c.baseURL, req.URL)
+	switch req.URL.Scheme {
+	case "ws":
+		req.URL.Scheme = "http"
+	case "wss":
+		req.URL.Scheme = "https"
+	case "http", "https":
+	default:
+		return nil, nil, fmt.Errorf("unsupported websocket scheme: %s", req.URL.Scheme)
+	}
+	req.Host = req.URL.Host
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if len(ws.config.Subprotocols) > 0 {
+		req.Header.Set("Sec-WebSocket-Protocol", strings.Join(ws.config.Subprotocols, ", "))
+	}
+	if ws.config.Compression {
+		req.Header.Set("Sec-WebSocket-Extensions", "permessage-deflate; client_no_context_takeover; server_no_context_takeover")
+	}
+	s.apply(req)
+	if err := c.bindAuth(s); err != nil {
+		return nil, nil, err
+	}
+	if s.auth != nil {
+		s.auth.Apply(req)
+	}
+
+	var upgraded io.ReadWriteCloser
+	resp, err := c.observe(ctx, req, attempt, func(r *http.Request) (*http.Response, error) {
+		resp, err := ws.http.Do(r)
+		if err != nil {
+			return nil, err
+		}
+		rwc, ok := resp.Body.(io.ReadWriteCloser)
+		if resp.StatusCode != http.StatusSwitchingProtocols || !ok {
+			return resp, nil
+		}
+		// 升级后的连接交给 wsConn，观测数据只记录握手
+		upgraded = rwc
+		handshake := *resp
+		handshake.Body = http.NoBody
+		return &handshake, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	drainBody(resp.Body)
+	if upgraded == nil {
+		return nil, resp, fmt.Errorf("%w: unexpected status code: %d", ErrWebSocketHandshake, resp.StatusCode)
+	}
+
+	compress, err := ws.checkHandshake(resp, key)
+	if err != nil {
+		_ = upgraded.Close()
+		return nil, nil, err
+	}
+	conn := newWSConn(upgraded, bufio.NewReader(upgraded), false, compress, &ws.config)
+	conn.subprotocol = resp.Header.Get("Sec-WebSocket-Protocol")
+	return conn, nil, nil
+}
+
+// checkHandshake 校验握手响应，返回是否启用 permessage-deflate
+func (ws *WebSocket) checkHandshake(resp *http.Response, key string) (bool, error) {
+	if !strings.EqualFold(resp.Header.Get("Upgrade"), "websocket") ||
+		!headerContainsToken(resp.Header, "Connection", "upgrade") {
+		return false, fmt.Errorf("%w: missing upgrade headers", ErrWebSocketHandshake)
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != wsAcceptKey(key) {
+		return false, fmt.Errorf("%w: invalid Sec-WebSocket-Accept", ErrWebSocketHandshake)
+	}
+
+	if protocol := resp.Header.Get("Sec-WebSocket-Protocol"); protocol != "" {
+		found := false
+		for _, p := range ws.config.Subprotocols {
+			found = found || p == protocol
+		}
+		if !found {
+			return false, fmt.Errorf("%w: unexpected subprotocol %q", ErrWebSocketHandshake, protocol)
+		}
+	}
+
+	compress := false
+	for _, ext := range resp.Header.Values("Sec-WebSocket-Extensions") {
+		for _, ext := range strings.Split(ext, ",") {
+			params := strings.Split(ext, ";")
+			name := strings.TrimSpace(params[0])
+			if name == "" {
+				continue
+			}
+			if name != "permessage-deflate" || !ws.config.Compression {
+				return false, fmt.Errorf("%w: unexpected extension %q", ErrWebSocketHandshake, name)
+			}
+			noContextTakeover := false
+			for _, p := range params[1:] {
+				noContextTakeover = noContextTakeover || strings.TrimSpace(p) == "server_no_context_takeover"
+			}
+			if !noContextTakeover {
+				return false, fmt.Errorf("%w: server context takeover is not supported", ErrWebSocketHandshake)
+			}
+			compress = true
+		}
+	}
+	return compress, nil
+}
+
+// ReadMessage 读取下一条消息。连接异常断开且设置了 Reconnect 时自动重连，
+// 对端正常关闭（1000）时返回 *CloseError。
+func (ws *WebSocket) ReadMessage(ctx context.Context) (MessageType, []byte, error) {
+	for {
+		conn, err := ws.current(ctx)
+		if err != nil {
+			return 0, nil, err
+		}
+		select {
+		case msg, ok := <-conn.messages:
+			if ok {
+				return msg.typ, msg.data, nil
+			}
+			// 连接已断开，由 current 决定是否重连
+		case <-ctx.Done():
+			return 0, nil, ctx.Err()
+		}
+	}
+}
+
+// WriteMessage 发送一条消息，ctx 只用于等待重连
+func (ws *WebSocket) WriteMessage(ctx context.Context, typ MessageType, data []byte) error {
+	conn, err := ws.current(ctx)
+	if err != nil {
+		return err
+	}
+	return conn.writeMessage(typ, data)
+}
+
+// Subprotocol 返回当前连接协商的子协议
+func (ws *WebSocket) Subprotocol() string {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	return ws.conn.subprotocol
+}
+
+// Close 发送关闭帧并等待对端确认后断开连接，之后不再重连
+func (ws *WebSocket) Close() error {
+	ws.cancel()
+	ws.mu.Lock()
+	if ws.closed {
+		ws.mu.Unlock()
+		return nil
+	}
+	ws.closed = true
+	conn := ws.conn
+	ws.mu.Unlock()
+	return conn.close(CloseNormalClosure, "")
+}
+
+// current 返回可用的连接，连接已断开时按 Reconnect 重连
+func (ws *WebSocket) current(ctx context.Context) (*wsConn, error) {
+	conn, reconnected, err := ws.reconnect(ctx)
+	if reconnected && ws.config.OnConnect != nil {
+		ws.config.OnConnect(ws)
+	}
+	return conn, err
+}
+
+func (ws *WebSocket) reconnect(ctx context.Context) (*wsConn, bool, error) {
+	ws.mu.Lock()
+	conn, closed := ws.conn, ws.closed
+	ws.mu.Unlock()
+	if closed {
+		return nil, false, ErrWebSocketClosed
+	}
+	if !conn.isDone() {
+		return conn, false, nil
+	}
+
+	ws.dialMu.Lock()
+	defer ws.dialMu.Unlock()
+	ws.mu.Lock()
+	if ws.conn != conn {
+		// 其他 goroutine 已经重连
+		conn = ws.conn
+		ws.mu.Unlock()
+		return conn, false, nil
+	}
+	ws.mu.Unlock()
+
+	err := conn.error()
+	policy := ws.config.Reconnect
+	var closeErr *CloseError
+	if policy == nil || (errors.As(err, &closeErr) && closeErr.Code == CloseNormalClosure) {
+		return nil, false, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(ws.ctx, cancel)
+	defer stop()
+	for attempt := 1; attempt <= policy.attempts(); attempt++ {
+		if werr := policy.wait(ctx, attempt); werr != nil {
+			if ws.ctx.Err() != nil {
+				return nil, false, ErrWebSocketClosed
+			}
+			return nil, false, werr
+		}
+		next, resp, derr := ws.dial(ctx, attempt+1)
+		if derr == nil {
+			ws.mu.Lock()
+			defer ws.mu.Unlock()
+			if ws.closed {
+				_ = next.close(CloseNormalClosure, "")
+				return nil, false, ErrWebSocketClosed
+			}
+			ws.conn = next
+			return next, true, nil
+		}
+		err = derr
+		if !ws.shouldReconnect(policy, resp, err) {
+			break
+		}
+	}
+	return nil, false, err
+}
+
+// shouldReconnect 判断握手失败后是否继续重连。收到非 101 响应时与请求重试一样按响应判断，
+// 响应校验失败时不重连，其他错误按网络错误判断
+func (ws *WebSocket) shouldReconnect(policy *RetryPolicy, resp *http.Response, err error) bool {
+	switch {
+	case resp != nil:
+		return policy.shouldRetry(resp, nil)
+	case errors.Is(err, ErrWebSocketHandshake):
+		return false
+	default:
+		return policy.shouldRetry(nil, err)
+	}
+}
+
+// wsAcceptKey 计算 Sec-WebSocket-Accept
+func wsAcceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + wsGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// headerContainsToken 判断逗号分隔的请求头中是否包含 token，不区分大小写
+func headerContainsToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,531 @@
+package httpclient
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// MessageType WebSocket 消息类型，与 RFC 6455 的 opcode 相同
+type MessageType int
+
+const (
+	TextMessage   MessageType = 1
+	BinaryMessage MessageType = 2
+)
+
+// RFC 6455 5.2 中的 opcode
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xa
+)
+
+// RFC 6455 7.4.1 中的关闭码
+const (
+	CloseNormalClosure  = 1000
+	CloseGoingAway      = 1001
+	CloseProtocolError  = 1002
+	CloseNoStatus       = 1005
+	CloseInvalidPayload = 1007
+	CloseMessageTooBig  = 1009
+	CloseInternalError  = 1011
+)
+
+const (
+	// wsCloseTimeout 发送关闭帧后等待对端回复的时间
+	wsCloseTimeout = 5 * time.Second
+	// wsMessageQueueLength 已读取但未被 ReadMessage 取走的消息数量上限
+	wsMessageQueueLength = 16
+)
+
+var (
+	// ErrWebSocketProtocol 对端发送的帧不符合 RFC 6455
+	ErrWebSocketProtocol = errors.New("websocket: protocol error")
+	// ErrWebSocketMessageTooLarge 接收的消息超过 MaxMessageSize
+	ErrWebSocketMessageTooLarge = errors.New("websocket: message too large")
+)
+
+// CloseError 对端发送的关闭帧
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("websocket: close %d", e.Code)
+	}
+	return fmt.Sprintf("websocket: close %d: %s", e.Code, e.Reason)
+}
+
+// wsFailure 需要以指定关闭码结束连接的错误
+type wsFailure struct {
+	code int
+	err  error
+}
+
+func (e *wsFailure) Error() string { return e.err.Error() }
+
+func (e *wsFailure) Unwrap() error { return e.err }
+
+func wsProtocolError(format string, args ...interface{}) error {
+	return &wsFailure{code: CloseProtocolError, err: fmt.Errorf("%w: %s", ErrWebSocketProtocol, fmt.Sprintf(format, args...))}
+}
+
+type wsMessage struct {
+	typ  MessageType
+	data []byte
+}
+
+type wsFrame struct {
+	fin     bool
+	rsv1    bool
+	opcode  byte
+	payload []byte
+}
+
+// wsConn 单个 WebSocket 连接，后台 goroutine 读取帧、回复 ping 并将完整消息放入 messages
+type wsConn struct {
+	rwc io.ReadWriteCloser
+	br  *bufio.Reader
+	// server 为 true 时发送的帧不加掩码，并要求收到的帧带掩码
+	server       bool
+	compress     bool
+	fragmentSize int
+	maxSize      int64
+	subprotocol  string
+
+	// messages 读取到的消息，连接断开后关闭
+	messages chan wsMessage
+	pong     chan struct{}
+
+	wmu       sync.Mutex
+	closeSent bool
+	// closing 发送关闭帧后关闭，之后收到的消息被丢弃
+	closing chan struct{}
+
+	failOnce sync.Once
+	done     chan struct{}
+	err      error
+}
+
+func newWSConn(rwc io.ReadWriteCloser, br *bufio.Reader, server, compress bool, config *WebSocketConfig) *wsConn {
+	c := &wsConn{
+		rwc:          rwc,
+		br:           br,
+		server:       server,
+		compress:     compress,
+		fragmentSize: config.FragmentSize,
+		maxSize:      config.MaxMessageSize,
+		messages:     make(chan wsMessage, wsMessageQueueLength),
+		pong:         make(chan struct{}, 1),
+		closing:      make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	if c.maxSize <= 0 {
+		c.maxSize = 32 << 20
+	}
+	go c.readLoop()
+	if config.PingInterval > 0 {
+		timeout := config.PongTimeout
+		if timeout <= 0 {
+			timeout = config.PingInterval
+		}
+		go c.keepalive(config.PingInterval, timeout)
+	}
+	return c
+}
+
+func (c *wsConn) readLoop() {
+	defer close(c.messages)
+	for {
+		typ, data, err := c.readMessage()
+		if err != nil {
+			var f *wsFailure
+			if errors.As(err, &f) {
+				_ = c.writeClose(f.code, "")
+			}
+			c.fail(err)
+			return
+		}
+		select {
+		case c.messages <- wsMessage{typ: typ, data: data}:
+		case <-c.closing:
+			// 关闭中，丢弃消息以便继续读取对端的关闭帧
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// keepalive 定时发送 ping，timeout 内没有收到 pong 时断开连接
+func (c *wsConn) keepalive(interval, timeout time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+		select {
+		case <-c.pong:
+		default:
+		}
+		if err := c.writeControl(wsOpPing, nil); err != nil {
+			return
+		}
+
+		timer := time.NewTimer(timeout)
+		select {
+		case <-c.pong:
+			timer.Stop()
+		case <-c.done:
+			timer.Stop()
+			return
+		case <-timer.C:
+			c.fail(ErrWebSocketPongTimeout)
+			return
+		}
+	}
+}
+
+// fail 记录第一个错误并断开连接
+func (c *wsConn) fail(err error) {
+	c.failOnce.Do(func() {
+		c.err = err
+		close(c.done)
+		_ = c.rwc.Close()
+	})
+}
+
+func (c *wsConn) isDone() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// error 返回连接断开的原因，只能在 done 关闭后调用
+func (c *wsConn) error() error {
+	<-c.done
+	return c.err
+}
+
+// close 发送关闭帧，等待对端回复关闭帧或超时后断开连接
+func (c *wsConn) close(code int, reason string) error {
+	if c.isDone() {
+		return nil
+	}
+	if err := c.writeClose(code, reason); err != nil {
+		c.fail(ErrWebSocketClosed)
+		return err
+	}
+	timer := time.NewTimer(wsCloseTimeout)
+	defer timer.Stop()
+	select {
+	case <-c.done:
+	case <-timer.C:
+		c.fail(ErrWebSocketClosed)
+	}
+	return nil
+}
+
+func (c *wsConn) readMessage() (MessageType, []byte, error) {
+	var (
+		typ        MessageType
+		data       []byte
+		compressed bool
+		started    bool
+	)
+	for {
+		f, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+		switch f.opcode {
+		case wsOpPing:
+			if err := c.writeControl(wsOpPong, f.payload); err != nil && !errors.Is(err, ErrWebSocketClosed) {
+				return 0, nil, err
+			}
+			continue
+		case wsOpPong:
+			select {
+			case c.pong <- struct{}{}:
+			default:
+			}
+			continue
+		case wsOpClose:
+			return 0, nil, c.handleClose(f.payload)
+		case wsOpText, wsOpBinary:
+			if started {
+				return 0, nil, wsProtocolError("expected continuation frame")
+			}
+			if f.rsv1 && !c.compress {
+				return 0, nil, wsProtocolError("unexpected compressed frame")
+			}
+			typ, compressed, started = MessageType(f.opcode), f.rsv1, true
+		case wsOpContinuation:
+			if !started || f.rsv1 {
+				return 0, nil, wsProtocolError("unexpected continuation frame")
+			}
+		default:
+			return 0, nil, wsProtocolError("unknown opcode %d", f.opcode)
+		}
+
+		if int64(len(data)+len(f.payload)) > c.maxSize {
+			return 0, nil, &wsFailure{code: CloseMessageTooBig, err: ErrWebSocketMessageTooLarge}
+		}
+		data = append(data, f.payload...)
+		if !f.fin {
+			continue
+		}
+		if compressed {
+			if data, err = wsDecompress(data, c.maxSize); err != nil {
+				return 0, nil, err
+			}
+		}
+		if typ == TextMessage && !utf8.Valid(data) {
+			return 0, nil, &wsFailure{code: CloseInvalidPayload, err: fmt.Errorf("%w: invalid utf-8 in text message", ErrWebSocketProtocol)}
+		}
+		return typ, data, nil
+	}
+}
+
+func (c *wsConn) readFrame() (wsFrame, error) {
+	var f wsFrame
+	var head [2]byte
+	if _, err := io.ReadFull(c.br, head[:]); err != nil {
+		return f, err
+	}
+	f.fin = head[0]&0x80 != 0
+	f.rsv1 = head[0]&0x40 != 0
+	f.opcode = head[0] & 0x0f
+	if head[0]&0x30 != 0 {
+		return f, wsProtocolError("reserved bits set")
+	}
+	masked := head[1]&0x80 != 0
+	if masked != c.server {
+		return f, wsProtocolError("unexpected mask bit")
+	}
+
+	n := uint64(head[1] & 0x7f)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return f, err
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return f, err
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	if f.opcode >= wsOpClose && (n > 125 || !f.fin || f.rsv1) {
+		return f, wsProtocolError("invalid control frame")
+	}
+	if n > uint64(c.maxSize) {
+		return f, &wsFailure{code: CloseMessageTooBig, err: ErrWebSocketMessageTooLarge}
+	}
+
+	var key [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.br, key[:]); err != nil {
+			return f, err
+		}
+	}
+	f.payload = make([]byte, n)
+	if _, err := io.ReadFull(c.br, f.payload); err != nil {
+		return f, err
+	}
+	if masked {
+		wsMask(key, f.payload)
+	}
+	return f, nil
+}
+
+// handleClose 回复对端的关闭帧
+func (c *wsConn) handleClose(payload []byte) error {
+	closeErr := &CloseError{Code: CloseNoStatus}
+	switch {
+	case len(payload) == 1:
+		return wsProtocolError("invalid close frame")
+	case len(payload) >= 2:
+		closeErr.Code = int(binary.BigEndian.Uint16(payload))
+		closeErr.Reason = string(payload[2:])
+		if !utf8.ValidString(closeErr.Reason) {
+			return wsProtocolError("invalid close reason")
+		}
+	}
+	_ = c.writeClose(closeErr.Code, "")
+	return closeErr
+}
+
+func (c *wsConn) writeMessage(typ MessageType, data []byte) error {
+	rsv1 := false
+	if c.compress {
+		var err error
+		if data, err = wsCompress(data); err != nil {
+			return err
+		}
+		rsv1 = true
+	}
+
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.closeSent {
+		return ErrWebSocketClosed
+	}
+	size := c.fragmentSize
+	if size <= 0 || size > len(data) {
+		size = len(data)
+	}
+	opcode := byte(typ)
+	for {
+		n := min(size, len(data))
+		fin := n == len(data)
+		if err := c.writeFrameLocked(opcode, fin, rsv1, data[:n]); err != nil {
+			c.fail(err)
+			return err
+		}
+		if fin {
+			return nil
+		}
+		data = data[n:]
+		opcode, rsv1 = wsOpContinuation, false
+	}
+}
+
+func (c *wsConn) writeControl(opcode byte, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.closeSent {
+		return ErrWebSocketClosed
+	}
+	return c.writeFrameLocked(opcode, true, false, payload)
+}
+
+// writeClose 发送关闭帧，每个连接只发送一次
+func (c *wsConn) writeClose(code int, reason string) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.closeSent {
+		return nil
+	}
+	c.closeSent = true
+	close(c.closing)
+
+	var payload []byte
+	if code != CloseNoStatus {
+		payload = binary.BigEndian.AppendUint16(nil, uint16(code))
+		payload = append(payload, reason...)
+	}
+	return c.writeFrameLocked(wsOpClose, true, false, payload)
+}
+
+func (c *wsConn) writeFrameLocked(opcode byte, fin, rsv1 bool, payload []byte) error {
+	buf := make([]byte, 0, 14+len(payload))
+	b0 := opcode
+	if fin {
+		b0 |= 0x80
+	}
+	if rsv1 {
+		b0 |= 0x40
+	}
+	buf = append(buf, b0)
+
+	var maskBit byte
+	if !c.server {
+		// 客户端发送的帧必须加掩码
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n <= 125:
+		buf = append(buf, maskBit|byte(n))
+	case n <= 0xffff:
+		buf = append(buf, maskBit|126)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(n))
+	default:
+		buf = append(buf, maskBit|127)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(n))
+	}
+
+	if c.server {
+		buf = append(buf, payload...)
+	} else {
+		var key [4]byte
+		if _, err := rand.Read(key[:]); err != nil {
+			return err
+		}
+		buf = append(buf, key[:]...)
+		start := len(buf)
+		buf = append(buf, payload...)
+		wsMask(key, buf[start:])
+	}
+	_, err := c.rwc.Write(buf)
+	return err
+}
+
+func wsMask(key [4]byte, data []byte) {
+	for i := range data {
+		data[i] ^= key[i%4]
+	}
+}
+
+var wsFlateWriters sync.Pool
+
+// wsCompress 按 RFC 7692 7.2.1 压缩消息，去掉结尾的 0x00 0x00 0xff 0xff
+func wsCompress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, _ := wsFlateWriters.Get().(*flate.Writer)
+	if w == nil {
+		var err error
+		if w, err = flate.NewWriter(&buf, flate.BestSpeed); err != nil {
+			return nil, err
+		}
+	} else {
+		w.Reset(&buf)
+	}
+	defer wsFlateWriters.Put(w)
+
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte{0x00, 0x00, 0xff, 0xff}), nil
+}
+
+// wsDecompress 补上压缩时去掉的结尾后解压，解压后超过 limit 字节时返回错误
+func wsDecompress(data []byte, limit int64) ([]byte, error) {
+	// 结尾追加一个空的 final block，让 flate 正常结束
+	r := flate.NewReader(io.MultiReader(bytes.NewReader(data), strings.NewReader("\x00\x00\xff\xff\x01\x00\x00\xff\xff")))
+	defer r.Close()
+	out, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, &wsFailure{code: CloseInvalidPayload, err: fmt.Errorf("%w: %v", ErrWebSocketProtocol, err)}
+	}
+	if int64(len(out)) > limit {
+		return nil, &wsFailure{code: CloseMessageTooBig, err: ErrWebSocketMessageTooLarge}
+	}
+	return out, nil
+}
@@ -0,0 +1,384 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newWebSocketServer 创建 WebSocket 测试服务端，握手完成后在新的 goroutine 中运行 handler
+func newWebSocketServer(t *testing.T, config *WebSocketConfig, handler func(c *wsConn, r *http.Request)) (*httptest.Server, *atomic.Int32) {
+	var handshakes atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || r.Header.Get("Sec-WebSocket-Version") != "13" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.Header.Get("Authorization") == "Bearer invalid" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handshakes.Add(1)
+
+		compress := config.Compression && strings.Contains(r.Header.Get("Sec-WebSocket-Extensions"), "permessage-deflate")
+		header := "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+			"Sec-WebSocket-Accept: " + wsAcceptKey(r.Header.Get("Sec-WebSocket-Key")) + "\r\n"
+		if compress {
+			header += "Sec-WebSocket-Extensions: permessage-deflate; server_no_context_takeover; client_no_context_takeover\r\n"
+		}
+		if protocols := r.Header.Get("Sec-WebSocket-Protocol"); protocols != "" {
+			header += "Sec-WebSocket-Protocol: " + strings.TrimSpace(strings.Split(protocols, ",")[0]) + "\r\n"
+		}
+
+		conn, brw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Error("Hijack failed. ", err)
+			return
+		}
+		if _, err := conn.Write([]byte(header + "\r\n")); err != nil {
+			conn.Close()
+			return
+		}
+		go handler(newWSConn(conn, brw.Reader, true, compress, config), r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &handshakes
+}
+
+func echoHandler(c *wsConn, _ *http.Request) {
+	for msg := range c.messages {
+		if err := c.writeMessage(msg.typ, msg.data); err != nil {
+			return
+		}
+	}
+}
+
+func wsURL(srv *httptest.Server) string {
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func TestWebSocketEcho(t *testing.T) {
+	srv, _ := newWebSocketServer(t, &WebSocketConfig{}, func(c *wsConn, r *http.Request) {
+		// 先把握手请求的认证头发回去
+		_ = c.writeMessage(TextMessage, []byte(r.Header.Get("Authorization")))
+		echoHandler(c, r)
+	})
+	observe := &eventObserve{}
+	client := NewHTTPClient(&Config{Auth: &AuthBearerToken{Token: "ws-token"}, Observe: observe})
+
+	ws, err := client.DialWebSocket(context.Background(), wsURL(srv)+"/chat", &WebSocketConfig{Subprotocols: []string{"chat.v2", "chat.v1"}},
+		WithRoute("WS /chat"), WithTimeout(time.Second))
+	if err != nil {
+		t.Fatal("Dial failed. ", err)
+	}
+	defer ws.Close()
+	if ws.Subprotocol() != "chat.v2" {
+		t.Fatal("Subprotocol not match. ", ws.Subprotocol())
+	}
+
+	ctx := context.Background()
+	typ, data, err := ws.ReadMessage(ctx)
+	if err != nil || typ != TextMessage || string(data) != "Bearer ws-token" {
+		t.Fatal("Auth header not match. ", typ, string(data), err)
+	}
+
+	large := bytes.Repeat([]byte{0, 1, 2, 3}, 50000)
+	for _, msg := range []wsMessage{{TextMessage, []byte("hello")}, {BinaryMessage, large}, {TextMessage, nil}} {
+		if err := ws.WriteMessage(ctx, msg.typ, msg.data); err != nil {
+			t.Fatal("Write failed. ", err)
+		}
+		typ, data, err := ws.ReadMessage(ctx)
+		if err != nil || typ != msg.typ || !bytes.Equal(data, msg.data) {
+			t.Fatalf("Echo not match. expected=%d/%d, actual=%d/%d, err=%v", msg.typ, len(msg.data), typ, len(data), err)
+		}
+	}
+
+	observe.mu.Lock()
+	events := observe.events
+	observe.mu.Unlock()
+	if len(events) != 1 || events[0].StatusCode != http.StatusSwitchingProtocols || events[0].Route != "WS /chat" {
+		t.Fatal("Handshake event not match. ", events)
+	}
+}
+
+func TestWebSocketFragmentationAndCompression(t *testing.T) {
+	tests := []struct {
+		name   string
+		config WebSocketConfig
+	}{
+		{"fragmentation", WebSocketConfig{FragmentSize: 7}},
+		{"compression", WebSocketConfig{Compression: true}},
+		{"compressed fragments", WebSocketConfig{Compression: true, FragmentSize: 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var serverConn atomic.Pointer[wsConn]
+			srv, _ := newWebSocketServer(t, &tt.config, func(c *wsConn, r *http.Request) {
+				serverConn.Store(c)
+				echoHandler(c, r)
+			})
+			client := NewHTTPClient(&Config{})
+			ws, err := client.DialWebSocket(context.Background(), wsURL(srv), &tt.config)
+			if err != nil {
+				t.Fatal("Dial failed. ", err)
+			}
+			defer ws.Close()
+
+			for i := 0; i < 3; i++ {
+				msg := strings.Repeat(fmt.Sprintf("message %d ", i), 100)
+				if err := ws.WriteMessage(context.Background(), TextMessage, []byte(msg)); err != nil {
+					t.Fatal("Write failed. ", err)
+				}
+				_, data, err := ws.ReadMessage(context.Background())
+				if err != nil || string(data) != msg {
+					t.Fatal("Echo not match. ", len(data), err)
+				}
+			}
+			if ws.conn.compress != tt.config.Compression || serverConn.Load().compress != tt.config.Compression {
+				t.Fatal("Compression not negotiated")
+			}
+		})
+	}
+}
+
+func TestWebSocketCompressionDeclined(t *testing.T) {
+	// 服务端不支持压缩时使用普通帧
+	srv, _ := newWebSocketServer(t, &WebSocketConfig{}, echoHandler)
+	ws, err := NewHTTPClient(&Config{}).DialWebSocket(context.Background(), wsURL(srv), &WebSocketConfig{Compression: true})
+	if err != nil {
+		t.Fatal("Dial failed. ", err)
+	}
+	defer ws.Close()
+	if ws.conn.compress {
+		t.Fatal("Compression should not be enabled")
+	}
+	if err := ws.WriteMessage(context.Background(), TextMessage, []byte("plain")); err != nil {
+		t.Fatal("Write failed. ", err)
+	}
+	if _, data, err := ws.ReadMessage(context.Background()); err != nil || string(data) != "plain" {
+		t.Fatal("Echo not match. ", string(data), err)
+	}
+}
+
+func TestWebSocketPing(t *testing.T) {
+	srv, _ := newWebSocketServer(t, &WebSocketConfig{}, echoHandler)
+	client := NewHTTPClient(&Config{})
+	config := &WebSocketConfig{PingInterval: 10 * time.Millisecond}
+
+	ws, err := client.DialWebSocket(context.Background(), wsURL(srv), config)
+	if err != nil {
+		t.Fatal("Dial failed. ", err)
+	}
+	defer ws.Close()
+	time.Sleep(100 * time.Millisecond)
+	if ws.conn.isDone() {
+		t.Fatal("Connection should be kept alive. ", ws.conn.error())
+	}
+
+	// 服务端不读取任何帧，ping 得不到回复
+	ws, err = client.DialWebSocket(context.Background(), newSilentServer(t), config)
+	if err != nil {
+		t.Fatal("Dial failed. ", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, _, err := ws.ReadMessage(ctx); !errors.Is(err, ErrWebSocketPongTimeout) {
+		t.Fatalf("Expected ErrWebSocketPongTimeout, actual=%v", err)
+	}
+}
+
+// newSilentServer 完成握手后不再读写的服务端
+func newSilentServer(t *testing.T) string {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			return
+		}
+		_, _ = conn.Write([]byte("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+			"Sec-WebSocket-Accept: " + wsAcceptKey(r.Header.Get("Sec-WebSocket-Key")) + "\r\n\r\n"))
+		t.Cleanup(func() { conn.Close() })
+	}))
+	t.Cleanup(srv.Close)
+	return wsURL(srv)
+}
+
+func TestWebSocketClose(t *testing.T) {
+	closed := make(chan error, 1)
+	srv, _ := newWebSocketServer(t, &WebSocketConfig{}, func(c *wsConn, r *http.Request) {
+		if r.URL.Path == "/bye" {
+			_ = c.close(CloseNormalClosure, "bye")
+			return
+		}
+		echoHandler(c, r)
+		closed <- c.error()
+	})
+	client := NewHTTPClient(&Config{})
+	config := &WebSocketConfig{Reconnect: &RetryPolicy{MaxAttempts: 3}}
+
+	// 客户端发起关闭
+	ws, err := client.DialWebSocket(context.Background(), wsURL(srv), config)
+	if err != nil {
+		t.Fatal("Dial failed. ", err)
+	}
+	if err := ws.Close(); err != nil {
+		t.Fatal("Close failed. ", err)
+	}
+	var closeErr *CloseError
+	if err := <-closed; !errors.As(err, &closeErr) || closeErr.Code != CloseNormalClosure {
+		t.Fatalf("Expected close 1000 on server, actual=%v", err)
+	}
+	if err := ws.WriteMessage(context.Background(), TextMessage, []byte("x")); !errors.Is(err, ErrWebSocketClosed) {
+		t.Fatalf("Expected ErrWebSocketClosed, actual=%v", err)
+	}
+
+	// 服务端正常关闭时不重连
+	ws, err = client.DialWebSocket(context.Background(), wsURL(srv)+"/bye", config)
+	if err != nil {
+		t.Fatal("Dial failed. ", err)
+	}
+	if _, _, err := ws.ReadMessage(context.Background()); !errors.As(err, &closeErr) || closeErr.Reason != "bye" {
+		t.Fatalf("Expected close error, actual=%v", err)
+	}
+}
+
+func TestWebSocketReconnect(t *testing.T) {
+	var mu sync.Mutex
+	connections := 0
+	srv, handshakes := newWebSocketServer(t, &WebSocketConfig{}, func(c *wsConn, r *http.Request) {
+		mu.Lock()
+		connections++
+		n := connections
+		mu.Unlock()
+		_ = c.writeMessage(TextMessage, []byte(fmt.Sprintf("connection %d", n)))
+		if n == 1 {
+			// 第一个连接异常断开
+			time.Sleep(10 * time.Millisecond)
+			c.fail(ErrWebSocketClosed)
+			return
+		}
+		echoHandler(c, r)
+	})
+
+	var connects atomic.Int32
+	client := NewHTTPClient(&Config{})
+	ws, err := client.DialWebSocket(context.Background(), wsURL(srv), &WebSocketConfig{
+		Reconnect: &RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond},
+		OnConnect: func(ws *WebSocket) {
+			// 重新订阅
+			if connects.Add(1) > 1 {
+				_ = ws.WriteMessage(context.Background(), TextMessage, []byte("subscribe"))
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal("Dial failed. ", err)
+	}
+	defer ws.Close()
+
+	var got []string
+	for i := 0; i < 3; i++ {
+		_, data, err := ws.ReadMessage(context.Background())
+		if err != nil {
+			t.Fatal("Read failed. ", err)
+		}
+		got = append(got, string(data))
+	}
+	if strings.Join(got, ",") != "connection 1,connection 2,subscribe" || handshakes.Load() != 2 || connects.Load() != 2 {
+		t.Fatal("Reconnect not match. ", got, handshakes.Load(), connects.Load())
+	}
+}
+
+// reconnectAuth 第一次握手后使用无效的凭据
+type reconnectAuth struct {
+	calls atomic.Int32
+}
+
+func (a *reconnectAuth) Apply(req *http.Request) {
+	if a.calls.Add(1) > 1 {
+		req.Header.Set("Authorization", "Bearer invalid")
+	}
+}
+
+func TestWebSocketReconnectHandshakeStatus(t *testing.T) {
+	srv, _ := newWebSocketServer(t, &WebSocketConfig{}, func(c *wsConn, r *http.Request) {
+		_ = c.writeMessage(TextMessage, []byte("hello"))
+		time.Sleep(10 * time.Millisecond)
+		c.fail(ErrWebSocketClosed)
+	})
+
+	var statuses []int
+	client := NewHTTPClient(&Config{Auth: &reconnectAuth{}})
+	ws, err := client.DialWebSocket(context.Background(), wsURL(srv), &WebSocketConfig{
+		Reconnect: &RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond, ShouldRetry: func(resp *http.Response, err error) bool {
+			// 握手返回非 101 时收到响应，与请求重试一致
+			statuses = append(statuses, resp.StatusCode)
+			return resp.StatusCode == http.StatusServiceUnavailable
+		}},
+	})
+	if err != nil {
+		t.Fatal("Dial failed. ", err)
+	}
+	defer ws.Close()
+
+	if _, _, err := ws.ReadMessage(context.Background()); err != nil {
+		t.Fatal("Read failed. ", err)
+	}
+	if _, _, err := ws.ReadMessage(context.Background()); !errors.Is(err, ErrWebSocketHandshake) {
+		t.Fatal("Expected handshake error. ", err)
+	}
+	if len(statuses) != 1 || statuses[0] != http.StatusUnauthorized {
+		t.Fatal("ShouldRetry not match. ", statuses)
+	}
+}
+
+func TestWebSocketProxy(t *testing.T) {
+	srv, _ := newWebSocketServer(t, &WebSocketConfig{}, echoHandler)
+	proxy := newSocks5Proxy(t, srv.Listener.Addr().String(), "", "")
+
+	client := NewHTTPClient(&Config{Proxy: &ProxyConfig{URL: proxy.URL}})
+	ws, err := client.DialWebSocket(context.Background(), "ws://ws.example.test/stream", nil)
+	if err != nil {
+		t.Fatal("Dial failed. ", err)
+	}
+	defer ws.Close()
+	if err := ws.WriteMessage(context.Background(), TextMessage, []byte("via proxy")); err != nil {
+		t.Fatal("Write failed. ", err)
+	}
+	if _, data, err := ws.ReadMessage(context.Background()); err != nil || string(data) != "via proxy" {
+		t.Fatal("Echo not match. ", string(data), err)
+	}
+	if proxy.lastHost() != "ws.example.test" {
+		t.Fatal("Request not proxied. ", proxy.lastHost())
+	}
+}
+
+func TestWebSocketHandshakeError(t *testing.T) {
+	srv, _ := newWebSocketServer(t, &WebSocketConfig{}, echoHandler)
+	client := NewHTTPClient(&Config{Auth: &AuthBearerToken{Token: "invalid"}})
+	if _, err := client.DialWebSocket(context.Background(), wsURL(srv), nil); !errors.Is(err, ErrWebSocketHandshake) {
+		t.Fatalf("Expected ErrWebSocketHandshake, actual=%v", err)
+	}
+}
+
+func TestWebSocketMessageTooLarge(t *testing.T) {
+	srv, _ := newWebSocketServer(t, &WebSocketConfig{}, func(c *wsConn, r *http.Request) {
+		_ = c.writeMessage(BinaryMessage, make([]byte, 2048))
+		echoHandler(c, r)
+	})
+	ws, err := NewHTTPClient(&Config{}).DialWebSocket(context.Background(), wsURL(srv), &WebSocketConfig{MaxMessageSize: 1024})
+	if err != nil {
+		t.Fatal("Dial failed. ", err)
+	}
+	defer ws.Close()
+	if _, _, err := ws.ReadMessage(context.Background()); !errors.Is(err, ErrWebSocketMessageTooLarge) {
+		t.Fatalf("Expected ErrWebSocketMessageTooLarge, actual=%v", err)
+	}
+}
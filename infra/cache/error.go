@@ -10,3 +10,23 @@ var KeyNotExistsError = errors.New("cache key not exists")
 func newKeyNotExistsError(k string) error {
 	return fmt.Errorf("%w. key=%s", KeyNotExistsError, k)
 }
+
+var TypeNotRegisteredError = errors.New("cache target type not registered")
+
+func newTypeNotRegisteredError(k string) error {
+	return fmt.Errorf("%w. key=%s", TypeNotRegisteredError, k)
+}
+
+// VersionMismatchError 同时匹配 KeyNotExistsError，版本不一致的值按缓存未命中处理
+var VersionMismatchError = errors.New("cache value version mismatch")
+
+func newVersionMismatchError(k string, expected, actual uint64) error {
+	return fmt.Errorf("%w. key=%s, expected=%d, actual=%d: %w", VersionMismatchError, k, expected, actual, KeyNotExistsError)
+}
+
+// ValueTooLargeError 单个值超过了有界本地缓存的 MaxBytes
+var ValueTooLargeError = errors.New("cache value too large")
+
+func newValueTooLargeError(k string, size, limit int64) error {
+	return fmt.Errorf("%w. key=%s, size=%d, max=%d", ValueTooLargeError, k, size, limit)
+}
@@ -0,0 +1,332 @@
+package cache
+
+// EvictionPolicy 容量不足时选择淘汰条目的策略
+type EvictionPolicy int
+
+const (
+	// PolicyLRU 淘汰最久未访问的条目
+	PolicyLRU EvictionPolicy = iota
+	// PolicyLFU 淘汰访问次数最少的条目，次数相同时淘汰最久未访问的
+	PolicyLFU
+	// PolicyTinyLFU W-TinyLFU，新条目先进入约占 1% 的 LRU 窗口，
+	// 离开窗口时与主区 SLRU 的淘汰候选比较访问频率，频率更高者留下
+	PolicyTinyLFU
+)
+
+// EvictionReason 条目被移出缓存的原因
+type EvictionReason int
+
+const (
+	// EvictionCapacity 超过容量被淘汰
+	EvictionCapacity EvictionReason = iota + 1
+	// EvictionExpired 过期被删除
+	EvictionExpired
+)
+
+func (r EvictionReason) String() string {
+	switch r {
+	case EvictionCapacity:
+		return "capacity"
+	case EvictionExpired:
+		return "expired"
+	default:
+		return "unknown"
+	}
+}
+
+// node 有界缓存中的一个条目，同一时刻只属于一个 nodeList
+type node struct {
+	key   string
+	hash  uint64
+	entry *entry
+	size  int64
+
+	prev, next *node
+	list       *nodeList
+}
+
+// nodeList 以 root 为哨兵的双向链表，头部为最近访问的条目
+type nodeList struct {
+	root node
+	len  int
+
+	// freq、prevBucket、nextBucket 仅用于 LFU 的频率桶
+	freq                   int
+	prevBucket, nextBucket *nodeList
+}
+
+func (l *nodeList) init() *nodeList {
+	l.root.next = &l.root
+	l.root.prev = &l.root
+	l.len = 0
+	return l
+}
+
+func (l *nodeList) pushFront(n *node) {
+	n.prev = &l.root
+	n.next = l.root.next
+	l.root.next.prev = n
+	l.root.next = n
+	n.list = l
+	l.len++
+}
+
+func (l *nodeList) remove(n *node) {
+	n.prev.next = n.next
+	n.next.prev = n.prev
+	n.prev, n.next, n.list = nil, nil, nil
+	l.len--
+}
+
+func (l *nodeList) moveToFront(n *node) {
+	l.remove(n)
+	l.pushFront(n)
+}
+
+func (l *nodeList) front() *node {
+	if l.len == 0 {
+		return nil
+	}
+	return l.root.next
+}
+
+func (l *nodeList) back() *node {
+	if l.len == 0 {
+		return nil
+	}
+	return l.root.prev
+}
+
+// evictor 淘汰策略，所有方法都是 O(1) 并由调用方加锁
+type evictor interface {
+	// add 新条目加入
+	add(n *node)
+	// access 条目被读取或更新
+	access(n *node)
+	// remove 条目被删除或淘汰
+	remove(n *node)
+	// victim 返回下一个应被淘汰的条目，没有条目时返回 nil
+	victim() *node
+}
+
+// newEvictor 创建淘汰策略，capacity 为预估的条目数，用于确定频率统计的大小
+func newEvictor(policy EvictionPolicy, capacity int) evictor {
+	switch policy {
+	case PolicyLFU:
+		return newLFUEvictor()
+	case PolicyTinyLFU:
+		return newTinyLFUEvictor(capacity)
+	default:
+		return newLRUEvictor()
+	}
+}
+
+type lruEvictor struct {
+	list nodeList
+}
+
+func newLRUEvictor() *lruEvictor {
+	e := &lruEvictor{}
+	e.list.init()
+	return e
+}
+
+func (e *lruEvictor) add(n *node)    { e.list.pushFront(n) }
+func (e *lruEvictor) access(n *node) { e.list.moveToFront(n) }
+func (e *lruEvictor) remove(n *node) { e.list.remove(n) }
+func (e *lruEvictor) victim() *node  { return e.list.back() }
+
+// lfuEvictor 按访问次数分桶，桶按次数升序组成链表，每个桶内按访问时间排序
+type lfuEvictor struct {
+	buckets nodeList
+}
+
+func newLFUEvictor() *lfuEvictor {
+	e := &lfuEvictor{}
+	e.buckets.prevBucket = &e.buckets
+	e.buckets.nextBucket = &e.buckets
+	return e
+}
+
+// bucketAfter 返回 prev 之后访问次数为 freq 的桶，不存在时创建
+func (e *lfuEvictor) bucketAfter(prev *nodeList, freq int) *nodeList {
+	if next := prev.nextBucket; next != &e.buckets && next.freq == freq {
+		return next
+	}
+	b := (&nodeList{freq: freq}).init()
+	b.prevBucket = prev
+	b.nextBucket = prev.nextBucket
+	prev.nextBucket.prevBucket = b
+	prev.nextBucket = b
+	return b
+}
+
+// unlinkIfEmpty 移除空桶
+func (e *lfuEvictor) unlinkIfEmpty(b *nodeList) {
+	if b.len > 0 {
+		return
+	}
+	b.prevBucket.nextBucket = b.nextBucket
+	b.nextBucket.prevBucket = b.prevBucket
+	b.prevBucket, b.nextBucket = nil, nil
+}
+
+func (e *lfuEvictor) add(n *node) {
+	e.bucketAfter(&e.buckets, 1).pushFront(n)
+}
+
+func (e *lfuEvictor) access(n *node) {
+	cur := n.list
+	next := e.bucketAfter(cur, cur.freq+1)
+	cur.remove(n)
+	next.pushFront(n)
+	e.unlinkIfEmpty(cur)
+}
+
+func (e *lfuEvictor) remove(n *node) {
+	b := n.list
+	b.remove(n)
+	e.unlinkIfEmpty(b)
+}
+
+func (e *lfuEvictor) victim() *node {
+	if first := e.buckets.nextBucket; first != &e.buckets {
+		return first.back()
+	}
+	return nil
+}
+
+// tinyLFUEvictor W-TinyLFU，窗口区为 LRU，主区为 probation 和 protected 组成的 SLRU，
+// protected 约占主区的 80%，区域大小按条目数计算
+type tinyLFUEvictor struct {
+	window    nodeList
+	probation nodeList
+	protected nodeList
+	sketch    *cmSketch
+}
+
+func newTinyLFUEvictor(capacity int) *tinyLFUEvictor {
+	e := &tinyLFUEvictor{sketch: newCMSketch(capacity)}
+	e.window.init()
+	e.probation.init()
+	e.protected.init()
+	return e
+}
+
+func (e *tinyLFUEvictor) add(n *node) {
+	e.sketch.increment(n.hash)
+	e.window.pushFront(n)
+}
+
+func (e *tinyLFUEvictor) access(n *node) {
+	e.sketch.increment(n.hash)
+	switch n.list {
+	case &e.probation:
+		e.probation.remove(n)
+		e.protected.pushFront(n)
+		// protected 超出份额时把最久未访问的降级回 probation
+		main := e.probation.len + e.protected.len
+		for e.protected.len > main-main/5 {
+			demoted := e.protected.back()
+			e.protected.remove(demoted)
+			e.probation.pushFront(demoted)
+		}
+	default:
+		n.list.moveToFront(n)
+	}
+}
+
+func (e *tinyLFUEvictor) remove(n *node) {
+	n.list.remove(n)
+}
+
+func (e *tinyLFUEvictor) victim() *node {
+	total := e.window.len + e.probation.len + e.protected.len
+	windowCap := max(1, total/100)
+	for e.window.len > windowCap {
+		n := e.window.back()
+		e.window.remove(n)
+		e.probation.pushFront(n)
+	}
+	if e.probation.len == 0 {
+		n := e.protected.back()
+		if n == nil {
+			return e.window.back()
+		}
+		e.protected.remove(n)
+		e.probation.pushFront(n)
+	}
+
+	// 刚离开窗口的候选与 probation 尾部比较，频率更高者留下
+	candidate, victim := e.probation.front(), e.probation.back()
+	if candidate == victim || e.sketch.estimate(candidate.hash) > e.sketch.estimate(victim.hash) {
+		return victim
+	}
+	return candidate
+}
+
+// cmSketch 4 行 Count-Min Sketch，每个计数器 4 位，每行的计数器个数为容量的 16 倍，
+// 累计增加次数达到容量的 10 倍时所有计数减半
+type cmSketch struct {
+	rows      [4][]uint64
+	mask      uint64
+	additions int
+	resetAt   int
+}
+
+var cmSketchSeeds = [4]uint64{0xc3a5c85c97cb3127, 0xb492b66fbe98f273, 0x9ae16a3b2f90404f, 0xcbf29ce484222325}
+
+func newCMSketch(capacity int) *cmSketch {
+	words := 1
+	for words < capacity && words < 1<<14 {
+		words <<= 1
+	}
+	s := &cmSketch{mask: uint64(words*16 - 1), resetAt: 10 * words}
+	for i := range s.rows {
+		s.rows[i] = make([]uint64, words)
+	}
+	return s
+}
+
+// slot 返回第 i 行计数器所在的字和位移
+func (s *cmSketch) slot(hash uint64, i int) (int, uint) {
+	j := (((hash ^ cmSketchSeeds[i]) * 0x9e3779b97f4a7c15) >> 32) & s.mask
+	return int(j >> 4), uint(j&15) * 4
+}
+
+func (s *cmSketch) increment(hash uint64) {
+	added := false
+	for i := range s.rows {
+		w, shift := s.slot(hash, i)
+		if (s.rows[i][w]>>shift)&0xf < 15 {
+			s.rows[i][w] += 1 << shift
+			added = true
+		}
+	}
+	if !added {
+		return
+	}
+	s.additions++
+	if s.additions >= s.resetAt {
+		s.reset()
+	}
+}
+
+func (s *cmSketch) estimate(hash uint64) int {
+	est := 15
+	for i := range s.rows {
+		w, shift := s.slot(hash, i)
+		est = min(est, int((s.rows[i][w]>>shift)&0xf))
+	}
+	return est
+}
+
+// reset 计数减半，使频率统计随时间衰减
+func (s *cmSketch) reset() {
+	for i := range s.rows {
+		for w := range s.rows[i] {
+			s.rows[i][w] = (s.rows[i][w] >> 1) & 0x7777777777777777
+		}
+	}
+	s.additions /= 2
+}
@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"fmt"
+	"hash/maphash"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// evictorHarness 以固定容量驱动 evictor，返回被淘汰的 key
+type evictorHarness struct {
+	evictor  evictor
+	nodes    map[string]*node
+	capacity int
+	seed     maphash.Seed
+}
+
+func newEvictorHarness(policy EvictionPolicy, capacity int) *evictorHarness {
+	return &evictorHarness{
+		evictor:  newEvictor(policy, capacity),
+		nodes:    make(map[string]*node),
+		capacity: capacity,
+		seed:     maphash.MakeSeed(),
+	}
+}
+
+func (h *evictorHarness) get(key string) bool {
+	n, ok := h.nodes[key]
+	if ok {
+		h.evictor.access(n)
+	}
+	return ok
+}
+
+func (h *evictorHarness) set(key string) []string {
+	if h.get(key) {
+		return nil
+	}
+	var evicted []string
+	for len(h.nodes) >= h.capacity {
+		v := h.evictor.victim()
+		h.evictor.remove(v)
+		delete(h.nodes, v.key)
+		evicted = append(evicted, v.key)
+	}
+	n := &node{key: key, hash: maphash.String(h.seed, key)}
+	h.nodes[key] = n
+	h.evictor.add(n)
+	return evicted
+}
+
+func TestEvictor_LRU(t *testing.T) {
+	h := newEvictorHarness(PolicyLRU, 3)
+	h.set("a")
+	h.set("b")
+	h.set("c")
+	h.get("a")
+
+	assert.Equal(t, []string{"b"}, h.set("d"))
+	assert.Equal(t, []string{"c"}, h.set("e"))
+	assert.Equal(t, []string{"a"}, h.set("f"))
+}
+
+func TestEvictor_LFU(t *testing.T) {
+	h := newEvictorHarness(PolicyLFU, 3)
+	h.set("a")
+	h.set("b")
+	h.set("c")
+	for i := 0; i < 3; i++ {
+		h.get("a")
+	}
+	h.get("b")
+	h.get("c")
+
+	// b 和 c 访问次数相同，淘汰最久未访问的 b
+	assert.Equal(t, []string{"b"}, h.set("d"))
+	// 新条目访问次数最少
+	assert.Equal(t, []string{"d"}, h.set("e"))
+
+	h.evictor.remove(h.nodes["e"])
+	delete(h.nodes, "e")
+	assert.Equal(t, "c", h.evictor.victim().key)
+}
+
+func TestEvictor_TinyLFU(t *testing.T) {
+	const capacity = 100
+	h := newEvictorHarness(PolicyTinyLFU, capacity)
+
+	// 热点数据被多次访问
+	for round := 0; round < 5; round++ {
+		for i := 0; i < capacity; i++ {
+			h.set(fmt.Sprintf("hot-%d", i))
+		}
+	}
+	// 一次性扫描不应冲掉热点数据
+	for i := 0; i < 10*capacity; i++ {
+		h.set(fmt.Sprintf("scan-%d", i))
+	}
+
+	hot := 0
+	for i := 0; i < capacity; i++ {
+		if _, ok := h.nodes[fmt.Sprintf("hot-%d", i)]; ok {
+			hot++
+		}
+	}
+	assert.Greater(t, hot, capacity*9/10)
+	assert.Equal(t, capacity, len(h.nodes))
+
+	lru := newEvictorHarness(PolicyLRU, capacity)
+	for round := 0; round < 5; round++ {
+		for i := 0; i < capacity; i++ {
+			lru.set(fmt.Sprintf("hot-%d", i))
+		}
+	}
+	for i := 0; i < 10*capacity; i++ {
+		lru.set(fmt.Sprintf("scan-%d", i))
+	}
+	assert.NotContains(t, lru.nodes, "hot-0")
+}
+
+func TestCMSketch(t *testing.T) {
+	s := newCMSketch(64)
+	for i := 0; i < 20; i++ {
+		s.increment(1)
+	}
+	s.increment(2)
+	assert.Equal(t, 15, s.estimate(1))
+	assert.Equal(t, 1, s.estimate(2))
+	assert.Equal(t, 0, s.estimate(3))
+
+	s.reset()
+	assert.Equal(t, 7, s.estimate(1))
+	assert.Equal(t, 0, s.estimate(2))
+}
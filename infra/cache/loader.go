@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// LoaderFunc 缓存未命中时加载 key 对应的值
+type LoaderFunc func(ctx context.Context) (any, error)
+
+// LoadingCache 支持防击穿加载的缓存
+type LoadingCache interface {
+	Cache
+	// GetOrLoad 返回缓存中的值，未命中时调用 loader 加载并写入缓存。
+	// 同一 key 的并发加载只执行一次，等待期间 ctx 结束时立即返回 ctx.Err()，加载本身不会因此取消
+	GetOrLoad(ctx context.Context, key string, loader LoaderFunc, ttl time.Duration, opts ...LoadOption) (any, error)
+}
+
+var (
+	_ LoadingCache = (*localCache)(nil)
+	_ LoadingCache = (*SqliteCache)(nil)
+)
+
+// LoadOption GetOrLoad 的可选参数
+type LoadOption func(s *loadSettings)
+
+type loadSettings struct {
+	beta float64
+}
+
+// WithEarlyExpiration 开启 XFetch 概率提前过期，beta 越大越早刷新，通常取 1。
+// 加载耗时越长、越接近过期时间，命中时越可能提前重新加载，避免大量请求在同一时刻过期
+func WithEarlyExpiration(beta float64) LoadOption {
+	return func(s *loadSettings) {
+		s.beta = beta
+	}
+}
+
+func newLoadSettings(opts []LoadOption) *loadSettings {
+	s := &loadSettings{}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// refreshEarly 按 XFetch 判断是否提前刷新：now - delta * beta * ln(rand) >= expire，
+// delta 为上次加载耗时
+func (s *loadSettings) refreshEarly(expire time.Time, delta time.Duration, now time.Time) bool {
+	if s.beta <= 0 || delta <= 0 {
+		return false
+	}
+	gap := -float64(delta) * s.beta * math.Log(1-rand.Float64())
+	return !now.Add(time.Duration(gap)).Before(expire)
+}
+
+// loadGroup 合并同一 key 的并发加载
+type loadGroup struct {
+	group singleflight.Group
+}
+
+// do 执行或等待 key 的加载，等待期间 ctx 结束时返回 ctx.Err()
+func (g *loadGroup) do(ctx context.Context, key string, load func(ctx context.Context) (any, error)) (any, error) {
+	// 加载由所有等待方共享，不随某个调用方的 ctx 取消
+	loadCtx := context.WithoutCancel(ctx)
+	ch := g.group.DoChan(key, func() (any, error) {
+		return load(loadCtx)
+	})
+	select {
+	case r := <-ch:
+		return r.Val, r.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// timedLoad 调用 loader 并返回耗时
+func timedLoad(ctx context.Context, loader LoaderFunc) (any, time.Duration, error) {
+	start := time.Now()
+	v, err := loader(ctx)
+	return v, time.Since(start), err
+}
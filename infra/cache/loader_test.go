@@ -0,0 +1,187 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newLoadingCaches(t *testing.T) map[string]LoadingCache {
+	sqlite, err := NewSqliteCache(createTempDB(t))
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = sqlite.Close() })
+
+	return map[string]LoadingCache{
+		"local":   NewLocalCache(nil).(LoadingCache),
+		"bounded": NewBoundedLocalCache(nil, LocalCacheConfig{MaxEntries: 100}).(LoadingCache),
+		"sqlite":  sqlite,
+	}
+}
+
+func TestGetOrLoad_Singleflight(t *testing.T) {
+	ctx := context.Background()
+
+	for name, cache := range newLoadingCaches(t) {
+		t.Run(name, func(t *testing.T) {
+			var calls atomic.Int32
+			release := make(chan struct{})
+			loader := func(ctx context.Context) (any, error) {
+				calls.Add(1)
+				<-release
+				return []byte("loaded"), nil
+			}
+
+			const n = 20
+			var wg sync.WaitGroup
+			results := make([]any, n)
+			for i := 0; i < n; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					v, err := cache.GetOrLoad(ctx, "key", loader, time.Hour)
+					assert.NoError(t, err)
+					results[i] = v
+				}(i)
+			}
+			time.Sleep(50 * time.Millisecond)
+			close(release)
+			wg.Wait()
+
+			assert.Equal(t, int32(1), calls.Load())
+			for _, v := range results {
+				assert.Equal(t, "loaded", fmt.Sprintf("%s", v))
+				assert.Equal(t, results[0], v)
+			}
+
+			// 之后直接命中缓存，与加载时返回相同的类型
+			v, err := cache.GetOrLoad(ctx, "key", loader, time.Hour)
+			assert.NoError(t, err)
+			assert.Equal(t, results[0], v)
+			assert.Equal(t, int32(1), calls.Load())
+		})
+	}
+}
+
+func TestGetOrLoad_Serializer(t *testing.T) {
+	ctx := context.Background()
+	serializer := NewJSONSerializer()
+	serializer.Register("user:", serializerUser{})
+	cache := NewLocalCache(serializer).(LoadingCache)
+
+	user := serializerUser{ID: 1, Name: "gopkg", Created: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}
+	loader := func(ctx context.Context) (any, error) {
+		return &user, nil
+	}
+
+	// 加载的值经过序列化后返回，与命中时相同
+	loaded, err := cache.GetOrLoad(ctx, "user:1", loader, time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, user, loaded)
+	cached, err := cache.GetOrLoad(ctx, "user:1", loader, time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, loaded, cached)
+}
+
+func TestGetOrLoad_Error(t *testing.T) {
+	ctx := context.Background()
+	loadErr := errors.New("load failed")
+
+	for name, cache := range newLoadingCaches(t) {
+		t.Run(name, func(t *testing.T) {
+			_, err := cache.GetOrLoad(ctx, "key", func(ctx context.Context) (any, error) {
+				return nil, loadErr
+			}, time.Hour)
+			assert.ErrorIs(t, err, loadErr)
+
+			// 失败不写入缓存
+			has, err := cache.Has(ctx, "key")
+			assert.NoError(t, err)
+			assert.False(t, has)
+		})
+	}
+}
+
+func TestGetOrLoad_ContextCanceled(t *testing.T) {
+	for name, cache := range newLoadingCaches(t) {
+		t.Run(name, func(t *testing.T) {
+			release := make(chan struct{})
+			loaded := make(chan struct{})
+			loader := func(ctx context.Context) (any, error) {
+				defer close(loaded)
+				<-release
+				// 调用方取消不影响加载
+				return []byte("loaded"), ctx.Err()
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			_, err := cache.GetOrLoad(ctx, "key", loader, time.Hour)
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+			close(release)
+			<-loaded
+			assert.Eventually(t, func() bool {
+				has, _ := cache.Has(context.Background(), "key")
+				return has
+			}, time.Second, time.Millisecond)
+		})
+	}
+}
+
+func TestGetOrLoad_EarlyExpiration(t *testing.T) {
+	ctx := context.Background()
+	cache := NewLocalCache(nil).(LoadingCache)
+
+	var calls atomic.Int32
+	loader := func(ctx context.Context) (any, error) {
+		calls.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		return []byte("loaded"), nil
+	}
+
+	_, err := cache.GetOrLoad(ctx, "key", loader, 40*time.Millisecond)
+	assert.NoError(t, err)
+
+	// 未开启时过期前一直命中
+	_, err = cache.GetOrLoad(ctx, "key", loader, 40*time.Millisecond)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+
+	// 加载耗时与剩余时间相当，开启后很快会提前刷新
+	deadline := time.Now().Add(30 * time.Millisecond)
+	for calls.Load() == 1 && time.Now().Before(deadline) {
+		_, err = cache.GetOrLoad(ctx, "key", loader, 40*time.Millisecond, WithEarlyExpiration(10))
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestLoadSettings_RefreshEarly(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		beta   float64
+		expire time.Time
+		delta  time.Duration
+		want   bool
+	}{
+		{name: "disabled", beta: 0, expire: now, delta: time.Hour, want: false},
+		{name: "no delta", beta: 1, expire: now.Add(time.Millisecond), delta: 0, want: false},
+		{name: "already expired", beta: 1, expire: now, delta: time.Millisecond, want: true},
+		{name: "far from expiry", beta: 1, expire: now.Add(time.Hour), delta: time.Nanosecond, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newLoadSettings([]LoadOption{WithEarlyExpiration(tt.beta)})
+			assert.Equal(t, tt.want, s.refreshEarly(tt.expire, tt.delta, now))
+		})
+	}
+}
@@ -2,8 +2,9 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -11,6 +12,39 @@ import (
 type entry struct {
 	Value      interface{}
 	ExpireTime time.Time
+	// Delta is how long the last load took, used for early expiration
+	Delta time.Duration
+}
+
+// LocalCacheConfig configures the capacity of a bounded local cache
+type LocalCacheConfig struct {
+	// MaxEntries limits the number of entries, 0 means no limit
+	MaxEntries int
+	// MaxBytes limits the approximate size of keys and serialized values, 0 means no limit
+	MaxBytes int64
+	// Policy selects which entry to evict when the cache is full
+	Policy EvictionPolicy
+	// OnEvict is called with the serialized value after an entry is evicted or expired.
+	// It is called outside of internal locks and may access the cache
+	OnEvict func(key string, value any, reason EvictionReason)
+}
+
+// LocalCacheStats is a snapshot of local cache counters
+type LocalCacheStats struct {
+	Hits   uint64
+	Misses uint64
+	// Evictions counts entries evicted for capacity
+	Evictions uint64
+	// Expirations counts expired entries removed by Get or cleanup
+	Expirations uint64
+	Entries     int
+	Bytes       int64
+}
+
+// LocalCache is a Cache kept in process memory
+type LocalCache interface {
+	Cache
+	Stats() LocalCacheStats
 }
 
 // localCache manages the query result cache
@@ -18,15 +52,34 @@ type localCache struct {
 	cleanupTicker *time.Ticker
 	cleanupStop   chan struct{}
 
-	store      sync.Map
+	store      localStore
 	serializer Serializer
+	onEvict    func(key string, value any, reason EvictionReason)
+	loads      loadGroup
+
+	hits        atomic.Uint64
+	misses      atomic.Uint64
+	evictions   atomic.Uint64
+	expirations atomic.Uint64
 }
 
-// NewLocalCache creates a new query cache with specified default TTL
+// NewLocalCache creates a new unbounded query cache
 func NewLocalCache(serializer Serializer) Cache {
+	return newLocalCache(serializer, &mapStore{}, nil)
+}
+
+// NewBoundedLocalCache creates a local cache limited by entry count and approximate byte size.
+// Operations are O(1) and the store is sharded to reduce lock contention
+func NewBoundedLocalCache(serializer Serializer, config LocalCacheConfig) LocalCache {
+	return newLocalCache(serializer, newBoundedStore(config), config.OnEvict)
+}
+
+func newLocalCache(serializer Serializer, store localStore, onEvict func(key string, value any, reason EvictionReason)) *localCache {
 	cache := &localCache{
 		cleanupStop: make(chan struct{}),
+		store:       store,
 		serializer:  serializer,
+		onEvict:     onEvict,
 	}
 
 	// Start cleanup goroutine if cache is enabled
@@ -37,57 +90,121 @@ func NewLocalCache(serializer Serializer) Cache {
 
 // Del delete a value from cache
 func (c *localCache) Del(ctx context.Context, key string) error {
-	c.store.Delete(key)
+	c.store.remove(key, nil)
 	return nil
 }
 
 // Get retrieves a value from cache
 func (c *localCache) Get(ctx context.Context, key string) (any, error) {
-	val, exists := c.store.Load(key)
+	e, err := c.getEntry(key)
+	if err != nil {
+		return nil, err
+	}
+	return c.deserialize(key, e.Value)
+}
+
+// getRaw returns the serialized value without calling the serializer
+func (c *localCache) getRaw(ctx context.Context, key string) (any, error) {
+	e, err := c.getEntry(key)
+	if err != nil {
+		return nil, err
+	}
+	return e.Value, nil
+}
+
+// getEntry returns an unexpired entry and records a hit or miss
+func (c *localCache) getEntry(key string) (*entry, error) {
+	e, exists := c.store.load(key)
 	if !exists {
+		c.misses.Add(1)
 		return nil, newKeyNotExistsError(key)
 	}
-	e := val.(*entry)
 
 	// Check if entry has expired
 	if time.Now().After(e.ExpireTime) {
-		_ = c.Del(ctx, key)
+		c.expire(key, e)
+		c.misses.Add(1)
 		return nil, newKeyNotExistsError(key)
 	}
-
-	deserialized, err := c.deserialize(key, e.Value)
-	if err != nil {
-		return nil, err
-	}
-	return deserialized, nil
+	c.hits.Add(1)
+	return e, nil
 }
 
 // Set stores a value in cache with custom TTL
 func (c *localCache) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
+	return c.set(key, value, ttl, 0)
+}
+
+func (c *localCache) set(key string, value any, ttl, delta time.Duration) error {
 	v, err := c.serialize(key, value)
 	if err != nil {
 		return err
 	}
+	return c.put(key, v, ttl, delta)
+}
+
+// setRaw stores data as the serialized value without calling the serializer
+func (c *localCache) setRaw(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	return c.put(key, data, ttl, 0)
+}
+
+// put stores an already serialized value
+func (c *localCache) put(key string, v []byte, ttl, delta time.Duration) error {
 	if ttl == 0 {
 		ttl = time.Hour * 24 * 365 * 99
 	}
 
-	c.store.Store(key, &entry{
+	evicted, err := c.store.store(key, &entry{
 		Value:      v,
 		ExpireTime: time.Now().Add(ttl),
+		Delta:      delta,
 	})
+	if err != nil {
+		return err
+	}
+	for _, n := range evicted {
+		c.evictions.Add(1)
+		c.notifyEvict(n.key, n.entry, EvictionCapacity)
+	}
 	return nil
 }
 
 // Has checks if a key exists in cache
 func (c *localCache) Has(ctx context.Context, key string) (bool, error) {
-	_, ok := c.store.Load(key)
+	_, ok := c.store.peek(key)
 	if ok {
 		return true, nil
 	}
 	return false, nil
 }
 
+// Stats returns a snapshot of cache counters
+func (c *localCache) Stats() LocalCacheStats {
+	entries, bytes := c.store.usage()
+	return LocalCacheStats{
+		Hits:        c.hits.Load(),
+		Misses:      c.misses.Load(),
+		Evictions:   c.evictions.Load(),
+		Expirations: c.expirations.Load(),
+		Entries:     entries,
+		Bytes:       bytes,
+	}
+}
+
+// expire removes an expired entry unless it has been replaced concurrently
+func (c *localCache) expire(key string, e *entry) {
+	if c.store.remove(key, e) {
+		c.expirations.Add(1)
+		c.notifyEvict(key, e, EvictionExpired)
+	}
+}
+
+func (c *localCache) notifyEvict(key string, e *entry, reason EvictionReason) {
+	if c.onEvict != nil {
+		c.onEvict(key, e.Value, reason)
+	}
+}
+
 // SetWithFunc is to set cache key which value from function return
 func (c *localCache) SetWithFunc(ctx context.Context, key string, fn func() (any, error), ttl time.Duration) (any, error) {
 	value, err := fn()
@@ -102,6 +219,79 @@ func (c *localCache) SetWithFunc(ctx context.Context, key string, fn func() (any
 	return value, nil
 }
 
+// GetOrLoad returns the cached value or loads it once for all concurrent callers.
+// Both loaded and cached values are returned deserialized, so callers see the same representation
+func (c *localCache) GetOrLoad(ctx context.Context, key string, loader LoaderFunc, ttl time.Duration, opts ...LoadOption) (any, error) {
+	return c.getOrLoad(ctx, key, loader, ttl, false, opts)
+}
+
+// getOrLoadRaw is GetOrLoad without the serializer, the loader must return []byte
+func (c *localCache) getOrLoadRaw(ctx context.Context, key string, loader LoaderFunc, ttl time.Duration, opts ...LoadOption) (any, error) {
+	return c.getOrLoad(ctx, key, loader, ttl, true, opts)
+}
+
+func (c *localCache) getOrLoad(ctx context.Context, key string, loader LoaderFunc, ttl time.Duration, raw bool, opts []LoadOption) (any, error) {
+	s := newLoadSettings(opts)
+	if v, ok, err := c.cached(key, s, raw); ok {
+		c.hits.Add(1)
+		return v, err
+	}
+	c.misses.Add(1)
+
+	// Raw and deserialized loads return different representations and must not be shared
+	group := key
+	if raw {
+		group = "raw\x00" + key
+	}
+	return c.loads.do(ctx, group, func(ctx context.Context) (any, error) {
+		v, delta, err := timedLoad(ctx, loader)
+		if err != nil {
+			return nil, err
+		}
+		var data []byte
+		if raw {
+			var ok bool
+			if data, ok = v.([]byte); !ok {
+				return nil, fmt.Errorf("cache raw loader must return []byte. key=%s, type=%T", key, v)
+			}
+		} else if data, err = c.serialize(key, v); err != nil {
+			return nil, err
+		}
+		if err := c.put(key, data, ttl, delta); err != nil {
+			return nil, err
+		}
+		if raw {
+			return data, nil
+		}
+		return c.deserialize(key, data)
+	})
+}
+
+// cached returns the value if it is present, not expired and not chosen for early refresh.
+// A value the serializer reports as missing, such as a version mismatch, is loaded again
+func (c *localCache) cached(key string, s *loadSettings, raw bool) (any, bool, error) {
+	e, ok := c.store.load(key)
+	if !ok {
+		return nil, false, nil
+	}
+	now := time.Now()
+	if now.After(e.ExpireTime) {
+		c.expire(key, e)
+		return nil, false, nil
+	}
+	if s.refreshEarly(e.ExpireTime, e.Delta, now) {
+		return nil, false, nil
+	}
+	if raw {
+		return e.Value, true, nil
+	}
+	v, err := c.deserialize(key, e.Value)
+	if errors.Is(err, KeyNotExistsError) {
+		return nil, false, nil
+	}
+	return v, true, err
+}
+
 func (c *localCache) serialize(key string, value any) ([]byte, error) {
 	if c.serializer == nil {
 		if v, ok := value.([]byte); ok {
@@ -132,9 +322,9 @@ func (c *localCache) cleanupExpired() {
 		select {
 		case <-c.cleanupTicker.C:
 			now := time.Now()
-			c.store.Range(func(k, v interface{}) bool {
-				if now.After(v.(*entry).ExpireTime) {
-					_ = c.Del(context.Background(), k.(string))
+			c.store.rangeEntries(func(k string, e *entry) bool {
+				if now.After(e.ExpireTime) {
+					c.expire(k, e)
 				}
 				return true
 			})
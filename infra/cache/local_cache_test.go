@@ -3,6 +3,7 @@ package cache
 import (
 	"context"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -372,3 +373,156 @@ func TestLocalCache_ConcurrentAccess(t *testing.T) {
 		<-done
 	}
 }
+
+func TestBoundedLocalCache_MaxEntries(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		policy EvictionPolicy
+	}{
+		{name: "lru", policy: PolicyLRU},
+		{name: "lfu", policy: PolicyLFU},
+		{name: "tinylfu", policy: PolicyTinyLFU},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var evicted []string
+			cache := NewBoundedLocalCache(nil, LocalCacheConfig{
+				MaxEntries: 10,
+				Policy:     tt.policy,
+				OnEvict: func(key string, value any, reason EvictionReason) {
+					assert.Equal(t, EvictionCapacity, reason)
+					assert.IsType(t, []byte{}, value)
+					evicted = append(evicted, key)
+				},
+			})
+
+			for i := 0; i < 30; i++ {
+				err := cache.Set(ctx, fmt.Sprintf("key-%d", i), []byte("value"), time.Hour)
+				assert.NoError(t, err)
+			}
+
+			stats := cache.Stats()
+			assert.Equal(t, 10, stats.Entries)
+			assert.Equal(t, uint64(20), stats.Evictions)
+			assert.Len(t, evicted, 20)
+
+			// 更新已存在的 key 不触发淘汰
+			before := cache.Stats().Evictions
+			for i := 0; i < 3; i++ {
+				assert.NoError(t, cache.Set(ctx, "key-29", []byte("updated"), time.Hour))
+			}
+			assert.Equal(t, before, cache.Stats().Evictions)
+		})
+	}
+}
+
+func TestBoundedLocalCache_MaxBytes(t *testing.T) {
+	ctx := context.Background()
+	cache := NewBoundedLocalCache(nil, LocalCacheConfig{MaxBytes: 4096})
+
+	value := make([]byte, 1024)
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, cache.Set(ctx, fmt.Sprintf("key-%d", i), value, time.Hour))
+	}
+	stats := cache.Stats()
+	assert.LessOrEqual(t, stats.Bytes, int64(4096))
+	assert.Equal(t, 3, stats.Entries)
+
+	// LRU 保留最近写入的条目
+	_, err := cache.Get(ctx, "key-9")
+	assert.NoError(t, err)
+	_, err = cache.Get(ctx, "key-0")
+	assert.ErrorIs(t, err, KeyNotExistsError)
+
+	// 超过容量的单个值返回错误，并删除旧值
+	assert.ErrorIs(t, cache.Set(ctx, "key-9", make([]byte, 8192), time.Hour), ValueTooLargeError)
+	has, err := cache.Has(ctx, "key-9")
+	assert.NoError(t, err)
+	assert.False(t, has)
+}
+
+func TestBoundedLocalCache_MaxBytesSharded(t *testing.T) {
+	ctx := context.Background()
+	var evicted []string
+	cache := NewBoundedLocalCache(nil, LocalCacheConfig{
+		MaxBytes: 1 << 20,
+		OnEvict: func(key string, value any, reason EvictionReason) {
+			evicted = append(evicted, key)
+		},
+	})
+
+	// 值超过单个分片的份额但不超过总容量时正常写入
+	assert.NoError(t, cache.Set(ctx, "large", make([]byte, 100<<10), time.Hour))
+	_, err := cache.Get(ctx, "large")
+	assert.NoError(t, err)
+	assert.Empty(t, evicted)
+
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, cache.Set(ctx, fmt.Sprintf("key-%d", i), make([]byte, 16<<10), time.Hour))
+	}
+	stats := cache.Stats()
+	assert.LessOrEqual(t, stats.Bytes, int64(1<<20))
+	assert.Greater(t, stats.Bytes, int64(1<<19))
+	assert.Equal(t, uint64(len(evicted)), stats.Evictions)
+
+	// 总容量已满时写入大值会从其他分片淘汰
+	assert.NoError(t, cache.Set(ctx, "larger", make([]byte, 512<<10), time.Hour))
+	_, err = cache.Get(ctx, "larger")
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, cache.Stats().Bytes, int64(1<<20))
+}
+
+func TestBoundedLocalCache_Expired(t *testing.T) {
+	ctx := context.Background()
+	reasons := make(map[string]EvictionReason)
+	cache := NewBoundedLocalCache(nil, LocalCacheConfig{
+		MaxEntries: 10,
+		OnEvict: func(key string, value any, reason EvictionReason) {
+			reasons[key] = reason
+		},
+	})
+
+	assert.NoError(t, cache.Set(ctx, "short", []byte("v"), 10*time.Millisecond))
+	assert.NoError(t, cache.Set(ctx, "long", []byte("v"), time.Hour))
+	time.Sleep(20 * time.Millisecond)
+
+	_, err := cache.Get(ctx, "short")
+	assert.ErrorIs(t, err, KeyNotExistsError)
+	_, err = cache.Get(ctx, "long")
+	assert.NoError(t, err)
+	assert.NoError(t, cache.Del(ctx, "long"))
+
+	assert.Equal(t, map[string]EvictionReason{"short": EvictionExpired}, reasons)
+	stats := cache.Stats()
+	assert.Equal(t, LocalCacheStats{Hits: 1, Misses: 1, Expirations: 1}, stats)
+}
+
+func TestBoundedLocalCache_ConcurrentAccess(t *testing.T) {
+	ctx := context.Background()
+	cache := NewBoundedLocalCache(nil, LocalCacheConfig{MaxEntries: 1000, Policy: PolicyTinyLFU})
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 2000; i++ {
+				key := fmt.Sprintf("key-%d", (i*7+g)%3000)
+				if i%3 == 0 {
+					_ = cache.Set(ctx, key, []byte(key), time.Hour)
+				} else {
+					_, _ = cache.Get(ctx, key)
+				}
+				if i%100 == 0 {
+					_ = cache.Del(ctx, key)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, cache.Stats().Entries, 1000)
+}
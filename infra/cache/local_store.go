@@ -0,0 +1,301 @@
+package cache
+
+import (
+	"hash/maphash"
+	"sync"
+	"sync/atomic"
+)
+
+// entryOverhead 估算每个条目除 key 和 value 外占用的字节数
+const entryOverhead = 96
+
+// localStore localCache 的底层存储
+type localStore interface {
+	// load 读取条目并记录一次访问
+	load(key string) (*entry, bool)
+	// peek 读取条目，不影响淘汰顺序
+	peek(key string) (*entry, bool)
+	// store 写入条目，返回因容量不足被淘汰的条目，单个条目超过总容量时返回 ValueTooLargeError
+	store(key string, e *entry) ([]*node, error)
+	// remove 删除条目，e 不为 nil 时仅在当前条目为 e 时删除
+	remove(key string, e *entry) bool
+	// rangeEntries 遍历条目快照，fn 中可以修改存储
+	rangeEntries(fn func(key string, e *entry) bool)
+	// usage 返回条目数和估算的字节数
+	usage() (int, int64)
+}
+
+var (
+	_ localStore = (*mapStore)(nil)
+	_ localStore = (*boundedStore)(nil)
+)
+
+// entrySize 估算条目占用的字节数
+func entrySize(key string, e *entry) int64 {
+	size := int64(len(key) + entryOverhead)
+	if v, ok := e.Value.([]byte); ok {
+		size += int64(len(v))
+	}
+	return size
+}
+
+// mapStore 基于 sync.Map 的无界存储
+type mapStore struct {
+	m sync.Map
+}
+
+func (s *mapStore) load(key string) (*entry, bool) {
+	return s.peek(key)
+}
+
+func (s *mapStore) peek(key string) (*entry, bool) {
+	v, ok := s.m.Load(key)
+	if !ok {
+		return nil, false
+	}
+	return v.(*entry), true
+}
+
+func (s *mapStore) store(key string, e *entry) ([]*node, error) {
+	s.m.Store(key, e)
+	return nil, nil
+}
+
+func (s *mapStore) remove(key string, e *entry) bool {
+	if e == nil {
+		_, ok := s.m.LoadAndDelete(key)
+		return ok
+	}
+	return s.m.CompareAndDelete(key, e)
+}
+
+func (s *mapStore) rangeEntries(fn func(key string, e *entry) bool) {
+	s.m.Range(func(k, v any) bool {
+		return fn(k.(string), v.(*entry))
+	})
+}
+
+func (s *mapStore) usage() (int, int64) {
+	var (
+		n     int
+		bytes int64
+	)
+	s.rangeEntries(func(key string, e *entry) bool {
+		n++
+		bytes += entrySize(key, e)
+		return true
+	})
+	return n, bytes
+}
+
+// boundedShard 有界存储的一个分片，条目数上限按分片平均分配
+type boundedShard struct {
+	mu         sync.Mutex
+	nodes      map[string]*node
+	evictor    evictor
+	maxEntries int
+	// maxBytes 为分片的平均字节份额，分片可以超出份额，只要总字节数不超过上限
+	maxBytes int64
+	bytes    int64
+	// total 指向 boundedStore 的总字节数
+	total *atomic.Int64
+}
+
+// removeNode 删除节点并更新字节数，调用方需持有锁
+func (s *boundedShard) removeNode(n *node) {
+	s.evictor.remove(n)
+	delete(s.nodes, n.key)
+	s.bytes -= n.size
+	s.total.Add(-n.size)
+}
+
+// boundedStore 分片加锁的有界存储。条目数按分片限制，字节数按所有分片的总和限制：
+// 分片超出平均份额时先淘汰自身的条目，仍超出总上限时再从其他分片淘汰
+type boundedStore struct {
+	shards   []*boundedShard
+	mask     uint64
+	seed     maphash.Seed
+	maxBytes int64
+	bytes    atomic.Int64
+}
+
+// newBoundedStore 创建有界存储，分片数保证每个分片至少能容纳 64 个条目或 64KB
+func newBoundedStore(config LocalCacheConfig) *boundedStore {
+	n := 16
+	for n > 1 && ((config.MaxEntries > 0 && config.MaxEntries/n < 64) || (config.MaxBytes > 0 && config.MaxBytes/int64(n) < 64<<10)) {
+		n /= 2
+	}
+
+	s := &boundedStore{
+		shards:   make([]*boundedShard, n),
+		mask:     uint64(n - 1),
+		seed:     maphash.MakeSeed(),
+		maxBytes: config.MaxBytes,
+	}
+	maxEntries := (config.MaxEntries + n - 1) / n
+	maxBytes := (config.MaxBytes + int64(n) - 1) / int64(n)
+	capacity := maxEntries
+	if capacity == 0 {
+		capacity = int(maxBytes / 1024)
+	}
+	for i := range s.shards {
+		s.shards[i] = &boundedShard{
+			nodes:      make(map[string]*node),
+			evictor:    newEvictor(config.Policy, capacity),
+			maxEntries: maxEntries,
+			maxBytes:   maxBytes,
+			total:      &s.bytes,
+		}
+	}
+	return s
+}
+
+// overCapacity 判断分片再加入 entries 个、共 bytes 字节的条目后是否需要淘汰自身的条目
+func (s *boundedStore) overCapacity(shard *boundedShard, entries int, bytes int64) bool {
+	if shard.maxEntries > 0 && len(shard.nodes)+entries > shard.maxEntries {
+		return true
+	}
+	return s.maxBytes > 0 && shard.bytes+bytes > shard.maxBytes && s.bytes.Load()+bytes > s.maxBytes
+}
+
+// evict 淘汰分片的条目直到能再容纳 entries 个、共 bytes 字节的条目，调用方需持有锁
+func (s *boundedStore) evict(shard *boundedShard, entries int, bytes int64) []*node {
+	var evicted []*node
+	for s.overCapacity(shard, entries, bytes) {
+		victim := shard.evictor.victim()
+		if victim == nil {
+			break
+		}
+		shard.removeNode(victim)
+		evicted = append(evicted, victim)
+	}
+	return evicted
+}
+
+// reclaim 总字节数超出上限时从 from 以外的分片淘汰，先淘汰超出平均份额的分片。
+// 每次只持有一个分片的锁，调用方不能持有任何分片的锁
+func (s *boundedStore) reclaim(from uint64) []*node {
+	var evicted []*node
+	for pass := 0; pass < 2; pass++ {
+		for i := uint64(1); i < uint64(len(s.shards)) && s.bytes.Load() > s.maxBytes; i++ {
+			shard := s.shards[(from+i)&s.mask]
+			shard.mu.Lock()
+			for s.bytes.Load() > s.maxBytes && (pass > 0 || shard.bytes > shard.maxBytes) {
+				victim := shard.evictor.victim()
+				if victim == nil {
+					break
+				}
+				shard.removeNode(victim)
+				evicted = append(evicted, victim)
+			}
+			shard.mu.Unlock()
+		}
+	}
+	return evicted
+}
+
+func (s *boundedStore) shard(key string) (*boundedShard, uint64) {
+	h := maphash.String(s.seed, key)
+	return s.shards[h&s.mask], h
+}
+
+func (s *boundedStore) load(key string) (*entry, bool) {
+	shard, _ := s.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	n, ok := shard.nodes[key]
+	if !ok {
+		return nil, false
+	}
+	shard.evictor.access(n)
+	return n.entry, true
+}
+
+func (s *boundedStore) peek(key string) (*entry, bool) {
+	shard, _ := s.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	n, ok := shard.nodes[key]
+	if !ok {
+		return nil, false
+	}
+	return n.entry, true
+}
+
+func (s *boundedStore) store(key string, e *entry) ([]*node, error) {
+	shard, h := s.shard(key)
+	size := entrySize(key, e)
+	if s.maxBytes > 0 && size > s.maxBytes {
+		// 写入失败时不保留旧值
+		s.remove(key, nil)
+		return nil, newValueTooLargeError(key, size, s.maxBytes)
+	}
+
+	shard.mu.Lock()
+	var evicted []*node
+	if n, ok := shard.nodes[key]; ok {
+		// 更新时原条目已在缓存中，值变大导致超出容量时可能淘汰自身
+		shard.bytes += size - n.size
+		s.bytes.Add(size - n.size)
+		n.entry, n.size = e, size
+		shard.evictor.access(n)
+		evicted = s.evict(shard, 0, 0)
+	} else {
+		// 先淘汰再写入，新条目不会被选为淘汰对象
+		evicted = s.evict(shard, 1, size)
+		n := &node{key: key, hash: h, entry: e, size: size}
+		shard.nodes[key] = n
+		shard.bytes += size
+		s.bytes.Add(size)
+		shard.evictor.add(n)
+	}
+	shard.mu.Unlock()
+
+	if s.maxBytes > 0 && s.bytes.Load() > s.maxBytes {
+		evicted = append(evicted, s.reclaim(h&s.mask)...)
+	}
+	return evicted, nil
+}
+
+func (s *boundedStore) remove(key string, e *entry) bool {
+	shard, _ := s.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	n, ok := shard.nodes[key]
+	if !ok || (e != nil && n.entry != e) {
+		return false
+	}
+	shard.removeNode(n)
+	return true
+}
+
+func (s *boundedStore) rangeEntries(fn func(key string, e *entry) bool) {
+	for _, shard := range s.shards {
+		shard.mu.Lock()
+		snapshot := make([]*node, 0, len(shard.nodes))
+		for _, n := range shard.nodes {
+			snapshot = append(snapshot, &node{key: n.key, entry: n.entry})
+		}
+		shard.mu.Unlock()
+
+		for _, n := range snapshot {
+			if !fn(n.key, n.entry) {
+				return
+			}
+		}
+	}
+}
+
+func (s *boundedStore) usage() (int, int64) {
+	var (
+		n     int
+		bytes int64
+	)
+	for _, shard := range s.shards {
+		shard.mu.Lock()
+		n += len(shard.nodes)
+		bytes += shard.bytes
+		shard.mu.Unlock()
+	}
+	return n, bytes
+}
@@ -0,0 +1,452 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// 确保 RedisCache 实现了 Cache 接口
+var _ Cache = (*RedisCache)(nil)
+
+// ErrRedisPoolClosed 连接池已关闭
+var ErrRedisPoolClosed = errors.New("redis pool closed")
+
+// RedisConfig RedisCache 的配置
+type RedisConfig struct {
+	// Addr Redis 地址，如 127.0.0.1:6379
+	Addr     string
+	Username string
+	Password string
+	DB       int
+	// PoolSize 最大连接数，默认 10
+	PoolSize int
+	// DialTimeout 建立连接的超时时间，默认 5 秒
+	DialTimeout time.Duration
+	// IdleTimeout 空闲连接超过该时间后关闭，默认 5 分钟
+	IdleTimeout time.Duration
+	// Serializer 为 nil 时只能存取 []byte 和 string
+	Serializer Serializer
+}
+
+// RedisCache 通过 RESP 协议访问 Redis，实现 Cache 接口。
+// 连接池中的连接按需建立，批量操作使用 pipeline 在一个连接上发送
+type RedisCache struct {
+	pool       *redisPool
+	serializer Serializer
+}
+
+// NewRedisCache 创建 RedisCache，并通过 PING 检查连接是否可用
+func NewRedisCache(ctx context.Context, config RedisConfig) (*RedisCache, error) {
+	if config.PoolSize <= 0 {
+		config.PoolSize = 10
+	}
+	if config.DialTimeout <= 0 {
+		config.DialTimeout = 5 * time.Second
+	}
+	if config.IdleTimeout <= 0 {
+		config.IdleTimeout = 5 * time.Minute
+	}
+
+	c := &RedisCache{
+		pool:       newRedisPool(config),
+		serializer: config.Serializer,
+	}
+	if _, err := c.do(ctx, "PING"); err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Get 通过键从缓存中检索值，键不存在时返回 KeyNotExistsError
+func (c *RedisCache) Get(ctx context.Context, key string) (any, error) {
+	reply, err := c.do(ctx, "GET", key)
+	if err != nil {
+		return nil, err
+	}
+	return c.decode(key, reply)
+}
+
+// getRaw 读取原始字节，不经过 Serializer
+func (c *RedisCache) getRaw(ctx context.Context, key string) (any, error) {
+	reply, err := c.do(ctx, "GET", key)
+	if err != nil {
+		return nil, err
+	}
+	return replyBytes(key, reply)
+}
+
+// setRaw 直接写入 data，不经过 Serializer
+func (c *RedisCache) setRaw(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	_, err := c.do(ctx, setArgs(key, data, ttl)...)
+	return err
+}
+
+// Set 写入键值，ttl 以毫秒精度映射为 PX，为 0 时永不过期
+func (c *RedisCache) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
+	args, err := c.setArgs(key, value, ttl)
+	if err != nil {
+		return err
+	}
+	_, err = c.do(ctx, args...)
+	return err
+}
+
+// Del 从缓存中删除一个键
+func (c *RedisCache) Del(ctx context.Context, key string) error {
+	_, err := c.do(ctx, "DEL", key)
+	return err
+}
+
+// Has 检查缓存中是否存在一个键
+func (c *RedisCache) Has(ctx context.Context, key string) (bool, error) {
+	reply, err := c.do(ctx, "EXISTS", key)
+	if err != nil {
+		return false, err
+	}
+	n, ok := reply.(int64)
+	if !ok {
+		return false, fmt.Errorf("%w: unexpected EXISTS reply %T", errRESPProtocol, reply)
+	}
+	return n > 0, nil
+}
+
+// SetWithFunc 调用 fn 并将结果写入缓存
+func (c *RedisCache) SetWithFunc(ctx context.Context, key string, fn func() (any, error), ttl time.Duration) (any, error) {
+	value, err := fn()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Set(ctx, key, value, ttl); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// GetMulti 使用 pipeline 批量读取，结果中只包含存在的键
+func (c *RedisCache) GetMulti(ctx context.Context, keys []string) (map[string]any, error) {
+	cmds := make([][]any, len(keys))
+	for i, key := range keys {
+		cmds[i] = []any{"GET", key}
+	}
+	replies, err := c.pipeline(ctx, cmds)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]any, len(keys))
+	for i, reply := range replies {
+		if err, ok := reply.(error); ok {
+			return nil, err
+		}
+		v, err := c.decode(keys[i], reply)
+		if errors.Is(err, KeyNotExistsError) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		values[keys[i]] = v
+	}
+	return values, nil
+}
+
+// SetMulti 使用 pipeline 批量写入，所有键使用相同的 ttl
+func (c *RedisCache) SetMulti(ctx context.Context, values map[string]any, ttl time.Duration) error {
+	cmds := make([][]any, 0, len(values))
+	for key, value := range values {
+		args, err := c.setArgs(key, value, ttl)
+		if err != nil {
+			return err
+		}
+		cmds = append(cmds, args)
+	}
+	replies, err := c.pipeline(ctx, cmds)
+	if err != nil {
+		return err
+	}
+	for _, reply := range replies {
+		if err, ok := reply.(error); ok {
+			return err
+		}
+	}
+	return nil
+}
+
+// DelMulti 批量删除
+func (c *RedisCache) DelMulti(ctx context.Context, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	args := make([]any, 0, len(keys)+1)
+	args = append(args, "DEL")
+	for _, key := range keys {
+		args = append(args, key)
+	}
+	_, err := c.do(ctx, args...)
+	return err
+}
+
+// Close 关闭所有连接
+func (c *RedisCache) Close() error {
+	c.pool.close()
+	return nil
+}
+
+func (c *RedisCache) setArgs(key string, value any, ttl time.Duration) ([]any, error) {
+	data, err := c.serialize(key, value)
+	if err != nil {
+		return nil, err
+	}
+	return setArgs(key, data, ttl), nil
+}
+
+// setArgs 返回写入 data 的 SET 命令
+func setArgs(key string, data []byte, ttl time.Duration) []any {
+	if ttl <= 0 {
+		return []any{"SET", key, data}
+	}
+	// 不足 1 毫秒的 ttl 按 1 毫秒处理，避免 PX 0 报错
+	return []any{"SET", key, data, "PX", max(ttl.Milliseconds(), 1)}
+}
+
+func (c *RedisCache) serialize(key string, value any) ([]byte, error) {
+	if c.serializer == nil {
+		switch v := value.(type) {
+		case []byte:
+			return v, nil
+		case string:
+			return []byte(v), nil
+		}
+		return nil, fmt.Errorf("cache serializer is nil and value is not []byte or string type")
+	}
+	return c.serializer.Serialize(key, value)
+}
+
+// decode 将 GET 回复转换为缓存值，nil 回复表示键不存在
+func (c *RedisCache) decode(key string, reply any) (any, error) {
+	data, err := replyBytes(key, reply)
+	if err != nil {
+		return nil, err
+	}
+	if c.serializer == nil {
+		return data, nil
+	}
+	return c.serializer.Deserialize(key, data)
+}
+
+// replyBytes 返回 GET 回复中的数据，nil 回复表示键不存在
+func replyBytes(key string, reply any) ([]byte, error) {
+	if reply == nil {
+		return nil, newKeyNotExistsError(key)
+	}
+	data, ok := reply.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("%w: unexpected GET reply %T", errRESPProtocol, reply)
+	}
+	return data, nil
+}
+
+// do 执行单个命令
+func (c *RedisCache) do(ctx context.Context, args ...any) (any, error) {
+	replies, err := c.pipeline(ctx, [][]any{args})
+	if err != nil {
+		return nil, err
+	}
+	if err, ok := replies[0].(error); ok {
+		return nil, err
+	}
+	return replies[0], nil
+}
+
+// pipeline 在同一个连接上先写入所有命令再依次读取回复，命令的错误回复以 RedisError 放在对应位置
+func (c *RedisCache) pipeline(ctx context.Context, cmds [][]any) ([]any, error) {
+	if len(cmds) == 0 {
+		return nil, nil
+	}
+	conn, err := c.pool.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	replies, err := conn.pipeline(ctx, cmds)
+	// 网络或协议错误后连接中可能残留未读回复，不再复用
+	c.pool.put(conn, err != nil)
+	return replies, err
+}
+
+// redisConn 连接池中的一个连接
+type redisConn struct {
+	conn     net.Conn
+	r        *bufio.Reader
+	w        *bufio.Writer
+	lastUsed time.Time
+	// broken 为 true 时连接的 deadline 已被 ctx 取消回调改写，不能再复用
+	broken bool
+}
+
+func (cn *redisConn) pipeline(ctx context.Context, cmds [][]any) ([]any, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = cn.conn.SetDeadline(deadline)
+	} else {
+		_ = cn.conn.SetDeadline(time.Time{})
+	}
+	// ctx 取消时让阻塞的读写立即返回
+	stop := context.AfterFunc(ctx, func() {
+		_ = cn.conn.SetDeadline(time.Unix(1, 0))
+	})
+
+	replies, err := cn.roundTrip(cmds)
+	// 回调已执行或正在执行时，它设置的过期 deadline 会影响下一次使用
+	if !stop() {
+		cn.broken = true
+	}
+	if err != nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return replies, err
+}
+
+func (cn *redisConn) roundTrip(cmds [][]any) ([]any, error) {
+	for _, args := range cmds {
+		if err := writeCommand(cn.w, args...); err != nil {
+			return nil, err
+		}
+	}
+	if err := cn.w.Flush(); err != nil {
+		return nil, err
+	}
+
+	replies := make([]any, len(cmds))
+	for i := range replies {
+		reply, err := readReply(cn.r)
+		var redisErr RedisError
+		switch {
+		case errors.As(err, &redisErr):
+			replies[i] = redisErr
+		case err != nil:
+			return nil, err
+		default:
+			replies[i] = reply
+		}
+	}
+	return replies, nil
+}
+
+// redisPool 限制最大连接数的连接池，空闲连接后进先出复用
+type redisPool struct {
+	config RedisConfig
+	// slots 容量为 PoolSize，获取连接前需要占用一个位置
+	slots chan struct{}
+
+	mu     sync.Mutex
+	idle   []*redisConn
+	closed bool
+}
+
+func newRedisPool(config RedisConfig) *redisPool {
+	return &redisPool{
+		config: config,
+		slots:  make(chan struct{}, config.PoolSize),
+	}
+}
+
+func (p *redisPool) get(ctx context.Context) (*redisConn, error) {
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		<-p.slots
+		return nil, ErrRedisPoolClosed
+	}
+	for len(p.idle) > 0 {
+		cn := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		if time.Since(cn.lastUsed) < p.config.IdleTimeout {
+			p.mu.Unlock()
+			return cn, nil
+		}
+		_ = cn.conn.Close()
+	}
+	p.mu.Unlock()
+
+	cn, err := p.dial(ctx)
+	if err != nil {
+		<-p.slots
+		return nil, err
+	}
+	return cn, nil
+}
+
+func (p *redisPool) put(cn *redisConn, broken bool) {
+	defer func() { <-p.slots }()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if broken || cn.broken || p.closed {
+		_ = cn.conn.Close()
+		return
+	}
+	cn.lastUsed = time.Now()
+	p.idle = append(p.idle, cn)
+}
+
+func (p *redisPool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	for _, cn := range p.idle {
+		_ = cn.conn.Close()
+	}
+	p.idle = nil
+}
+
+// dial 建立连接并完成认证和选择数据库
+func (p *redisPool) dial(ctx context.Context) (*redisConn, error) {
+	dialer := net.Dialer{Timeout: p.config.DialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", p.config.Addr)
+	if err != nil {
+		return nil, err
+	}
+	cn := &redisConn{conn: conn, r: bufio.NewReader(conn), w: bufio.NewWriter(conn)}
+
+	var cmds [][]any
+	if p.config.Password != "" {
+		if p.config.Username != "" {
+			cmds = append(cmds, []any{"AUTH", p.config.Username, p.config.Password})
+		} else {
+			cmds = append(cmds, []any{"AUTH", p.config.Password})
+		}
+	}
+	if p.config.DB != 0 {
+		cmds = append(cmds, []any{"SELECT", p.config.DB})
+	}
+	if len(cmds) > 0 {
+		replies, err := cn.pipeline(ctx, cmds)
+		if err == nil && cn.broken {
+			err = context.Cause(ctx)
+		}
+		if err == nil {
+			for _, reply := range replies {
+				if e, ok := reply.(error); ok {
+					err = e
+					break
+				}
+			}
+		}
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+	return cn, nil
+}
@@ -0,0 +1,265 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRedisCache(t *testing.T, config RedisConfig) (*RedisCache, *fakeRedis) {
+	server := newFakeRedis(t, config.Password)
+	config.Addr = server.addr()
+	cache, err := NewRedisCache(context.Background(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = cache.Close() })
+	return cache, server
+}
+
+func TestRedisCache_Set_Get(t *testing.T) {
+	ctx := context.Background()
+	cache, _ := newTestRedisCache(t, RedisConfig{})
+
+	tests := []struct {
+		name    string
+		key     string
+		value   any
+		want    any
+		wantErr bool
+	}{
+		{name: "bytes", key: "k1", value: []byte("v1"), want: []byte("v1")},
+		{name: "string", key: "k2", value: "v2", want: []byte("v2")},
+		{name: "binary", key: "k3", value: []byte("a\r\nb\x00"), want: []byte("a\r\nb\x00")},
+		{name: "unsupported without serializer", key: "k4", value: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := cache.Set(ctx, tt.key, tt.value, time.Hour)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			got, err := cache.Get(ctx, tt.key)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			has, err := cache.Has(ctx, tt.key)
+			assert.NoError(t, err)
+			assert.True(t, has)
+
+			assert.NoError(t, cache.Del(ctx, tt.key))
+			_, err = cache.Get(ctx, tt.key)
+			assert.ErrorIs(t, err, KeyNotExistsError)
+		})
+	}
+}
+
+func TestRedisCache_TTL(t *testing.T) {
+	ctx := context.Background()
+	cache, _ := newTestRedisCache(t, RedisConfig{})
+
+	assert.NoError(t, cache.Set(ctx, "short", []byte("v"), 30*time.Millisecond))
+	assert.NoError(t, cache.Set(ctx, "tiny", []byte("v"), time.Microsecond))
+	assert.NoError(t, cache.Set(ctx, "forever", []byte("v"), 0))
+
+	ttl, err := cache.do(ctx, "PTTL", "short")
+	assert.NoError(t, err)
+	assert.InDelta(t, 30, ttl, 10)
+	ttl, err = cache.do(ctx, "PTTL", "forever")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-1), ttl)
+
+	time.Sleep(50 * time.Millisecond)
+	has, err := cache.Has(ctx, "short")
+	assert.NoError(t, err)
+	assert.False(t, has)
+	has, err = cache.Has(ctx, "forever")
+	assert.NoError(t, err)
+	assert.True(t, has)
+}
+
+func TestRedisCache_Serializer(t *testing.T) {
+	ctx := context.Background()
+	serializer := NewJSONSerializer()
+	serializer.Register("user:", serializerUser{})
+	cache, _ := newTestRedisCache(t, RedisConfig{Serializer: serializer})
+
+	user := serializerUser{ID: 1, Name: "gopkg", Created: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}
+	assert.NoError(t, cache.Set(ctx, "user:1", user, time.Hour))
+	got, err := cache.Get(ctx, "user:1")
+	assert.NoError(t, err)
+	assert.Equal(t, user, got)
+
+	users := NewTypedPassthrough[serializerUser](cache)
+	got, err = users.Get(ctx, "user:1")
+	assert.NoError(t, err)
+	assert.Equal(t, user, got)
+
+	// Typed 编码后的字节不再经过 Serializer
+	typed := NewTyped[serializerUser](cache, nil)
+	assert.NoError(t, typed.Set(ctx, "other:1", user, time.Hour))
+	got, err = typed.Get(ctx, "other:1")
+	assert.NoError(t, err)
+	assert.Equal(t, user, got)
+}
+
+func TestRedisCache_Pipeline(t *testing.T) {
+	ctx := context.Background()
+	cache, server := newTestRedisCache(t, RedisConfig{})
+
+	values := make(map[string]any)
+	keys := make([]string, 0, 100)
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		values[key] = []byte(key)
+		keys = append(keys, key)
+	}
+	assert.NoError(t, cache.SetMulti(ctx, values, time.Hour))
+	assert.Greater(t, server.pipelined.Load(), int32(0))
+
+	got, err := cache.GetMulti(ctx, append(keys, "missing"))
+	assert.NoError(t, err)
+	assert.Len(t, got, 100)
+	assert.Equal(t, []byte("key-42"), got["key-42"])
+
+	assert.NoError(t, cache.DelMulti(ctx, keys[:50]))
+	got, err = cache.GetMulti(ctx, keys)
+	assert.NoError(t, err)
+	assert.Len(t, got, 50)
+
+	// 单条命令的错误回复不影响连接复用
+	_, err = cache.do(ctx, "UNKNOWN")
+	var redisErr RedisError
+	assert.ErrorAs(t, err, &redisErr)
+	assert.True(t, strings.HasPrefix(redisErr.Error(), "ERR unknown command"))
+	_, err = cache.Get(ctx, "key-99")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), server.conns.Load())
+}
+
+func TestRedisCache_Pool(t *testing.T) {
+	ctx := context.Background()
+	cache, server := newTestRedisCache(t, RedisConfig{PoolSize: 3})
+	server.delay.Store(int64(5 * time.Millisecond))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := fmt.Sprintf("key-%d", i)
+			assert.NoError(t, cache.Set(ctx, key, []byte(key), time.Hour))
+			got, err := cache.Get(ctx, key)
+			assert.NoError(t, err)
+			assert.Equal(t, []byte(key), got)
+		}(i)
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, server.conns.Load(), int32(3))
+
+	// 等待连接超时
+	timeoutCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	server.delay.Store(int64(100 * time.Millisecond))
+	for i := 0; i < 3; i++ {
+		go func() { _, _ = cache.Get(ctx, "key-0") }()
+	}
+	time.Sleep(10 * time.Millisecond)
+	_, err := cache.Get(timeoutCtx, "key-0")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRedisCache_ContextCanceled(t *testing.T) {
+	cache, server := newTestRedisCache(t, RedisConfig{})
+	server.delay.Store(int64(200 * time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := cache.Get(ctx, "key")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), 150*time.Millisecond)
+
+	// 被中断的连接不再复用
+	server.delay.Store(0)
+	_, err = cache.Get(context.Background(), "key")
+	assert.ErrorIs(t, err, KeyNotExistsError)
+	assert.Equal(t, int32(2), server.conns.Load())
+}
+
+func TestRedisConn_CanceledAfterRoundTrip(t *testing.T) {
+	cache, server := newTestRedisCache(t, RedisConfig{})
+	conn, err := cache.pool.get(context.Background())
+	assert.NoError(t, err)
+
+	// ctx 已取消时回调可能在命令完成后才执行，连接不能放回连接池
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _ = conn.pipeline(ctx, [][]any{{"PING"}})
+	assert.True(t, conn.broken)
+	cache.pool.put(conn, false)
+
+	_, err = cache.do(context.Background(), "PING")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), server.conns.Load())
+}
+
+func TestRedisCache_AuthAndDB(t *testing.T) {
+	ctx := context.Background()
+	cache, server := newTestRedisCache(t, RedisConfig{Password: "secret", DB: 2})
+	assert.NoError(t, cache.Set(ctx, "k", []byte("v"), time.Hour))
+
+	server.mu.Lock()
+	_, ok := server.data[2]["k"]
+	server.mu.Unlock()
+	assert.True(t, ok)
+
+	_, err := NewRedisCache(ctx, RedisConfig{Addr: server.addr(), Password: "wrong"})
+	var redisErr RedisError
+	assert.ErrorAs(t, err, &redisErr)
+
+	_, err = NewRedisCache(ctx, RedisConfig{Addr: server.addr()})
+	assert.ErrorAs(t, err, &redisErr)
+	assert.True(t, strings.HasPrefix(redisErr.Error(), "NOAUTH"))
+}
+
+func TestReadReply(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    any
+		wantErr bool
+	}{
+		{name: "simple string", data: "+OK\r\n", want: "OK"},
+		{name: "integer", data: ":-12\r\n", want: int64(-12)},
+		{name: "bulk string", data: "$3\r\nabc\r\n", want: []byte("abc")},
+		{name: "nil bulk", data: "$-1\r\n", want: nil},
+		{name: "array", data: "*3\r\n$1\r\na\r\n:1\r\n-ERR x\r\n", want: []any{[]byte("a"), int64(1), RedisError("ERR x")}},
+		{name: "error", data: "-ERR bad\r\n", wantErr: true},
+		{name: "missing crlf", data: "+OK\n", wantErr: true},
+		{name: "short bulk", data: "$5\r\nab\r\n", wantErr: true},
+		{name: "unknown type", data: "?x\r\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readReply(bufio.NewReader(strings.NewReader(tt.data)))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
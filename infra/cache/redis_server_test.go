@@ -0,0 +1,184 @@
+package cache
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeRedis 进程内的 RESP 服务端，支持 RedisCache 用到的命令
+type fakeRedis struct {
+	ln       net.Listener
+	password string
+
+	mu   sync.Mutex
+	data map[int]map[string]fakeRedisValue
+
+	// conns 累计建立的连接数，pipelined 为读取命令时缓冲区中已有后续命令的次数
+	conns     atomic.Int32
+	pipelined atomic.Int32
+	// delay 每条命令处理前等待的时间
+	delay atomic.Int64
+}
+
+type fakeRedisValue struct {
+	data     []byte
+	expireAt time.Time
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeRedis{ln: ln, password: password, data: make(map[int]map[string]fakeRedisValue)}
+	go s.serve()
+	t.Cleanup(func() { _ = ln.Close() })
+	return s
+}
+
+func (s *fakeRedis) addr() string {
+	return s.ln.Addr().String()
+}
+
+func (s *fakeRedis) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.conns.Add(1)
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	authed := s.password == ""
+	db := 0
+
+	for {
+		reply, err := readReply(r)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				fmt.Fprintf(w, "-ERR %s\r\n", err)
+				_ = w.Flush()
+			}
+			return
+		}
+		if r.Buffered() > 0 {
+			s.pipelined.Add(1)
+		}
+		if d := s.delay.Load(); d > 0 {
+			time.Sleep(time.Duration(d))
+		}
+
+		arr, _ := reply.([]any)
+		args := make([]string, len(arr))
+		for i, a := range arr {
+			b, _ := a.([]byte)
+			args[i] = string(b)
+		}
+		if len(args) == 0 {
+			w.WriteString("-ERR empty command\r\n")
+		} else {
+			name := strings.ToUpper(args[0])
+			switch {
+			case name == "AUTH":
+				if args[len(args)-1] == s.password {
+					authed = true
+					w.WriteString("+OK\r\n")
+				} else {
+					w.WriteString("-WRONGPASS invalid username-password pair\r\n")
+				}
+			case !authed:
+				w.WriteString("-NOAUTH Authentication required.\r\n")
+			case name == "SELECT":
+				db, _ = strconv.Atoi(args[1])
+				w.WriteString("+OK\r\n")
+			default:
+				s.exec(w, db, name, args[1:])
+			}
+		}
+		// 缓冲区中还有命令时继续读取，模拟 Redis 对 pipeline 的批量回复
+		if r.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *fakeRedis) exec(w *bufio.Writer, db int, name string, args []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data[db] == nil {
+		s.data[db] = make(map[string]fakeRedisValue)
+	}
+	data := s.data[db]
+	get := func(key string) (fakeRedisValue, bool) {
+		v, ok := data[key]
+		if ok && !v.expireAt.IsZero() && time.Now().After(v.expireAt) {
+			delete(data, key)
+			return v, false
+		}
+		return v, ok
+	}
+
+	switch name {
+	case "PING":
+		w.WriteString("+PONG\r\n")
+	case "GET":
+		v, ok := get(args[0])
+		if !ok {
+			w.WriteString("$-1\r\n")
+			return
+		}
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(v.data), v.data)
+	case "SET":
+		v := fakeRedisValue{data: []byte(args[1])}
+		if len(args) == 4 && strings.ToUpper(args[2]) == "PX" {
+			ms, err := strconv.Atoi(args[3])
+			if err != nil || ms <= 0 {
+				w.WriteString("-ERR invalid expire time in 'set' command\r\n")
+				return
+			}
+			v.expireAt = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		}
+		data[args[0]] = v
+		w.WriteString("+OK\r\n")
+	case "DEL", "EXISTS":
+		n := 0
+		for _, key := range args {
+			if _, ok := get(key); ok {
+				n++
+				if name == "DEL" {
+					delete(data, key)
+				}
+			}
+		}
+		fmt.Fprintf(w, ":%d\r\n", n)
+	case "PTTL":
+		v, ok := get(args[0])
+		switch {
+		case !ok:
+			w.WriteString(":-2\r\n")
+		case v.expireAt.IsZero():
+			w.WriteString(":-1\r\n")
+		default:
+			fmt.Fprintf(w, ":%d\r\n", time.Until(v.expireAt).Milliseconds())
+		}
+	default:
+		fmt.Fprintf(w, "-ERR unknown command '%s'\r\n", name)
+	}
+}
@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// respMaxBulkLen 单个 bulk string 的最大长度，与 Redis 的 proto-max-bulk-len 默认值一致
+const respMaxBulkLen = 512 << 20
+
+// RedisError Redis 返回的错误回复，如 "WRONGTYPE Operation against a key holding the wrong kind of value"
+type RedisError string
+
+func (e RedisError) Error() string {
+	return string(e)
+}
+
+var errRESPProtocol = errors.New("redis protocol error")
+
+// writeCommand 以 RESP 数组写入命令，参数支持 string、[]byte 和整数
+func writeCommand(w *bufio.Writer, args ...any) error {
+	w.WriteByte('*')
+	w.WriteString(strconv.Itoa(len(args)))
+	w.WriteString("\r\n")
+	for _, arg := range args {
+		var b []byte
+		switch v := arg.(type) {
+		case string:
+			b = []byte(v)
+		case []byte:
+			b = v
+		case int:
+			b = strconv.AppendInt(nil, int64(v), 10)
+		case int64:
+			b = strconv.AppendInt(nil, v, 10)
+		default:
+			return fmt.Errorf("redis: unsupported argument type %T", arg)
+		}
+		w.WriteByte('$')
+		w.WriteString(strconv.Itoa(len(b)))
+		w.WriteString("\r\n")
+		w.Write(b)
+		if _, err := w.WriteString("\r\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readReply 读取一个 RESP2 回复，返回 string（简单字符串）、[]byte（bulk string）、int64、[]any 或 nil。
+// 错误回复作为 RedisError 返回，此时连接仍然可用
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, fmt.Errorf("%w: empty reply", errRESPProtocol)
+	}
+
+	switch line[0] {
+	case '+':
+		return string(line[1:]), nil
+	case '-':
+		return nil, RedisError(line[1:])
+	case ':':
+		n, err := strconv.ParseInt(string(line[1:]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid integer %q", errRESPProtocol, line[1:])
+		}
+		return n, nil
+	case '$':
+		n, err := parseLength(line)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		if n > respMaxBulkLen {
+			return nil, fmt.Errorf("%w: bulk length %d too large", errRESPProtocol, n)
+		}
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		if b[n] != '\r' || b[n+1] != '\n' {
+			return nil, fmt.Errorf("%w: bulk string not terminated", errRESPProtocol)
+		}
+		return b[:n], nil
+	case '*':
+		n, err := parseLength(line)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		arr := make([]any, 0, min(n, 1024))
+		for i := 0; i < n; i++ {
+			v, err := readReply(r)
+			var redisErr RedisError
+			if err != nil && !errors.As(err, &redisErr) {
+				return nil, err
+			}
+			if err != nil {
+				v = redisErr
+			}
+			arr = append(arr, v)
+		}
+		return arr, nil
+	default:
+		return nil, fmt.Errorf("%w: unexpected reply type %q", errRESPProtocol, line[0])
+	}
+}
+
+// readLine 读取以 \r\n 结尾的一行，不包含 \r\n
+func readLine(r *bufio.Reader) ([]byte, error) {
+	line, err := r.ReadSlice('\n')
+	if err != nil {
+		if errors.Is(err, bufio.ErrBufferFull) {
+			return nil, fmt.Errorf("%w: line too long", errRESPProtocol)
+		}
+		return nil, err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("%w: line not terminated by CRLF", errRESPProtocol)
+	}
+	return line[:len(line)-2], nil
+}
+
+// parseLength 解析 bulk string 或数组的长度，-1 表示 nil
+func parseLength(line []byte) (int, error) {
+	n, err := strconv.Atoi(string(line[1:]))
+	if err != nil || n < -1 {
+		return 0, fmt.Errorf("%w: invalid length %q", errRESPProtocol, line[1:])
+	}
+	return n, nil
+}
@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var (
+	_ Serializer = (*JSONSerializer)(nil)
+	_ Serializer = (*GobSerializer)(nil)
+)
+
+// registry 按 key 前缀登记反序列化的目标类型，匹配时取最长前缀
+type registry struct {
+	mu    sync.RWMutex
+	types map[string]reflect.Type
+}
+
+// Register 登记 key 以 prefix 开头时反序列化的目标类型，target 为该类型的零值，
+// 如 User{} 反序列化为 User，(*User)(nil) 反序列化为 *User。prefix 为空时匹配所有 key
+func (r *registry) Register(prefix string, target any) {
+	t := reflect.TypeOf(target)
+	if t == nil {
+		panic("cache: register nil target type")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.types == nil {
+		r.types = make(map[string]reflect.Type)
+	}
+	r.types[prefix] = t
+}
+
+// lookup 返回 key 对应的目标类型
+func (r *registry) lookup(key string) (reflect.Type, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var (
+		found   reflect.Type
+		longest = -1
+	)
+	for prefix, t := range r.types {
+		if len(prefix) > longest && strings.HasPrefix(key, prefix) {
+			found, longest = t, len(prefix)
+		}
+	}
+	return found, found != nil
+}
+
+// decodeInto 创建 t 类型的值并调用 decode 填充，t 为指针类型时返回指针
+func decodeInto(t reflect.Type, decode func(ptr any) error) (any, error) {
+	if t.Kind() == reflect.Pointer {
+		ptr := reflect.New(t.Elem())
+		if err := decode(ptr.Interface()); err != nil {
+			return nil, err
+		}
+		return ptr.Interface(), nil
+	}
+	ptr := reflect.New(t)
+	if err := decode(ptr.Interface()); err != nil {
+		return nil, err
+	}
+	return ptr.Elem().Interface(), nil
+}
+
+// toBytes 将缓存中保存的原始数据转换为 []byte，SqliteCache 读出的是 string
+func toBytes(key string, data any) ([]byte, error) {
+	switch v := data.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	default:
+		return nil, fmt.Errorf("cache data is not []byte. key=%s, type=%T", key, data)
+	}
+}
+
+// JSONSerializer 使用 encoding/json 序列化，未登记类型的 key 反序列化为 any
+type JSONSerializer struct {
+	registry
+}
+
+// NewJSONSerializer 创建 JSONSerializer
+func NewJSONSerializer() *JSONSerializer {
+	return &JSONSerializer{}
+}
+
+func (s *JSONSerializer) Serialize(key string, data interface{}) ([]byte, error) {
+	return json.Marshal(data)
+}
+
+func (s *JSONSerializer) Deserialize(key string, data any) (any, error) {
+	b, err := toBytes(key, data)
+	if err != nil {
+		return nil, err
+	}
+	t, ok := s.lookup(key)
+	if !ok {
+		var v any
+		if err := json.Unmarshal(b, &v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+	return decodeInto(t, func(ptr any) error {
+		return json.Unmarshal(b, ptr)
+	})
+}
+
+// GobSerializer 使用 encoding/gob 序列化，反序列化前必须登记目标类型
+type GobSerializer struct {
+	registry
+}
+
+// NewGobSerializer 创建 GobSerializer
+func NewGobSerializer() *GobSerializer {
+	return &GobSerializer{}
+}
+
+func (s *GobSerializer) Serialize(key string, data interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *GobSerializer) Deserialize(key string, data any) (any, error) {
+	b, err := toBytes(key, data)
+	if err != nil {
+		return nil, err
+	}
+	t, ok := s.lookup(key)
+	if !ok {
+		return nil, newTypeNotRegisteredError(key)
+	}
+	return decodeInto(t, func(ptr any) error {
+		return gob.NewDecoder(bytes.NewReader(b)).Decode(ptr)
+	})
+}
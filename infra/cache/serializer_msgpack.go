@@ -0,0 +1,787 @@
+package cache
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var _ Serializer = (*MsgpackSerializer)(nil)
+
+// MsgpackSerializer 使用 MessagePack 格式的紧凑二进制序列化。
+// 结构体按字段名编码为 map，可用 `msgpack:"name,omitempty"` 标签调整，实现了
+// encoding.BinaryMarshaler 的类型（如 time.Time）编码为 bin。
+// 未登记类型的 key 反序列化为 nil、bool、int64、uint64、float64、string、[]byte、[]any 或 map[string]any
+type MsgpackSerializer struct {
+	registry
+}
+
+// NewMsgpackSerializer 创建 MsgpackSerializer
+func NewMsgpackSerializer() *MsgpackSerializer {
+	return &MsgpackSerializer{}
+}
+
+func (s *MsgpackSerializer) Serialize(key string, data interface{}) ([]byte, error) {
+	return msgpackMarshal(data)
+}
+
+func (s *MsgpackSerializer) Deserialize(key string, data any) (any, error) {
+	b, err := toBytes(key, data)
+	if err != nil {
+		return nil, err
+	}
+	t, ok := s.lookup(key)
+	if !ok {
+		d := &msgpackDecoder{data: b}
+		v, err := d.decodeAny()
+		if err != nil {
+			return nil, err
+		}
+		return v, d.finish()
+	}
+	return decodeInto(t, func(ptr any) error {
+		return msgpackUnmarshal(b, ptr)
+	})
+}
+
+// MessagePack 格式的类型标记
+const (
+	mpNil      byte = 0xc0
+	mpFalse    byte = 0xc2
+	mpTrue     byte = 0xc3
+	mpBin8     byte = 0xc4
+	mpBin16    byte = 0xc5
+	mpBin32    byte = 0xc6
+	mpFloat32  byte = 0xca
+	mpFloat64  byte = 0xcb
+	mpUint8    byte = 0xcc
+	mpUint16   byte = 0xcd
+	mpUint32   byte = 0xce
+	mpUint64   byte = 0xcf
+	mpInt8     byte = 0xd0
+	mpInt16    byte = 0xd1
+	mpInt32    byte = 0xd2
+	mpInt64    byte = 0xd3
+	mpStr8     byte = 0xd9
+	mpStr16    byte = 0xda
+	mpStr32    byte = 0xdb
+	mpArray16  byte = 0xdc
+	mpArray32  byte = 0xdd
+	mpMap16    byte = 0xde
+	mpMap32    byte = 0xdf
+	mpFixMap   byte = 0x80
+	mpFixArray byte = 0x90
+	mpFixStr   byte = 0xa0
+)
+
+var errMsgpackShort = errors.New("msgpack: unexpected end of data")
+
+var (
+	binaryMarshalerType   = reflect.TypeFor[encoding.BinaryMarshaler]()
+	binaryUnmarshalerType = reflect.TypeFor[encoding.BinaryUnmarshaler]()
+)
+
+// msgpackField 结构体中参与编码的字段
+type msgpackField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+// msgpackFieldCache 缓存每个结构体类型的字段列表
+var msgpackFieldCache sync.Map
+
+// msgpackFields 返回结构体导出字段，匿名嵌入且没有标签的结构体字段会被展开
+func msgpackFields(t reflect.Type) []msgpackField {
+	if cached, ok := msgpackFieldCache.Load(t); ok {
+		return cached.([]msgpackField)
+	}
+	var (
+		fields   []msgpackField
+		embedded []msgpackField
+		seen     = make(map[string]bool)
+	)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("msgpack")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && tag == "" && f.Type.Kind() == reflect.Struct {
+			for _, ef := range msgpackFields(f.Type) {
+				ef.index = append([]int{i}, ef.index...)
+				embedded = append(embedded, ef)
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		seen[name] = true
+		fields = append(fields, msgpackField{name: name, index: []int{i}, omitEmpty: opts == "omitempty"})
+	}
+	// 外层字段优先于嵌入结构体中的同名字段
+	for _, ef := range embedded {
+		if !seen[ef.name] {
+			seen[ef.name] = true
+			fields = append(fields, ef)
+		}
+	}
+	msgpackFieldCache.Store(t, fields)
+	return fields
+}
+
+func msgpackMarshal(v any) ([]byte, error) {
+	e := &msgpackEncoder{}
+	if err := e.encode(reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+func msgpackUnmarshal(data []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("msgpack: unmarshal target must be a non-nil pointer, got %T", v)
+	}
+	d := &msgpackDecoder{data: data}
+	if err := d.decode(rv.Elem()); err != nil {
+		return err
+	}
+	return d.finish()
+}
+
+type msgpackEncoder struct {
+	buf []byte
+}
+
+func (e *msgpackEncoder) encode(v reflect.Value) error {
+	if !v.IsValid() {
+		e.buf = append(e.buf, mpNil)
+		return nil
+	}
+	if v.Type().Implements(binaryMarshalerType) {
+		// 值为 nil 的指针和接口无法调用 MarshalBinary
+		if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil() {
+			e.buf = append(e.buf, mpNil)
+			return nil
+		}
+		b, err := v.Interface().(encoding.BinaryMarshaler).MarshalBinary()
+		if err != nil {
+			return err
+		}
+		e.writeBin(b)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			e.buf = append(e.buf, mpTrue)
+		} else {
+			e.buf = append(e.buf, mpFalse)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.writeInt(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.writeUint(v.Uint())
+	case reflect.Float32:
+		e.buf = append(e.buf, mpFloat32)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, math.Float32bits(float32(v.Float())))
+	case reflect.Float64:
+		e.buf = append(e.buf, mpFloat64)
+		e.buf = binary.BigEndian.AppendUint64(e.buf, math.Float64bits(v.Float()))
+	case reflect.String:
+		e.writeString(v.String())
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			e.buf = append(e.buf, mpNil)
+			return nil
+		}
+		return e.encode(v.Elem())
+	case reflect.Slice:
+		if v.IsNil() {
+			e.buf = append(e.buf, mpNil)
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			e.writeBin(v.Bytes())
+			return nil
+		}
+		return e.encodeArray(v)
+	case reflect.Array:
+		return e.encodeArray(v)
+	case reflect.Map:
+		if v.IsNil() {
+			e.buf = append(e.buf, mpNil)
+			return nil
+		}
+		e.writeHeader(v.Len(), mpFixMap, 16, mpMap16, mpMap32)
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := e.encode(iter.Key()); err != nil {
+				return err
+			}
+			if err := e.encode(iter.Value()); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		return e.encodeStruct(v)
+	default:
+		return fmt.Errorf("msgpack: unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func (e *msgpackEncoder) encodeArray(v reflect.Value) error {
+	e.writeHeader(v.Len(), mpFixArray, 16, mpArray16, mpArray32)
+	for i := 0; i < v.Len(); i++ {
+		if err := e.encode(v.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *msgpackEncoder) encodeStruct(v reflect.Value) error {
+	fields := msgpackFields(v.Type())
+	values := make([]reflect.Value, 0, len(fields))
+	names := make([]string, 0, len(fields))
+	for _, f := range fields {
+		fv := v.FieldByIndex(f.index)
+		if f.omitEmpty && fv.IsZero() {
+			continue
+		}
+		values = append(values, fv)
+		names = append(names, f.name)
+	}
+	e.writeHeader(len(values), mpFixMap, 16, mpMap16, mpMap32)
+	for i, fv := range values {
+		e.writeString(names[i])
+		if err := e.encode(fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *msgpackEncoder) writeInt(n int64) {
+	switch {
+	case n >= 0:
+		e.writeUint(uint64(n))
+	case n >= -32:
+		e.buf = append(e.buf, byte(int8(n)))
+	case n >= math.MinInt8:
+		e.buf = append(e.buf, mpInt8, byte(int8(n)))
+	case n >= math.MinInt16:
+		e.buf = append(e.buf, mpInt16)
+		e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(n))
+	case n >= math.MinInt32:
+		e.buf = append(e.buf, mpInt32)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(n))
+	default:
+		e.buf = append(e.buf, mpInt64)
+		e.buf = binary.BigEndian.AppendUint64(e.buf, uint64(n))
+	}
+}
+
+func (e *msgpackEncoder) writeUint(n uint64) {
+	switch {
+	case n <= 0x7f:
+		e.buf = append(e.buf, byte(n))
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, mpUint8, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, mpUint16)
+		e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(n))
+	case n <= math.MaxUint32:
+		e.buf = append(e.buf, mpUint32)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(n))
+	default:
+		e.buf = append(e.buf, mpUint64)
+		e.buf = binary.BigEndian.AppendUint64(e.buf, n)
+	}
+}
+
+func (e *msgpackEncoder) writeString(s string) {
+	n := len(s)
+	switch {
+	case n < 32:
+		e.buf = append(e.buf, mpFixStr|byte(n))
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, mpStr8, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, mpStr16)
+		e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(n))
+	default:
+		e.buf = append(e.buf, mpStr32)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(n))
+	}
+	e.buf = append(e.buf, s...)
+}
+
+func (e *msgpackEncoder) writeBin(b []byte) {
+	n := len(b)
+	switch {
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, mpBin8, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, mpBin16)
+		e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(n))
+	default:
+		e.buf = append(e.buf, mpBin32)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(n))
+	}
+	e.buf = append(e.buf, b...)
+}
+
+// writeHeader 写入 array 或 map 的长度，长度小于 fixMax 时使用 fix 格式
+func (e *msgpackEncoder) writeHeader(n int, fix byte, fixMax int, tag16, tag32 byte) {
+	switch {
+	case n < fixMax:
+		e.buf = append(e.buf, fix|byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, tag16)
+		e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(n))
+	default:
+		e.buf = append(e.buf, tag32)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(n))
+	}
+}
+
+type msgpackDecoder struct {
+	data []byte
+	pos  int
+}
+
+// finish 检查数据是否已全部读取
+func (d *msgpackDecoder) finish() error {
+	if d.pos != len(d.data) {
+		return fmt.Errorf("msgpack: %d trailing bytes", len(d.data)-d.pos)
+	}
+	return nil
+}
+
+func (d *msgpackDecoder) peek() (byte, error) {
+	if d.pos >= len(d.data) {
+		return 0, errMsgpackShort
+	}
+	return d.data[d.pos], nil
+}
+
+func (d *msgpackDecoder) read(n int) ([]byte, error) {
+	if n < 0 || len(d.data)-d.pos < n {
+		return nil, errMsgpackShort
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+// readLen 读取 size 字节的大端长度
+func (d *msgpackDecoder) readLen(size int) (int, error) {
+	b, err := d.read(size)
+	if err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return int(b[0]), nil
+	case 2:
+		return int(binary.BigEndian.Uint16(b)), nil
+	default:
+		return int(binary.BigEndian.Uint32(b)), nil
+	}
+}
+
+// readCollection 读取 array 或 map 的长度，每个元素至少占用 1 字节，长度超过剩余数据时视为数据损坏
+func (d *msgpackDecoder) readCollection(tag byte, fix byte, tag16, tag32 byte, perItem int) (int, bool, error) {
+	var (
+		n   int
+		err error
+	)
+	switch {
+	case tag&0xf0 == fix:
+		n = int(tag & 0x0f)
+	case tag == tag16:
+		n, err = d.readLen(2)
+	case tag == tag32:
+		n, err = d.readLen(4)
+	default:
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, true, err
+	}
+	if n*perItem > len(d.data)-d.pos {
+		return 0, true, errMsgpackShort
+	}
+	return n, true, nil
+}
+
+// readBytes 读取 str 或 bin
+func (d *msgpackDecoder) readBytes(tag byte) ([]byte, bool, error) {
+	var (
+		n   int
+		err error
+	)
+	switch {
+	case tag&0xe0 == mpFixStr:
+		n = int(tag & 0x1f)
+	case tag == mpStr8, tag == mpBin8:
+		n, err = d.readLen(1)
+	case tag == mpStr16, tag == mpBin16:
+		n, err = d.readLen(2)
+	case tag == mpStr32, tag == mpBin32:
+		n, err = d.readLen(4)
+	default:
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, true, err
+	}
+	b, err := d.read(n)
+	return b, true, err
+}
+
+// readNumber 读取整数或浮点数，返回值为 int64、uint64 或 float64
+func (d *msgpackDecoder) readNumber(tag byte) (any, bool, error) {
+	if tag <= 0x7f {
+		return int64(tag), true, nil
+	}
+	if tag >= 0xe0 {
+		return int64(int8(tag)), true, nil
+	}
+	var size int
+	switch tag {
+	case mpUint8, mpInt8:
+		size = 1
+	case mpUint16, mpInt16:
+		size = 2
+	case mpUint32, mpInt32, mpFloat32:
+		size = 4
+	case mpUint64, mpInt64, mpFloat64:
+		size = 8
+	default:
+		return nil, false, nil
+	}
+	b, err := d.read(size)
+	if err != nil {
+		return nil, true, err
+	}
+	switch tag {
+	case mpUint8:
+		return int64(b[0]), true, nil
+	case mpUint16:
+		return int64(binary.BigEndian.Uint16(b)), true, nil
+	case mpUint32:
+		return int64(binary.BigEndian.Uint32(b)), true, nil
+	case mpUint64:
+		u := binary.BigEndian.Uint64(b)
+		if u > math.MaxInt64 {
+			return u, true, nil
+		}
+		return int64(u), true, nil
+	case mpInt8:
+		return int64(int8(b[0])), true, nil
+	case mpInt16:
+		return int64(int16(binary.BigEndian.Uint16(b))), true, nil
+	case mpInt32:
+		return int64(int32(binary.BigEndian.Uint32(b))), true, nil
+	case mpInt64:
+		return int64(binary.BigEndian.Uint64(b)), true, nil
+	case mpFloat32:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), true, nil
+	default:
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), true, nil
+	}
+}
+
+// decodeAny 解码为通用类型
+func (d *msgpackDecoder) decodeAny() (any, error) {
+	tag, err := d.peek()
+	if err != nil {
+		return nil, err
+	}
+	d.pos++
+
+	switch tag {
+	case mpNil:
+		return nil, nil
+	case mpFalse:
+		return false, nil
+	case mpTrue:
+		return true, nil
+	}
+	if n, ok, err := d.readNumber(tag); ok {
+		return n, err
+	}
+	if b, ok, err := d.readBytes(tag); ok {
+		if err != nil {
+			return nil, err
+		}
+		if tag == mpBin8 || tag == mpBin16 || tag == mpBin32 {
+			return append([]byte(nil), b...), nil
+		}
+		return string(b), nil
+	}
+	if n, ok, err := d.readCollection(tag, mpFixArray, mpArray16, mpArray32, 1); ok {
+		if err != nil {
+			return nil, err
+		}
+		arr := make([]any, n)
+		for i := range arr {
+			if arr[i], err = d.decodeAny(); err != nil {
+				return nil, err
+			}
+		}
+		return arr, nil
+	}
+	if n, ok, err := d.readCollection(tag, mpFixMap, mpMap16, mpMap32, 2); ok {
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[string]any, n)
+		for i := 0; i < n; i++ {
+			k, err := d.decodeAny()
+			if err != nil {
+				return nil, err
+			}
+			if m[fmt.Sprint(k)], err = d.decodeAny(); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	}
+	return nil, fmt.Errorf("msgpack: unsupported type 0x%02x", tag)
+}
+
+// decode 解码到 v，v 必须可设置
+func (d *msgpackDecoder) decode(v reflect.Value) error {
+	tag, err := d.peek()
+	if err != nil {
+		return err
+	}
+	if tag == mpNil {
+		d.pos++
+		v.SetZero()
+		return nil
+	}
+
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return d.decode(v.Elem())
+	}
+	if reflect.PointerTo(v.Type()).Implements(binaryUnmarshalerType) {
+		d.pos++
+		b, ok, err := d.readBytes(tag)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return d.mismatch(tag, v.Type())
+		}
+		return v.Addr().Interface().(encoding.BinaryUnmarshaler).UnmarshalBinary(b)
+	}
+	if v.Kind() == reflect.Interface && v.NumMethod() == 0 {
+		a, err := d.decodeAny()
+		if err != nil {
+			return err
+		}
+		if a != nil {
+			v.Set(reflect.ValueOf(a))
+		}
+		return nil
+	}
+
+	d.pos++
+	switch v.Kind() {
+	case reflect.Bool:
+		if tag != mpTrue && tag != mpFalse {
+			return d.mismatch(tag, v.Type())
+		}
+		v.SetBool(tag == mpTrue)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := d.numberOf(tag, v.Type())
+		if err != nil {
+			return err
+		}
+		i, ok := n.(int64)
+		if !ok || v.OverflowInt(i) {
+			return fmt.Errorf("msgpack: value %v overflows %s", n, v.Type())
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := d.numberOf(tag, v.Type())
+		if err != nil {
+			return err
+		}
+		var u uint64
+		switch n := n.(type) {
+		case int64:
+			if n < 0 {
+				return fmt.Errorf("msgpack: value %d overflows %s", n, v.Type())
+			}
+			u = uint64(n)
+		case uint64:
+			u = n
+		default:
+			return fmt.Errorf("msgpack: value %v overflows %s", n, v.Type())
+		}
+		if v.OverflowUint(u) {
+			return fmt.Errorf("msgpack: value %d overflows %s", u, v.Type())
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		n, err := d.numberOf(tag, v.Type())
+		if err != nil {
+			return err
+		}
+		switch n := n.(type) {
+		case int64:
+			v.SetFloat(float64(n))
+		case uint64:
+			v.SetFloat(float64(n))
+		case float64:
+			v.SetFloat(n)
+		}
+	case reflect.String:
+		b, ok, err := d.readBytes(tag)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return d.mismatch(tag, v.Type())
+		}
+		v.SetString(string(b))
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b, ok, err := d.readBytes(tag)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return d.mismatch(tag, v.Type())
+			}
+			v.SetBytes(append([]byte(nil), b...))
+			return nil
+		}
+		n, ok, err := d.readCollection(tag, mpFixArray, mpArray16, mpArray32, 1)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return d.mismatch(tag, v.Type())
+		}
+		v.Set(reflect.MakeSlice(v.Type(), n, n))
+		for i := 0; i < n; i++ {
+			if err := d.decode(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Array:
+		n, ok, err := d.readCollection(tag, mpFixArray, mpArray16, mpArray32, 1)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return d.mismatch(tag, v.Type())
+		}
+		if n != v.Len() {
+			return fmt.Errorf("msgpack: array length %d does not match %s", n, v.Type())
+		}
+		for i := 0; i < n; i++ {
+			if err := d.decode(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		n, ok, err := d.readCollection(tag, mpFixMap, mpMap16, mpMap32, 2)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return d.mismatch(tag, v.Type())
+		}
+		m := reflect.MakeMapWithSize(v.Type(), n)
+		for i := 0; i < n; i++ {
+			key := reflect.New(v.Type().Key()).Elem()
+			if err := d.decode(key); err != nil {
+				return err
+			}
+			val := reflect.New(v.Type().Elem()).Elem()
+			if err := d.decode(val); err != nil {
+				return err
+			}
+			m.SetMapIndex(key, val)
+		}
+		v.Set(m)
+	case reflect.Struct:
+		return d.decodeStruct(tag, v)
+	default:
+		return fmt.Errorf("msgpack: unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func (d *msgpackDecoder) decodeStruct(tag byte, v reflect.Value) error {
+	n, ok, err := d.readCollection(tag, mpFixMap, mpMap16, mpMap32, 2)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return d.mismatch(tag, v.Type())
+	}
+	fields := msgpackFields(v.Type())
+	for i := 0; i < n; i++ {
+		var name string
+		if err := d.decode(reflect.ValueOf(&name).Elem()); err != nil {
+			return err
+		}
+		idx := -1
+		for j := range fields {
+			if fields[j].name == name {
+				idx = j
+				break
+			}
+		}
+		if idx < 0 {
+			// 忽略未知字段
+			if _, err := d.decodeAny(); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := d.decode(v.FieldByIndex(fields[idx].index)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *msgpackDecoder) numberOf(tag byte, t reflect.Type) (any, error) {
+	n, ok, err := d.readNumber(tag)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, d.mismatch(tag, t)
+	}
+	return n, nil
+}
+
+func (d *msgpackDecoder) mismatch(tag byte, t reflect.Type) error {
+	return fmt.Errorf("msgpack: cannot decode type 0x%02x into %s", tag, t)
+}
@@ -0,0 +1,154 @@
+package cache
+
+import (
+	"encoding"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type msgpackBase struct {
+	ID   int64
+	Name string
+}
+
+type msgpackItem struct {
+	msgpackBase
+	Name    string            `msgpack:"name"`
+	Note    string            `msgpack:"note,omitempty"`
+	Secret  string            `msgpack:"-"`
+	Ratio   float32           `msgpack:"ratio"`
+	Counts  map[string]uint16 `msgpack:"counts"`
+	Data    []byte            `msgpack:"data"`
+	Point   [2]int8           `msgpack:"point"`
+	Parent  *msgpackItem      `msgpack:"parent"`
+	Extra   any               `msgpack:"extra"`
+	private int
+}
+
+func TestMsgpack_Encoding(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  []byte
+	}{
+		{name: "nil", value: nil, want: []byte{0xc0}},
+		{name: "true", value: true, want: []byte{0xc3}},
+		{name: "positive fixint", value: 127, want: []byte{0x7f}},
+		{name: "negative fixint", value: -32, want: []byte{0xe0}},
+		{name: "uint8", value: 200, want: []byte{0xcc, 0xc8}},
+		{name: "int8", value: -100, want: []byte{0xd0, 0x9c}},
+		{name: "uint16", value: 1000, want: []byte{0xcd, 0x03, 0xe8}},
+		{name: "int64", value: int64(math.MinInt64), want: []byte{0xd3, 0x80, 0, 0, 0, 0, 0, 0, 0}},
+		{name: "float64", value: 1.5, want: []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{name: "fixstr", value: "abc", want: []byte{0xa3, 'a', 'b', 'c'}},
+		{name: "bin", value: []byte{1, 2}, want: []byte{0xc4, 0x02, 1, 2}},
+		{name: "fixarray", value: []int{1, 2}, want: []byte{0x92, 0x01, 0x02}},
+		{name: "fixmap", value: map[string]bool{"a": false}, want: []byte{0x81, 0xa1, 'a', 0xc2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := msgpackMarshal(tt.value)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMsgpack_RoundTrip(t *testing.T) {
+	item := msgpackItem{
+		msgpackBase: msgpackBase{ID: -70000},
+		Name:        "outer",
+		Secret:      "hidden",
+		Ratio:       0.25,
+		Counts:      map[string]uint16{"a": 1, "b": 65535},
+		Data:        []byte("raw"),
+		Point:       [2]int8{-1, 1},
+		Parent:      &msgpackItem{Name: "parent", Note: "note"},
+		Extra:       []any{"x", int64(1), 2.5, nil},
+	}
+
+	data, err := msgpackMarshal(item)
+	assert.NoError(t, err)
+
+	var got msgpackItem
+	assert.NoError(t, msgpackUnmarshal(data, &got))
+	item.Secret = ""
+	assert.Equal(t, item, got)
+
+	// 未登记类型解码为通用类型，嵌入结构体的字段被展开
+	s := NewMsgpackSerializer()
+	generic, err := s.Deserialize("k", data)
+	assert.NoError(t, err)
+	m := generic.(map[string]any)
+	assert.Equal(t, int64(-70000), m["ID"])
+	assert.Equal(t, "outer", m["name"])
+	assert.Equal(t, "", m["Name"])
+	assert.NotContains(t, m, "note")
+	assert.Equal(t, "note", m["parent"].(map[string]any)["note"])
+}
+
+func TestMsgpack_FieldShadowing(t *testing.T) {
+	type outer struct {
+		msgpackBase
+		ID string
+	}
+	data, err := msgpackMarshal(outer{msgpackBase: msgpackBase{ID: 1, Name: "base"}, ID: "outer"})
+	assert.NoError(t, err)
+
+	var got map[string]any
+	assert.NoError(t, msgpackUnmarshal(data, &got))
+	assert.Equal(t, map[string]any{"ID": "outer", "Name": "base"}, got)
+}
+
+func TestMsgpack_NilMarshaler(t *testing.T) {
+	type holder struct {
+		Value encoding.BinaryMarshaler
+		Time  *time.Time
+	}
+	data, err := msgpackMarshal(holder{})
+	assert.NoError(t, err)
+
+	var got map[string]any
+	assert.NoError(t, msgpackUnmarshal(data, &got))
+	assert.Equal(t, map[string]any{"Value": nil, "Time": nil}, got)
+}
+
+func TestMsgpack_LongValues(t *testing.T) {
+	long := make([]string, 70000)
+	for i := range long {
+		long[i] = "v"
+	}
+	data, err := msgpackMarshal(long)
+	assert.NoError(t, err)
+	assert.Equal(t, byte(0xdd), data[0])
+
+	var got []string
+	assert.NoError(t, msgpackUnmarshal(data, &got))
+	assert.Equal(t, long, got)
+}
+
+func TestMsgpack_DecodeErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		target any
+	}{
+		{name: "truncated", data: []byte{0xa3, 'a'}, target: new(string)},
+		{name: "type mismatch", data: []byte{0xa1, 'a'}, target: new(int)},
+		{name: "overflow", data: []byte{0xcd, 0x03, 0xe8}, target: new(int8)},
+		{name: "negative into uint", data: []byte{0xff}, target: new(uint)},
+		{name: "array length", data: []byte{0x91, 0x01}, target: new([2]int)},
+		{name: "huge length", data: []byte{0xdd, 0xff, 0xff, 0xff, 0xff}, target: new([]int)},
+		{name: "trailing bytes", data: []byte{0x01, 0x02}, target: new(int)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Error(t, msgpackUnmarshal(tt.data, tt.target))
+		})
+	}
+}
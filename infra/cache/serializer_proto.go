@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"fmt"
+	"reflect"
+
+	"google.golang.org/protobuf/proto"
+)
+
+var _ Serializer = (*ProtoSerializer)(nil)
+
+var protoMessageType = reflect.TypeFor[proto.Message]()
+
+// ProtoSerializer 序列化 proto.Message，反序列化前必须登记目标消息类型，如 (*pb.User)(nil)
+type ProtoSerializer struct {
+	registry
+}
+
+// NewProtoSerializer 创建 ProtoSerializer
+func NewProtoSerializer() *ProtoSerializer {
+	return &ProtoSerializer{}
+}
+
+// Register 登记 key 以 prefix 开头时反序列化的消息类型，target 必须实现 proto.Message
+func (s *ProtoSerializer) Register(prefix string, target proto.Message) {
+	s.registry.Register(prefix, target)
+}
+
+func (s *ProtoSerializer) Serialize(key string, data interface{}) ([]byte, error) {
+	msg, ok := data.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("cache value is not proto.Message. key=%s, type=%T", key, data)
+	}
+	return proto.Marshal(msg)
+}
+
+func (s *ProtoSerializer) Deserialize(key string, data any) (any, error) {
+	b, err := toBytes(key, data)
+	if err != nil {
+		return nil, err
+	}
+	t, ok := s.lookup(key)
+	if !ok || !t.Implements(protoMessageType) {
+		return nil, newTypeNotRegisteredError(key)
+	}
+	msg := reflect.New(t.Elem()).Interface().(proto.Message)
+	if err := proto.Unmarshal(b, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
@@ -0,0 +1,179 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type serializerUser struct {
+	ID      int
+	Name    string
+	Tags    []string
+	Created time.Time
+}
+
+type registrar interface {
+	Serializer
+	Register(prefix string, target any)
+}
+
+func TestSerializer_RoundTrip(t *testing.T) {
+	user := serializerUser{ID: 1, Name: "gopkg", Tags: []string{"a", "b"}, Created: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+
+	tests := []struct {
+		name       string
+		serializer registrar
+	}{
+		{name: "json", serializer: NewJSONSerializer()},
+		{name: "gob", serializer: NewGobSerializer()},
+		{name: "msgpack", serializer: NewMsgpackSerializer()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.serializer.Register("user:", serializerUser{})
+			tt.serializer.Register("user:ptr:", (*serializerUser)(nil))
+
+			data, err := tt.serializer.Serialize("user:1", user)
+			assert.NoError(t, err)
+			got, err := tt.serializer.Deserialize("user:1", data)
+			assert.NoError(t, err)
+			assert.Equal(t, user, got)
+
+			// 最长前缀匹配，且兼容 SqliteCache 返回的 string
+			got, err = tt.serializer.Deserialize("user:ptr:1", string(data))
+			assert.NoError(t, err)
+			assert.Equal(t, &user, got)
+
+			_, err = tt.serializer.Deserialize("user:1", 1)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSerializer_Unregistered(t *testing.T) {
+	data, err := NewJSONSerializer().Serialize("k", map[string]any{"id": 1})
+	assert.NoError(t, err)
+	got, err := NewJSONSerializer().Deserialize("k", data)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"id": float64(1)}, got)
+
+	data, err = NewGobSerializer().Serialize("k", serializerUser{})
+	assert.NoError(t, err)
+	_, err = NewGobSerializer().Deserialize("k", data)
+	assert.ErrorIs(t, err, TypeNotRegisteredError)
+}
+
+func TestProtoSerializer(t *testing.T) {
+	s := NewProtoSerializer()
+	s.Register("name:", (*wrapperspb.StringValue)(nil))
+	s.Register("doc:", (*structpb.Struct)(nil))
+
+	data, err := s.Serialize("name:1", wrapperspb.String("gopkg"))
+	assert.NoError(t, err)
+	got, err := s.Deserialize("name:1", data)
+	assert.NoError(t, err)
+	assert.Equal(t, "gopkg", got.(*wrapperspb.StringValue).GetValue())
+
+	doc, err := structpb.NewStruct(map[string]any{"id": 1.0, "tags": []any{"a"}})
+	assert.NoError(t, err)
+	data, err = s.Serialize("doc:1", doc)
+	assert.NoError(t, err)
+	got, err = s.Deserialize("doc:1", data)
+	assert.NoError(t, err)
+	assert.True(t, proto.Equal(doc, got.(proto.Message)))
+
+	_, err = s.Serialize("name:1", "not proto")
+	assert.Error(t, err)
+	_, err = s.Deserialize("other", data)
+	assert.ErrorIs(t, err, TypeNotRegisteredError)
+}
+
+func TestGzipSerializer(t *testing.T) {
+	inner := NewJSONSerializer()
+	inner.Register("", "")
+	s := NewGzipSerializer(inner, 64)
+
+	tests := []struct {
+		name       string
+		value      string
+		compressed bool
+	}{
+		{name: "below threshold", value: "short", compressed: false},
+		{name: "above threshold", value: strings.Repeat("gopkg", 100), compressed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := s.Serialize("k", tt.value)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.compressed, data[0] == gzipFlagCompressed)
+			if tt.compressed {
+				assert.Less(t, len(data), len(tt.value))
+			}
+
+			got, err := s.Deserialize("k", data)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.value, got)
+		})
+	}
+
+	_, err := s.Deserialize("k", []byte{9})
+	assert.Error(t, err)
+}
+
+func TestVersionedSerializer(t *testing.T) {
+	inner := NewJSONSerializer()
+	inner.Register("", serializerUser{})
+	v1 := NewVersionedSerializer(inner, 1)
+	v2 := NewVersionedSerializer(inner, 2)
+
+	data, err := v1.Serialize("user:1", serializerUser{ID: 1})
+	assert.NoError(t, err)
+	got, err := v1.Deserialize("user:1", data)
+	assert.NoError(t, err)
+	assert.Equal(t, serializerUser{ID: 1}, got)
+
+	_, err = v2.Deserialize("user:1", data)
+	assert.ErrorIs(t, err, VersionMismatchError)
+	assert.ErrorIs(t, err, KeyNotExistsError)
+
+	// 引入信封之前写入的数据
+	_, err = v1.Deserialize("user:1", []byte(`{"ID":1}`))
+	assert.ErrorIs(t, err, VersionMismatchError)
+}
+
+func TestSerializer_WithTyped(t *testing.T) {
+	ctx := context.Background()
+	inner := NewMsgpackSerializer()
+	inner.Register("user:", serializerUser{})
+	c := NewLocalCache(NewVersionedSerializer(NewGzipSerializer(inner, 32), 1))
+
+	// Serializer 负责编码，Typed 只做类型转换
+	users := NewTypedPassthrough[serializerUser](c)
+	want := serializerUser{ID: 1, Name: strings.Repeat("gopkg", 20)}
+	assert.NoError(t, users.Set(ctx, "user:1", want, time.Hour))
+
+	got, err := users.Get(ctx, "user:1")
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	// 旧版本数据按未命中处理，GetOrLoad 重新加载
+	old := NewLocalCache(NewVersionedSerializer(inner, 0))
+	assert.NoError(t, old.Set(ctx, "user:1", want, time.Hour))
+	e, _ := old.(*localCache).store.peek("user:1")
+	c.(*localCache).store.store("user:1", e)
+
+	got, err = users.GetOrLoad(ctx, "user:1", func(ctx context.Context) (serializerUser, error) {
+		return serializerUser{ID: 2}, nil
+	}, time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, serializerUser{ID: 2}, got)
+}
@@ -0,0 +1,123 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	gzipFlagRaw byte = iota
+	gzipFlagCompressed
+)
+
+// versionMagic 版本信封的首字节，用于识别引入信封之前写入的数据
+const versionMagic byte = 'V'
+
+var (
+	_ Serializer = (*gzipSerializer)(nil)
+	_ Serializer = (*versionedSerializer)(nil)
+)
+
+type gzipSerializer struct {
+	inner     Serializer
+	threshold int
+}
+
+// NewGzipSerializer 包装 inner，序列化结果超过 threshold 字节时使用 gzip 压缩。
+// 输出首字节标记是否压缩，因此 threshold 可以随时调整
+func NewGzipSerializer(inner Serializer, threshold int) Serializer {
+	return &gzipSerializer{inner: inner, threshold: threshold}
+}
+
+func (s *gzipSerializer) Serialize(key string, data interface{}) ([]byte, error) {
+	b, err := s.inner.Serialize(key, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) <= s.threshold {
+		return append([]byte{gzipFlagRaw}, b...), nil
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(gzipFlagCompressed)
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(b); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *gzipSerializer) Deserialize(key string, data any) (any, error) {
+	b, err := toBytes(key, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, fmt.Errorf("cache data is empty. key=%s", key)
+	}
+
+	switch b[0] {
+	case gzipFlagRaw:
+		return s.inner.Deserialize(key, b[1:])
+	case gzipFlagCompressed:
+		r, err := gzip.NewReader(bytes.NewReader(b[1:]))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		raw, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		return s.inner.Deserialize(key, raw)
+	default:
+		return nil, fmt.Errorf("cache data has unknown compression flag. key=%s, flag=%d", key, b[0])
+	}
+}
+
+type versionedSerializer struct {
+	inner   Serializer
+	version uint64
+}
+
+// NewVersionedSerializer 包装 inner，在序列化结果前写入 version。
+// 反序列化时版本不一致返回 VersionMismatchError，该错误同时匹配 KeyNotExistsError，
+// 修改缓存值结构后升级 version 即可让旧数据按未命中处理
+func NewVersionedSerializer(inner Serializer, version uint64) Serializer {
+	return &versionedSerializer{inner: inner, version: version}
+}
+
+func (s *versionedSerializer) Serialize(key string, data interface{}) ([]byte, error) {
+	b, err := s.inner.Serialize(key, data)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(b)+1+binary.MaxVarintLen64)
+	out = append(out, versionMagic)
+	out = binary.AppendUvarint(out, s.version)
+	return append(out, b...), nil
+}
+
+func (s *versionedSerializer) Deserialize(key string, data any) (any, error) {
+	b, err := toBytes(key, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 || b[0] != versionMagic {
+		return nil, newVersionMismatchError(key, s.version, 0)
+	}
+	version, n := binary.Uvarint(b[1:])
+	if n <= 0 {
+		return nil, newVersionMismatchError(key, s.version, 0)
+	}
+	if version != s.version {
+		return nil, newVersionMismatchError(key, s.version, version)
+	}
+	return s.inner.Deserialize(key, b[1+n:])
+}
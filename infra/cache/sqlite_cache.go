@@ -13,7 +13,8 @@ var _ Cache = (*SqliteCache)(nil)
 
 // SqliteCache 使用 SQLite 数据库实现 Cache 接口。
 type SqliteCache struct {
-	db *sql.DB
+	db    *sql.DB
+	loads loadGroup
 }
 
 // NewSqliteCache 创建一个新的 SqliteCache 实例。
@@ -29,12 +30,23 @@ func NewSqliteCache(dbPath string) (*SqliteCache, error) {
 		CREATE TABLE IF NOT EXISTS cache (
 			key TEXT PRIMARY KEY,
 			value TEXT,
-			expires_at INTEGER
+			expires_at INTEGER,
+			delta INTEGER NOT NULL DEFAULT 0
 		)
 	`)
 	if err != nil {
 		return nil, err
 	}
+	// 旧版本创建的表没有 delta 列（上次加载耗时，毫秒）
+	hasDelta, err := sqliteHasColumn(db, "cache", "delta")
+	if err != nil {
+		return nil, err
+	}
+	if !hasDelta {
+		if _, err := db.Exec(`ALTER TABLE cache ADD COLUMN delta INTEGER NOT NULL DEFAULT 0`); err != nil {
+			return nil, err
+		}
+	}
 
 	cache := &SqliteCache{db: db}
 
@@ -44,8 +56,32 @@ func NewSqliteCache(dbPath string) (*SqliteCache, error) {
 	return cache, nil
 }
 
+// sqliteHasColumn 通过 PRAGMA table_info 检查表中是否存在指定的列
+func sqliteHasColumn(db *sql.DB, table, column string) (bool, error) {
+	rows, err := db.Query(`SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
 // Set 将一个键值对和 TTL 添加到缓存中。
 func (c *SqliteCache) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
+	return c.set(ctx, key, value, ttl, 0)
+}
+
+func (c *SqliteCache) set(ctx context.Context, key string, value any, ttl, delta time.Duration) error {
 	var expiresAt int64
 	if ttl > 0 {
 		expiresAt = time.Now().Add(ttl).Unix()
@@ -53,8 +89,8 @@ func (c *SqliteCache) Set(ctx context.Context, key string, value any, ttl time.D
 	// expiresAt 为 0 表示永不过期
 
 	_, err := c.db.ExecContext(ctx, `
-		REPLACE INTO cache (key, value, expires_at) VALUES (?, ?, ?)
-	`, key, value, expiresAt)
+		REPLACE INTO cache (key, value, expires_at, delta) VALUES (?, ?, ?, ?)
+	`, key, value, expiresAt, delta.Milliseconds())
 	return err
 }
 
@@ -140,6 +176,49 @@ func (c *SqliteCache) SetWithFunc(ctx context.Context, key string, fn func() (an
 	return val, nil
 }
 
+// GetOrLoad 返回缓存中的值，未命中时调用 loader 加载并写入缓存，同一 key 的并发加载只执行一次。
+// 命中和加载时都返回 string，即写入数据库后读出的值
+func (c *SqliteCache) GetOrLoad(ctx context.Context, key string, loader LoaderFunc, ttl time.Duration, opts ...LoadOption) (any, error) {
+	s := newLoadSettings(opts)
+	var (
+		value     string
+		expiresAt int64
+		delta     int64
+	)
+	err := c.db.QueryRowContext(ctx, `
+		SELECT value, expires_at, delta FROM cache WHERE key = ?
+	`, key).Scan(&value, &expiresAt, &delta)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if err == nil {
+		now := time.Now()
+		expire := time.Unix(expiresAt, 0)
+		if expiresAt == 0 || (!now.After(expire) && !s.refreshEarly(expire, time.Duration(delta)*time.Millisecond, now)) {
+			return value, nil
+		}
+	}
+
+	return c.loads.do(ctx, key, func(ctx context.Context) (any, error) {
+		v, delta, err := timedLoad(ctx, loader)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.set(ctx, key, v, ttl, delta); err != nil {
+			return nil, err
+		}
+		// 读回写入后的值，保证与命中时的类型一致
+		var value string
+		err = c.db.QueryRowContext(ctx, `
+			SELECT value FROM cache WHERE key = ?
+		`, key).Scan(&value)
+		if err == sql.ErrNoRows {
+			return nil, newKeyNotExistsError(key)
+		}
+		return value, err
+	})
+}
+
 // cleanupExpiredKeys 定期从数据库中删除过期的键。
 func (c *SqliteCache) cleanupExpiredKeys() {
 	ticker := time.NewTicker(1 * time.Minute) // 每分钟清理一次
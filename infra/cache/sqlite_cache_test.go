@@ -2,6 +2,8 @@ package cache
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"testing"
@@ -39,6 +41,34 @@ func TestNewSqliteCache_InvalidPath(t *testing.T) {
 	}
 }
 
+func TestNewSqliteCache_MigrateDelta(t *testing.T) {
+	dbPath := createTempDB(t)
+
+	// 旧版本创建的表没有 delta 列
+	db, err := sql.Open("sqlite3", dbPath)
+	assert.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE cache (key TEXT PRIMARY KEY, value TEXT, expires_at INTEGER)`)
+	assert.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO cache (key, value, expires_at) VALUES ('old', 'v', 0)`)
+	assert.NoError(t, err)
+	assert.NoError(t, db.Close())
+
+	for i := 0; i < 2; i++ {
+		cache, err := NewSqliteCache(dbPath)
+		assert.NoError(t, err)
+		hasDelta, err := sqliteHasColumn(cache.db, "cache", "delta")
+		assert.NoError(t, err)
+		assert.True(t, hasDelta)
+
+		v, err := cache.GetOrLoad(context.Background(), "old", func(ctx context.Context) (any, error) {
+			return nil, errors.New("should not load")
+		}, time.Hour)
+		assert.NoError(t, err)
+		assert.Equal(t, "v", v)
+		assert.NoError(t, cache.Close())
+	}
+}
+
 func TestSqliteCache_Set_Get(t *testing.T) {
 	ctx := context.Background()
 	dbPath := createTempDB(t)
@@ -0,0 +1,202 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Codec 负责 V 与缓存中字节数据之间的转换
+type Codec[V any] interface {
+	Encode(value V) ([]byte, error)
+	Decode(data []byte) (V, error)
+}
+
+// JSONCodec 使用 encoding/json 编解码，是 Typed 的默认 Codec
+type JSONCodec[V any] struct{}
+
+func (JSONCodec[V]) Encode(value V) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (JSONCodec[V]) Decode(data []byte) (V, error) {
+	var v V
+	err := json.Unmarshal(data, &v)
+	return v, err
+}
+
+// TypeMismatchError 缓存中的值无法转换为期望的类型
+type TypeMismatchError struct {
+	Key string
+	// Expected 期望的类型
+	Expected string
+	// Actual 缓存中实际的类型
+	Actual string
+	// Err 解码失败时的原始错误
+	Err error
+}
+
+func (e *TypeMismatchError) Error() string {
+	msg := fmt.Sprintf("cache value type mismatch. key=%s, expected=%s, actual=%s", e.Key, e.Expected, e.Actual)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *TypeMismatchError) Unwrap() error {
+	return e.Err
+}
+
+// rawCache 由带 Serializer 的缓存实现，Typed 通过它直接存取 Codec 编码后的字节，避免再经 Serializer 编码一次
+type rawCache interface {
+	getRaw(ctx context.Context, key string) (any, error)
+	setRaw(ctx context.Context, key string, data []byte, ttl time.Duration) error
+}
+
+// rawLoadingCache 是不经过 Serializer 的 GetOrLoad，loader 返回 []byte
+type rawLoadingCache interface {
+	getOrLoadRaw(ctx context.Context, key string, loader LoaderFunc, ttl time.Duration, opts ...LoadOption) (any, error)
+}
+
+// Typed 在 Cache 之上提供类型安全的读写，值通过 Codec 编码为 []byte 后存入底层缓存。
+// 底层缓存配置了 Serializer 时编码后的字节绕过 Serializer 直接存储
+type Typed[V any] struct {
+	cache Cache
+	codec Codec[V]
+	// passthrough 为 true 时不使用 codec，值原样交给底层缓存
+	passthrough bool
+}
+
+// NewTyped 创建 Typed，codec 为 nil 时使用 JSONCodec
+func NewTyped[V any](cache Cache, codec Codec[V]) *Typed[V] {
+	if codec == nil {
+		codec = JSONCodec[V]{}
+	}
+	return &Typed[V]{cache: cache, codec: codec}
+}
+
+// NewTypedPassthrough 创建不做编码的 Typed，值原样交给底层缓存，由底层缓存的 Serializer 负责序列化，
+// 适用于 Serializer 已登记目标类型的场景
+func NewTypedPassthrough[V any](cache Cache) *Typed[V] {
+	return &Typed[V]{cache: cache, passthrough: true}
+}
+
+// Get 获取并解码 key 对应的值，key 不存在时返回 KeyNotExistsError，类型不符时返回 *TypeMismatchError
+func (t *Typed[V]) Get(ctx context.Context, key string) (V, error) {
+	var zero V
+	var raw any
+	var err error
+	if rc, ok := t.cache.(rawCache); ok && !t.passthrough {
+		raw, err = rc.getRaw(ctx, key)
+	} else {
+		raw, err = t.cache.Get(ctx, key)
+	}
+	if err != nil {
+		return zero, err
+	}
+	return t.decode(key, raw)
+}
+
+// Set 编码 value 后写入底层缓存
+func (t *Typed[V]) Set(ctx context.Context, key string, value V, ttl time.Duration) error {
+	if t.passthrough {
+		return t.cache.Set(ctx, key, value, ttl)
+	}
+	data, err := t.codec.Encode(value)
+	if err != nil {
+		return fmt.Errorf("cache encode failed. key=%s: %w", key, err)
+	}
+	if rc, ok := t.cache.(rawCache); ok {
+		return rc.setRaw(ctx, key, data, ttl)
+	}
+	return t.cache.Set(ctx, key, data, ttl)
+}
+
+// GetOrLoad 获取 key 对应的值，key 不存在时调用 loader 加载并写入缓存。
+// 底层缓存实现了 LoadingCache 时同一 key 的并发加载只执行一次，opts 才会生效
+func (t *Typed[V]) GetOrLoad(ctx context.Context, key string, loader func(ctx context.Context) (V, error), ttl time.Duration, opts ...LoadOption) (V, error) {
+	lc, ok := t.cache.(LoadingCache)
+	if !ok {
+		v, err := t.Get(ctx, key)
+		if err == nil || !errors.Is(err, KeyNotExistsError) {
+			return v, err
+		}
+		v, err = loader(ctx)
+		if err != nil {
+			return v, err
+		}
+		if err := t.Set(ctx, key, v, ttl); err != nil {
+			return v, err
+		}
+		return v, nil
+	}
+
+	getOrLoad := lc.GetOrLoad
+	if rc, ok := t.cache.(rawLoadingCache); ok && !t.passthrough {
+		getOrLoad = rc.getOrLoadRaw
+	}
+	raw, err := getOrLoad(ctx, key, func(ctx context.Context) (any, error) {
+		v, err := loader(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if t.passthrough {
+			return v, nil
+		}
+		data, err := t.codec.Encode(v)
+		if err != nil {
+			return nil, fmt.Errorf("cache encode failed. key=%s: %w", key, err)
+		}
+		return data, nil
+	}, ttl, opts...)
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+	return t.decode(key, raw)
+}
+
+// decode 将底层缓存返回的值转换为 V。
+// localCache 返回 []byte 或 Serializer 反序列化后的值，SqliteCache 返回 string
+func (t *Typed[V]) decode(key string, raw any) (V, error) {
+	if t.passthrough {
+		if v, ok := raw.(V); ok {
+			return v, nil
+		}
+		var zero V
+		return zero, t.mismatch(key, raw, nil)
+	}
+
+	var data []byte
+	switch v := raw.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	case V:
+		// Serializer 已经反序列化为 V
+		return v, nil
+	default:
+		var zero V
+		return zero, t.mismatch(key, raw, nil)
+	}
+	v, err := t.codec.Decode(data)
+	if err != nil {
+		var zero V
+		return zero, t.mismatch(key, raw, err)
+	}
+	return v, nil
+}
+
+func (t *Typed[V]) mismatch(key string, raw any, err error) error {
+	return &TypeMismatchError{
+		Key:      key,
+		Expected: reflect.TypeFor[V]().String(),
+		Actual:   fmt.Sprintf("%T", raw),
+		Err:      err,
+	}
+}
@@ -0,0 +1,157 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type typedUser struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestTyped_Get_Set(t *testing.T) {
+	ctx := context.Background()
+	sqlite, err := NewSqliteCache(createTempDB(t))
+	assert.NoError(t, err)
+	defer sqlite.Close()
+
+	tests := []struct {
+		name  string
+		cache Cache
+	}{
+		{name: "local cache", cache: NewLocalCache(nil)},
+		{name: "sqlite cache", cache: sqlite},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := NewTyped[typedUser](tt.cache, nil)
+			want := typedUser{ID: 1, Name: "gopkg"}
+			assert.NoError(t, users.Set(ctx, "user:1", want, time.Hour))
+
+			got, err := users.Get(ctx, "user:1")
+			assert.NoError(t, err)
+			assert.Equal(t, want, got)
+
+			_, err = users.Get(ctx, "user:2")
+			assert.ErrorIs(t, err, KeyNotExistsError)
+
+			names := NewTyped[string](tt.cache, nil)
+			assert.NoError(t, names.Set(ctx, "name", "gopkg", time.Hour))
+			name, err := names.Get(ctx, "name")
+			assert.NoError(t, err)
+			assert.Equal(t, "gopkg", name)
+
+			// 同一个 key 按不同类型读取
+			_, err = names.Get(ctx, "user:1")
+			var mismatch *TypeMismatchError
+			assert.True(t, errors.As(err, &mismatch))
+			assert.Equal(t, "user:1", mismatch.Key)
+			assert.Equal(t, "string", mismatch.Expected)
+			assert.Error(t, mismatch.Err)
+		})
+	}
+}
+
+func TestTyped_TypeMismatch(t *testing.T) {
+	ctx := context.Background()
+	c := NewLocalCache(&mockSerializer{
+		deserializeFunc: func(key string, data any) (any, error) {
+			if key == "int" {
+				return 42, nil
+			}
+			return typedUser{ID: 1}, nil
+		},
+	})
+	assert.NoError(t, c.Set(ctx, "int", []byte("42"), time.Hour))
+	assert.NoError(t, c.Set(ctx, "user", []byte("{}"), time.Hour))
+
+	// 包装后不再实现 rawCache，Typed 读取的是 Serializer 反序列化后的值
+	users := NewTyped[typedUser](struct{ Cache }{c}, nil)
+	got, err := users.Get(ctx, "user")
+	assert.NoError(t, err)
+	assert.Equal(t, typedUser{ID: 1}, got)
+
+	_, err = users.Get(ctx, "int")
+	var mismatch *TypeMismatchError
+	assert.True(t, errors.As(err, &mismatch))
+	assert.Equal(t, "cache value type mismatch. key=int, expected=cache.typedUser, actual=int", err.Error())
+}
+
+func TestTyped_GetOrLoad(t *testing.T) {
+	ctx := context.Background()
+	users := NewTyped[typedUser](NewLocalCache(nil), nil)
+
+	calls := 0
+	loader := func(ctx context.Context) (typedUser, error) {
+		calls++
+		return typedUser{ID: calls, Name: "loaded"}, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		got, err := users.GetOrLoad(ctx, "user:1", loader, time.Hour)
+		assert.NoError(t, err)
+		assert.Equal(t, typedUser{ID: 1, Name: "loaded"}, got)
+	}
+	assert.Equal(t, 1, calls)
+
+	loadErr := errors.New("load failed")
+	_, err := users.GetOrLoad(ctx, "user:2", func(ctx context.Context) (typedUser, error) {
+		return typedUser{}, loadErr
+	}, time.Hour)
+	assert.ErrorIs(t, err, loadErr)
+	_, err = users.Get(ctx, "user:2")
+	assert.ErrorIs(t, err, KeyNotExistsError)
+}
+
+func TestTyped_Serializer(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name       string
+		serializer Serializer
+	}{
+		{name: "json", serializer: NewJSONSerializer()},
+		{name: "gob", serializer: NewGobSerializer()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := NewTyped[typedUser](NewLocalCache(tt.serializer), nil)
+			want := typedUser{ID: 1, Name: "gopkg"}
+			assert.NoError(t, users.Set(ctx, "user:1", want, time.Hour))
+			got, err := users.Get(ctx, "user:1")
+			assert.NoError(t, err)
+			assert.Equal(t, want, got)
+
+			calls := 0
+			for i := 0; i < 2; i++ {
+				got, err = users.GetOrLoad(ctx, "user:2", func(ctx context.Context) (typedUser, error) {
+					calls++
+					return typedUser{ID: 2}, nil
+				}, time.Hour)
+				assert.NoError(t, err)
+				assert.Equal(t, typedUser{ID: 2}, got)
+			}
+			assert.Equal(t, 1, calls)
+			got, err = users.Get(ctx, "user:2")
+			assert.NoError(t, err)
+			assert.Equal(t, typedUser{ID: 2}, got)
+		})
+	}
+}
+
+func TestTyped_DecodeErrorReturnsZero(t *testing.T) {
+	ctx := context.Background()
+	c := NewLocalCache(nil)
+	assert.NoError(t, c.Set(ctx, "user:1", []byte(`{"id":1,"name":2}`), time.Hour))
+
+	got, err := NewTyped[typedUser](c, nil).Get(ctx, "user:1")
+	var mismatch *TypeMismatchError
+	assert.True(t, errors.As(err, &mismatch))
+	assert.Equal(t, typedUser{}, got)
+}